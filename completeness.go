@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// สถานะของหุ้นหนึ่งตัวในไตรมาสหนึ่ง
+const (
+	cellComplete    = "complete"     // มีงบการเงินและราคา ณ สิ้นไตรมาส
+	cellNoPrice     = "no_price"     // มีงบการเงินแต่ API ไม่มีราคาให้
+	cellPriceFailed = "price_failed" // มีงบการเงินแต่ดึงราคาไม่สำเร็จ
+	cellMissing     = "missing"      // ไม่มีงบการเงินของไตรมาสนี้
+	cellFailed      = "failed"       // ดึงงบการเงินของหุ้นนี้ไม่สำเร็จ
+)
+
+// cellSymbols - ตัวอักษรของแต่ละสถานะใน heat-map
+var cellSymbols = map[string]string{
+	cellComplete:    "#",
+	cellNoPrice:     "+",
+	cellPriceFailed: "!",
+	cellMissing:     ".",
+	cellFailed:      "x",
+}
+
+// SymbolCompleteness - ความครบถ้วนของข้อมูลหุ้นหนึ่งตัว Cells เรียงตาม CompletenessReport.Periods
+type SymbolCompleteness struct {
+	Symbol            string   `json:"symbol"`
+	Cells             []string `json:"cells"`
+	Quarters          int      `json:"quarters"`          // ไตรมาสที่มีงบการเงิน
+	MissingFinancials int      `json:"missingFinancials"` // ไตรมาสที่ไม่มีงบหรือดึงไม่สำเร็จ
+	MissingPrices     int      `json:"missingPrices"`     // ไตรมาสที่มีงบแต่ไม่มีราคา
+	MissingPeriods    []Period `json:"missingPeriods"`
+}
+
+// Gaps - จำนวนช่องที่ข้อมูลไม่ครบ
+func (s SymbolCompleteness) Gaps() int {
+	return s.MissingFinancials + s.MissingPrices
+}
+
+// CompletenessReport - ตาราง หุ้น × ไตรมาสปฏิทิน ของช่วงที่ขอข้อมูล
+type CompletenessReport struct {
+	Periods []Period             `json:"periods"`
+	Symbols []SymbolCompleteness `json:"symbols"`
+}
+
+// completenessBuilder - สะสมข้อมูลทีละหุ้น เพื่อใช้ได้ทั้งกับข้อมูลในหน่วยความจำและ spool ของโหมด -stream
+type completenessBuilder struct {
+	start, end Period
+	latest     Period
+	symbols    map[string]map[Period]bool // หุ้น -> ไตรมาสที่มีงบ -> มีราคาหรือไม่
+	failed     map[string]string          // หุ้น -> ขั้นตอนที่ล้มเหลว (financial มาก่อน price)
+}
+
+func newCompletenessBuilder(start, end Period, fetchErrors []FetchError) *completenessBuilder {
+	b := &completenessBuilder{
+		start:   start,
+		end:     end,
+		symbols: make(map[string]map[Period]bool),
+		failed:  make(map[string]string),
+	}
+	for _, fe := range fetchErrors {
+		b.periods(fe.Symbol)
+		if b.failed[fe.Symbol] != "financial" {
+			b.failed[fe.Symbol] = fe.Stage
+		}
+	}
+	return b
+}
+
+// Add - เพิ่มงบการเงินของหุ้น เก็บเฉพาะงวดที่อยู่ในช่วงที่ขอ
+func (b *completenessBuilder) Add(records []FinancialData) error {
+	for _, item := range records {
+		p := calendarPeriodOf(item)
+		if p.IsZero() || p.Before(b.start) || b.end.Before(p) {
+			continue
+		}
+		if b.latest.Before(p) {
+			b.latest = p
+		}
+		b.periods(item.Symbol)[p] = item.PriceData != nil
+	}
+	return nil
+}
+
+func (b *completenessBuilder) periods(symbol string) map[Period]bool {
+	periods, ok := b.symbols[symbol]
+	if !ok {
+		periods = make(map[Period]bool)
+		b.symbols[symbol] = periods
+	}
+	return periods
+}
+
+// Report - สร้างตาราง ไตรมาสสุดท้ายคือไตรมาสล่าสุดที่มีบริษัทใดรายงานแล้ว
+// เพื่อไม่ให้ไตรมาสที่ยังไม่ถึงกำหนดส่งงบถูกนับเป็นข้อมูลขาดของทุกหุ้น
+func (b *completenessBuilder) Report() CompletenessReport {
+	end := b.end
+	if !b.latest.IsZero() && b.latest.Before(end) {
+		end = b.latest
+	}
+
+	var report CompletenessReport
+	for p := b.start; !end.Before(p); p = p.Add(1) {
+		report.Periods = append(report.Periods, p)
+	}
+
+	for _, symbol := range sortedKeys(b.symbols) {
+		row := SymbolCompleteness{
+			Symbol:         symbol,
+			Cells:          make([]string, len(report.Periods)),
+			MissingPeriods: []Period{},
+		}
+
+		for i, p := range report.Periods {
+			hasPrice, ok := b.symbols[symbol][p]
+			switch {
+			case !ok && b.failed[symbol] == "financial":
+				row.Cells[i] = cellFailed
+			case !ok:
+				row.Cells[i] = cellMissing
+			case hasPrice:
+				row.Cells[i] = cellComplete
+			case b.failed[symbol] == "price":
+				row.Cells[i] = cellPriceFailed
+			default:
+				row.Cells[i] = cellNoPrice
+			}
+
+			switch row.Cells[i] {
+			case cellComplete:
+				row.Quarters++
+			case cellNoPrice, cellPriceFailed:
+				row.Quarters++
+				row.MissingPrices++
+			default:
+				row.MissingFinancials++
+				row.MissingPeriods = append(row.MissingPeriods, p)
+			}
+		}
+		report.Symbols = append(report.Symbols, row)
+	}
+	return report
+}
+
+// BuildCompletenessReport - สร้างตารางความครบถ้วนจากข้อมูลที่ดึงมาและข้อผิดพลาดระหว่างดึง
+func BuildCompletenessReport(data []FinancialData, fetchErrors []FetchError, start, end Period) CompletenessReport {
+	b := newCompletenessBuilder(start, end, fetchErrors)
+	b.Add(data)
+	return b.Report()
+}
+
+// ExportCompletenessCSV - เขียนตาราง หุ้น × ไตรมาส พร้อมสรุปช่องว่างของแต่ละหุ้น
+func ExportCompletenessCSV(report CompletenessReport, filename string) error {
+	columns := []string{"Symbol", "Quarters", "MissingFinancials", "MissingPrices", "MissingPeriods"}
+	for _, p := range report.Periods {
+		columns = append(columns, displayPeriod(p))
+	}
+
+	rows := make([][]string, len(report.Symbols))
+	for i, s := range report.Symbols {
+		missing := make([]string, len(s.MissingPeriods))
+		for j, p := range s.MissingPeriods {
+			missing[j] = displayPeriod(p)
+		}
+		row := []string{
+			s.Symbol, strconv.Itoa(s.Quarters), strconv.Itoa(s.MissingFinancials), strconv.Itoa(s.MissingPrices),
+			strings.Join(missing, " "),
+		}
+		for _, cell := range s.Cells {
+			row = append(row, T("completeness.cell."+cell))
+		}
+		rows[i] = row
+	}
+	return writeReportCSV(filename, columns, rows)
+}
+
+// writeCompletenessHeatmap - แสดงตารางแบบย่อหนึ่งตัวอักษรต่อไตรมาส
+// แสดงเฉพาะหุ้นที่ข้อมูลไม่ครบ เรียงจากขาดมากไปน้อย ไม่เกิน limit ตัว
+func writeCompletenessHeatmap(w io.Writer, report CompletenessReport, limit int) {
+	var gaps []SymbolCompleteness
+	for _, s := range report.Symbols {
+		if s.Gaps() > 0 {
+			gaps = append(gaps, s)
+		}
+	}
+	sort.SliceStable(gaps, func(i, j int) bool { return gaps[i].Gaps() > gaps[j].Gaps() })
+
+	if len(report.Periods) == 0 {
+		return
+	}
+	fmt.Fprint(w, T("completeness.summary", len(report.Symbols)-len(gaps), len(report.Symbols),
+		displayPeriod(report.Periods[0]), displayPeriod(report.Periods[len(report.Periods)-1])))
+	if len(gaps) == 0 {
+		return
+	}
+
+	width := 0
+	for _, s := range gaps {
+		width = max(width, len(s.Symbol))
+	}
+
+	// แถวหัวตารางแสดงปี (สองหลักท้าย) ที่ไตรมาสแรกของแต่ละปี
+	header := []byte(strings.Repeat(" ", len(report.Periods)))
+	for i, p := range report.Periods {
+		if p.Quarter == 1 || (i == 0 && p.Quarter <= 2) {
+			copy(header[i:], fmt.Sprintf("%02d", displayYear(p.Year)%100))
+		}
+	}
+	fmt.Fprintf(w, "%-*s %s\n", width, "", header)
+
+	for i, s := range gaps {
+		if i == limit {
+			fmt.Fprint(w, T("completeness.more", len(gaps)-limit))
+			break
+		}
+		var cells strings.Builder
+		for _, cell := range s.Cells {
+			cells.WriteString(cellSymbols[cell])
+		}
+		fmt.Fprintf(w, "%-*s %s  %d/%d\n", width, s.Symbol, cells.String(), s.Quarters, len(s.Cells))
+	}
+
+	// แถวสุดท้ายคือสัดส่วนหุ้นที่มีข้อมูลครบในแต่ละไตรมาส (0-9, * = 100%)
+	coverage := make([]byte, len(report.Periods))
+	for i := range report.Periods {
+		complete := 0
+		for _, s := range report.Symbols {
+			if s.Cells[i] == cellComplete {
+				complete++
+			}
+		}
+		coverage[i] = byte('0' + complete*10/len(report.Symbols))
+		if complete == len(report.Symbols) {
+			coverage[i] = '*'
+		}
+	}
+	fmt.Fprintf(w, "%-*s %s\n", width, "%", coverage)
+	fmt.Fprint(w, T("completeness.legend"))
+}
@@ -0,0 +1,549 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CVConfig - การตั้งค่า walk-forward cross-validation
+// แต่ละ fold ทดสอบกับวันที่ช่วงถัดไปและฝึกด้วยแถวก่อนหน้าทั้งหมด (expanding) หรือเฉพาะ WindowPeriods วันที่ล่าสุด (rolling)
+// แถว train ที่ห่างจากวันแรกของ fold ไม่เกิน PurgeDays + EmbargoDays ถูกตัดทิ้ง
+// เพราะผลตอบแทนล่วงหน้าของแถวเหล่านั้นคาบเกี่ยวกับช่วงทดสอบ
+type CVConfig struct {
+	Folds           int `json:"folds"`
+	MinTrainPeriods int `json:"min_train_periods"` // จำนวนวันที่ขั้นต่ำก่อน fold แรก
+	WindowPeriods   int `json:"window_periods"`    // 0 = expanding window
+	PurgeDays       int `json:"purge_days"`        // ระยะเวลาของเป้าหมายเป็นวันปฏิทิน
+	EmbargoDays     int `json:"embargo_days"`      // ระยะห่างเพิ่มเติมระหว่าง train กับช่วงทดสอบ
+}
+
+// CVFold - แถว train และแถวทดสอบ (ตำแหน่งใน TrainingMatrix.Rows) ของ fold หนึ่ง
+type CVFold struct {
+	Index                int
+	Train, Test          []int
+	TrainStart, TrainEnd time.Time
+	TestStart, TestEnd   time.Time
+}
+
+// walkForwardFolds - แบ่ง rows (เรียงตามวันที่) เป็น fold ตามวันที่ของแถว
+// วันที่ที่เหลือหลัง MinTrainPeriods แบ่งเป็น Folds ช่วงเท่ากัน เศษยกไปเป็นข้อมูล train ของ fold แรก
+// fold ที่ไม่เหลือแถว train หลังตัดทิ้งจะถูกข้าม
+func walkForwardFolds(m TrainingMatrix, rows []int, cfg CVConfig) ([]CVFold, error) {
+	var dates []time.Time
+	for _, r := range rows {
+		if d := m.Rows[r].SnapshotDate; len(dates) == 0 || !d.Equal(dates[len(dates)-1]) {
+			dates = append(dates, d)
+		}
+	}
+	if cfg.Folds <= 0 || cfg.MinTrainPeriods < 1 || len(dates)-cfg.MinTrainPeriods < cfg.Folds {
+		return nil, errMsg("cv.not_enough_periods", len(dates), cfg.Folds, cfg.MinTrainPeriods)
+	}
+
+	size := (len(dates) - cfg.MinTrainPeriods) / cfg.Folds
+	first := len(dates) - cfg.Folds*size
+	var folds []CVFold
+	for i := 0; i < cfg.Folds; i++ {
+		start := first + i*size
+		fold := CVFold{Index: i + 1, TestStart: dates[start], TestEnd: dates[start+size-1]}
+		trainEnd := fold.TestStart.AddDate(0, 0, -(cfg.PurgeDays + cfg.EmbargoDays))
+		var trainStart time.Time
+		if cfg.WindowPeriods > 0 {
+			trainStart = dates[max(0, start-cfg.WindowPeriods)]
+		}
+		for _, r := range rows {
+			date := m.Rows[r].SnapshotDate
+			switch {
+			case !date.Before(fold.TestStart) && !date.After(fold.TestEnd):
+				fold.Test = append(fold.Test, r)
+			case date.Before(trainEnd) && !date.Before(trainStart):
+				fold.Train = append(fold.Train, r)
+			}
+		}
+		if len(fold.Train) == 0 {
+			logMsg("cv.fold_skipped", fold.Index, fold.TestStart.Format("2006-01-02"))
+			continue
+		}
+		fold.TrainStart = m.Rows[fold.Train[0]].SnapshotDate
+		fold.TrainEnd = m.Rows[fold.Train[len(fold.Train)-1]].SnapshotDate
+		folds = append(folds, fold)
+	}
+	if len(folds) == 0 {
+		return nil, errMsg("cv.not_enough_periods", len(dates), cfg.Folds, cfg.MinTrainPeriods)
+	}
+	return folds, nil
+}
+
+// labelHorizonOf - ระยะเวลาถือครอง (วันทำการ) จากชื่อคอลัมน์ label เช่น label_fwdReturn60 -> 60
+func labelHorizonOf(col string) (int, bool) {
+	name, ok := strings.CutPrefix(col, labelColumnPrefix)
+	if !ok {
+		return 0, false
+	}
+	for _, kind := range labelKinds {
+		if days, ok := strings.CutPrefix(name, kind); ok {
+			if n, err := strconv.Atoi(days); err == nil {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// cv metrics ที่เลือกโมเดลได้ และทิศทางที่ดีกว่า
+var cvMetrics = map[string]bool{ // true = ยิ่งมากยิ่งดี
+	"rmse":              false,
+	"logloss":           false,
+	"auc":               true,
+	"rank_ic":           true,
+	"top_decile_return": true,
+}
+
+// foldMetrics - ตัวชี้วัดของ fold: rmse (regression) หรือ auc และ logloss (classification)
+// รวมกับ rank_ic และ top_decile_return (ผลตอบแทนของหุ้น 10% ที่ได้คะแนนสูงสุด จากคอลัมน์ returns)
+func foldMetrics(task string, m TrainingMatrix, rows []int, y, pred []float64, returns int) map[string]float64 {
+	dates := make([]time.Time, len(rows))
+	ret := make([]float64, len(rows))
+	for i, r := range rows {
+		dates[i] = m.Rows[r].SnapshotDate
+		ret[i] = math.NaN()
+		if returns >= 0 {
+			ret[i] = m.Rows[r].Values[returns]
+		}
+	}
+	metrics := map[string]float64{
+		"rank_ic":           rankIC(dates, y, pred),
+		"top_decile_return": topDecileReturn(dates, ret, pred),
+	}
+	if task == taskClassification {
+		metrics["auc"] = auc(y, pred)
+		metrics["logloss"] = logLoss(y, pred)
+	} else {
+		metrics["rmse"] = rmse(y, pred)
+	}
+	return metrics
+}
+
+// crossValidate - ฝึกและวัดผลทุก fold ด้วยการตั้งค่าเดียวกัน
+// gbdt ใช้แถว train ช่วงท้ายของแต่ละ fold สำหรับ early stopping (ไม่ใช้แถวทดสอบ)
+func crossValidate(m TrainingMatrix, features []int, target, returns int, folds []CVFold, cfg TrainConfig, cv CVConfig, validFraction float64) ([]map[string]float64, error) {
+	results := make([]map[string]float64, len(folds))
+	for i, fold := range folds {
+		train, valid := fold.Train, []int(nil)
+		if cfg.Model == modelGBDT && cfg.GBDT.EarlyStopping > 0 {
+			train, valid = timeOrderedHoldout(m, train, validFraction, cv.PurgeDays+cv.EmbargoDays)
+			if len(train) == 0 {
+				train, valid = fold.Train, nil
+			}
+		}
+		model, err := fitModel(m, features, target, train, valid, cfg)
+		if err != nil {
+			return nil, err
+		}
+		x, y := designRows(m, features, target, fold.Test)
+		pred := make([]float64, len(x))
+		for j := range x {
+			pred[j] = model.Predict(x[j])
+		}
+		results[i] = foldMetrics(cfg.Task, m, fold.Test, y, pred, returns)
+	}
+	return results, nil
+}
+
+// searchParam - ค่าที่จะลองของ flag หนึ่งตัว
+type searchParam struct {
+	Name   string
+	Values []string
+}
+
+// parseSearchSpace - อ่านค่าที่จะลอง เช่น "learning-rate=0.03,0.1;max-depth=3,5"
+// ชื่อต้องเป็น flag ของโมเดล (เช่นเดียวกับคำสั่ง train) ว่าง = ลองเฉพาะการตั้งค่าปัจจุบัน
+func parseSearchSpace(spec string, fs *flag.FlagSet, allowed map[string]bool) ([]searchParam, error) {
+	var params []searchParam
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, values, ok := strings.Cut(part, "=")
+		name = strings.TrimPrefix(strings.TrimSpace(name), "-")
+		if !ok || !allowed[name] {
+			return nil, errMsg("cv.invalid_param", part)
+		}
+		param := searchParam{Name: name}
+		for _, v := range strings.Split(values, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				param.Values = append(param.Values, v)
+			}
+		}
+		if len(param.Values) == 0 {
+			return nil, errMsg("cv.invalid_param", part)
+		}
+		// ตรวจรูปแบบของค่าโดยตั้งแล้วคืนค่าเดิม
+		current := fs.Lookup(name).Value.String()
+		for _, v := range param.Values {
+			if err := fs.Set(name, v); err != nil {
+				return nil, errMsg("cv.invalid_param", part)
+			}
+		}
+		fs.Set(name, current)
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+// searchCandidates - ชุดค่าที่จะลอง แต่ละชุดเป็นค่าของทุก param ตามลำดับ
+// grid ลองทุกชุด random สุ่ม trials ชุดจาก grid โดยไม่ซ้ำด้วย seed ที่กำหนด (ผลเหมือนเดิมทุกครั้ง)
+func searchCandidates(params []searchParam, mode string, trials int, seed int64) ([][]string, error) {
+	total := 1
+	for _, p := range params {
+		total *= len(p.Values)
+	}
+	index := make([]int, total)
+	for i := range index {
+		index[i] = i
+	}
+	switch mode {
+	case "grid":
+	case "random":
+		if trials <= 0 {
+			return nil, errMsg("cv.invalid_trials", trials)
+		}
+		if trials < total {
+			index = rand.New(rand.NewSource(seed)).Perm(total)[:trials]
+			sort.Ints(index)
+		}
+	default:
+		return nil, errMsg("cv.invalid_search", mode)
+	}
+
+	candidates := make([][]string, len(index))
+	for c, i := range index {
+		values := make([]string, len(params))
+		// param สุดท้ายเปลี่ยนเร็วที่สุด
+		for p := len(params) - 1; p >= 0; p-- {
+			values[p] = params[p].Values[i%len(params[p].Values)]
+			i /= len(params[p].Values)
+		}
+		candidates[c] = values
+	}
+	return candidates, nil
+}
+
+// CVCandidate - ผลของการตั้งค่าหนึ่งชุด
+type CVCandidate struct {
+	Index  int                  `json:"candidate"`
+	Params map[string]string    `json:"params"`
+	Args   []string             `json:"args"` // flag ของคำสั่ง train ที่ให้การตั้งค่าเดียวกัน
+	Config TrainConfig          `json:"config"`
+	Folds  []map[string]float64 `json:"-"`
+	Mean   map[string]float64   `json:"mean"`
+}
+
+// meanMetrics - ค่าเฉลี่ยของแต่ละตัวชี้วัดจาก fold ที่คำนวณได้ (ไม่นับ NaN)
+// ตัวชี้วัดที่ไม่มี fold ใดคำนวณได้จะไม่อยู่ในผลลัพธ์
+func meanMetrics(folds []map[string]float64) map[string]float64 {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, metrics := range folds {
+		for name, v := range metrics {
+			if !math.IsNaN(v) {
+				sums[name] += v
+				counts[name]++
+			}
+		}
+	}
+	mean := make(map[string]float64, len(sums))
+	for name, sum := range sums {
+		mean[name] = sum / float64(counts[name])
+	}
+	return mean
+}
+
+// score - ค่าเฉลี่ยของ metric (NaN ถ้าไม่มี fold ใดคำนวณได้)
+func (c CVCandidate) score(metric string) float64 {
+	if v, ok := c.Mean[metric]; ok {
+		return v
+	}
+	return math.NaN()
+}
+
+// bestCandidate - การตั้งค่าที่ค่าเฉลี่ยของ metric ดีที่สุด ถ้าเท่ากันเลือกชุดที่มาก่อน
+func bestCandidate(candidates []CVCandidate, metric string) (int, bool) {
+	best := -1
+	for i, c := range candidates {
+		v := c.score(metric)
+		if math.IsNaN(v) {
+			continue
+		}
+		if best < 0 || (cvMetrics[metric] && v > candidates[best].score(metric)) || (!cvMetrics[metric] && v < candidates[best].score(metric)) {
+			best = i
+		}
+	}
+	return best, best >= 0
+}
+
+// cvMetricOrder - ลำดับคอลัมน์ของตัวชี้วัดในรายงาน
+var cvMetricOrder = []string{"rmse", "auc", "logloss", "rank_ic", "top_decile_return"}
+
+var cvMetricColumns = map[string]string{
+	"rmse":              "RMSE",
+	"auc":               "AUC",
+	"logloss":           "LogLoss",
+	"rank_ic":           "RankIC",
+	"top_decile_return": "TopDecileReturn",
+}
+
+// formatCVDate - วันที่ในรายงาน cv (ว่างถ้าไม่มี)
+func formatCVDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func formatMetric(metrics map[string]float64, name string) string {
+	v, ok := metrics[name]
+	if !ok || math.IsNaN(v) {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', 6, 64)
+}
+
+// formatParams - ค่าของ param ในรูป name=value คั่นด้วยช่องว่าง เรียงตามชื่อ
+func formatParams(params map[string]string) string {
+	var parts []string
+	for _, name := range sortedKeys(params) {
+		parts = append(parts, name+"="+params[name])
+	}
+	return strings.Join(parts, " ")
+}
+
+// ExportCVFolds - เขียนตัวชี้วัดของทุก fold ของทุกการตั้งค่า
+func ExportCVFolds(candidates []CVCandidate, folds []CVFold, filename string) error {
+	columns := []string{"Candidate", "Params", "Fold", "TrainStart", "TrainEnd", "TestStart", "TestEnd", "TrainRows", "TestRows"}
+	for _, name := range cvMetricOrder {
+		columns = append(columns, cvMetricColumns[name])
+	}
+	var records [][]string
+	for _, c := range candidates {
+		for i, fold := range folds {
+			record := []string{
+				strconv.Itoa(c.Index), formatParams(c.Params), strconv.Itoa(fold.Index),
+				formatCVDate(fold.TrainStart), formatCVDate(fold.TrainEnd), formatCVDate(fold.TestStart), formatCVDate(fold.TestEnd),
+				strconv.Itoa(len(fold.Train)), strconv.Itoa(len(fold.Test)),
+			}
+			for _, name := range cvMetricOrder {
+				record = append(record, formatMetric(c.Folds[i], name))
+			}
+			records = append(records, record)
+		}
+	}
+	return writeReportCSV(filename, columns, records)
+}
+
+// ExportCVSearch - เขียนค่าเฉลี่ยของทุกการตั้งค่า เรียงจากดีที่สุดตาม metric
+func ExportCVSearch(candidates []CVCandidate, metric, filename string) error {
+	columns := []string{"Rank", "Candidate", "Params"}
+	for _, name := range cvMetricOrder {
+		columns = append(columns, cvMetricColumns[name])
+	}
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		va, vb := candidates[order[a]].score(metric), candidates[order[b]].score(metric)
+		switch {
+		case math.IsNaN(vb):
+			return !math.IsNaN(va)
+		case math.IsNaN(va):
+			return false
+		case cvMetrics[metric]:
+			return va > vb
+		}
+		return va < vb
+	})
+	records := make([][]string, 0, len(candidates))
+	for rank, i := range order {
+		c := candidates[i]
+		record := []string{strconv.Itoa(rank + 1), strconv.Itoa(c.Index), formatParams(c.Params)}
+		for _, name := range cvMetricOrder {
+			record = append(record, formatMetric(c.Mean, name))
+		}
+		records = append(records, record)
+	}
+	return writeReportCSV(filename, columns, records)
+}
+
+// CVResult - การตั้งค่าที่ดีที่สุดและข้อมูลที่ใช้ทำซ้ำ (<out>_best.json)
+type CVResult struct {
+	CreatedAt  time.Time   `json:"created_at"`
+	Source     string      `json:"source"`
+	Target     string      `json:"target"`
+	Features   string      `json:"features,omitempty"`
+	Metric     string      `json:"metric"`
+	Search     string      `json:"search"`
+	SearchSeed int64       `json:"search_seed"`
+	CV         CVConfig    `json:"cv"`
+	Folds      int         `json:"folds"`
+	Candidates int         `json:"candidates"`
+	Best       CVCandidate `json:"best"`
+}
+
+// WriteCVResult - เขียนผลการค้นหาเป็น JSON
+func WriteCVResult(result CVResult, filename string) error {
+	body, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return errMsg("cv.write", err)
+	}
+	out, err := createOutput(filename)
+	if err != nil {
+		return errMsg("export.create_file", "JSON", err)
+	}
+	defer out.Close()
+	if _, err := out.Write(append(body, '\n')); err != nil {
+		return errMsg("cv.write", err)
+	}
+	if err := out.Close(); err != nil {
+		return errMsg("export.save_file", "JSON", err)
+	}
+	return nil
+}
+
+// runCV - คำสั่ง cv: stock-predict cv [flags] <matrix>
+// walk-forward cross-validation บนแถว train และ valid ของ matrix (ไม่ใช้แถว test) แล้วเลือกการตั้งค่าที่ดีที่สุด
+func runCV(args []string) error {
+	fs := flag.NewFlagSet("cv", flag.ContinueOnError)
+	output := fs.String("out", "cv", "ชื่อไฟล์ผลลัพธ์ (ไม่ต้องใส่นามสกุล) เขียน _folds.csv, _search.csv และ _best.json")
+	target := fs.String("target", labelColumnPrefix+labelName("fwdReturn", 60), "คอลัมน์เป้าหมาย (label_*)")
+	featureSpec := fs.String("features", "", "feature ที่ใช้ คั่นด้วยจุลภาค เป็นชื่อคอลัมน์หรือกลุ่ม ค่าเริ่มต้นคือทุกคอลัมน์ที่ไม่ใช่ label")
+	folds := fs.Int("folds", 5, "จำนวน fold")
+	minTrain := fs.Int("min-train-periods", 8, "จำนวนวันที่ (ไตรมาส) ขั้นต่ำของข้อมูล train ก่อน fold แรก")
+	window := fs.Int("window", 0, "ฝึกด้วยข้อมูลเฉพาะกี่วันที่ล่าสุด (0 = ใช้ทั้งหมดก่อนหน้า)")
+	purge := fs.Int("purge", -1, "ตัดแถว train ที่ห่างจาก fold ไม่เกินกี่วัน (ค่าเริ่มต้นคือระยะเวลาของเป้าหมาย)")
+	embargo := fs.Int("embargo", 0, "ระยะห่างเพิ่มเติมเป็นวันระหว่างข้อมูล train กับ fold")
+	grid := fs.String("grid", "", "ค่าที่จะลอง เช่น \"model=ridge,gbdt;learning-rate=0.03,0.1\" (ชื่อเดียวกับ flag ของโมเดล)")
+	search := fs.String("search", "grid", "วิธีค้นหา: grid (ทุกชุด) หรือ random (สุ่ม -trials ชุดจาก grid)")
+	trials := fs.Int("trials", 20, "จำนวนชุดที่สุ่มลองของ -search random")
+	searchSeed := fs.Int64("search-seed", 1, "seed ของ -search random")
+	metric := fs.String("metric", "rank_ic", "ตัวชี้วัดที่ใช้เลือก (rank_ic, top_decile_return, rmse, auc, logloss)")
+	validFraction := fs.Float64("valid-fraction", 0.2, "สัดส่วนแถว train ช่วงท้ายของแต่ละ fold ที่ใช้ early stopping ของ gbdt")
+
+	// flag ของโมเดลลงทะเบียนแยกเพื่อรู้ว่าชื่อใดใช้ใน -grid ได้
+	before := make(map[string]bool)
+	fs.VisitAll(func(f *flag.Flag) { before[f.Name] = true })
+	config := trainFlags(fs)
+	modelFlags := make(map[string]bool)
+	fs.VisitAll(func(f *flag.Flag) { modelFlags[f.Name] = !before[f.Name] })
+
+	lang := fs.String("lang", "th", "ภาษาของหัวคอลัมน์และข้อความ / language for headers and messages (th, en)")
+	buddhistEra := fs.Bool("buddhist-era", false, "แสดงปีเป็นพุทธศักราช / show years in the Buddhist Era")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := SetLocale(*lang, *buddhistEra); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errMsg("cv.usage")
+	}
+	if _, ok := cvMetrics[*metric]; !ok {
+		return errMsg("cv.invalid_metric", *metric)
+	}
+	params, err := parseSearchSpace(*grid, fs, modelFlags)
+	if err != nil {
+		return err
+	}
+	candidateValues, err := searchCandidates(params, *search, *trials, *searchSeed)
+	if err != nil {
+		return err
+	}
+
+	m, err := ReadMatrix(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	targetIndex, features, _, err := modelInputs(m, *target, *featureSpec)
+	if err != nil {
+		return err
+	}
+	horizon, _ := labelHorizonOf(*target)
+	returns := m.columnIndex(labelColumnPrefix + labelName("fwdReturn", horizon))
+	cv := CVConfig{Folds: *folds, MinTrainPeriods: *minTrain, WindowPeriods: *window, PurgeDays: *purge, EmbargoDays: *embargo}
+	if cv.PurgeDays < 0 {
+		cv.PurgeDays = defaultEmbargoDays([]int{horizon})
+	}
+
+	rows := append(splitRows(m, targetIndex, "train"), splitRows(m, targetIndex, "valid")...)
+	sort.Ints(rows)
+	cvFolds, err := walkForwardFolds(m, rows, cv)
+	if err != nil {
+		return err
+	}
+	for _, fold := range cvFolds {
+		logMsg("cv.fold", fold.Index, formatCVDate(fold.TrainStart), formatCVDate(fold.TrainEnd), len(fold.Train),
+			formatCVDate(fold.TestStart), formatCVDate(fold.TestEnd), len(fold.Test))
+	}
+
+	candidates := make([]CVCandidate, 0, len(candidateValues))
+	for i, values := range candidateValues {
+		c := CVCandidate{Index: i + 1, Params: make(map[string]string)}
+		for p, param := range params {
+			fs.Set(param.Name, values[p])
+			c.Params[param.Name] = values[p]
+		}
+		if c.Config, err = config(); err != nil {
+			return err
+		}
+		// flag ของโมเดลทั้งหมดที่ต่างจากค่าเริ่มต้น สำหรับทำซ้ำด้วยคำสั่ง train
+		fs.VisitAll(func(f *flag.Flag) {
+			if modelFlags[f.Name] && f.Value.String() != f.DefValue {
+				c.Args = append(c.Args, "-"+f.Name, f.Value.String())
+			}
+		})
+		if c.Folds, err = crossValidate(m, features, targetIndex, returns, cvFolds, c.Config, cv, *validFraction); err != nil {
+			return err
+		}
+		c.Mean = meanMetrics(c.Folds)
+		logMsg("cv.candidate", c.Index, len(candidateValues), formatParams(c.Params), *metric, formatMetric(c.Mean, *metric))
+		candidates = append(candidates, c)
+	}
+
+	best, ok := bestCandidate(candidates, *metric)
+	if !ok {
+		return errMsg("cv.no_score", *metric)
+	}
+	command := slices.Concat([]string{"stock-predict", "train"}, candidates[best].Args, []string{"-target", *target})
+	if *featureSpec != "" {
+		command = append(command, "-features", *featureSpec)
+	}
+	logMsg("cv.best", candidates[best].Index, formatParams(candidates[best].Params), *metric, formatMetric(candidates[best].Mean, *metric),
+		strings.Join(append(command, fs.Arg(0)), " "))
+
+	if err := ExportCVFolds(candidates, cvFolds, *output+"_folds.csv"); err != nil {
+		return err
+	}
+	if err := ExportCVSearch(candidates, *metric, *output+"_search.csv"); err != nil {
+		return err
+	}
+	return WriteCVResult(CVResult{
+		CreatedAt:  time.Now().UTC(),
+		Source:     fs.Arg(0),
+		Target:     *target,
+		Features:   *featureSpec,
+		Metric:     *metric,
+		Search:     *search,
+		SearchSeed: *searchSeed,
+		CV:         cv,
+		Folds:      len(cvFolds),
+		Candidates: len(candidates),
+		Best:       candidates[best],
+	}, *output+"_best.json")
+}
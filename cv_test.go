@@ -0,0 +1,125 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWalkForwardFolds(t *testing.T) {
+	monthEnds := []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30", "2024-05-31", "2024-06-30", "2024-07-31"}
+	matrixOf := func(n int) (TrainingMatrix, []int) {
+		var rows []MatrixRow
+		var idx []int
+		for i, date := range monthEnds[:n] {
+			rows = append(rows, testRow("A", date, 1, 1))
+			idx = append(idx, i)
+		}
+		return testMatrix([]string{"x"}, rows...), idx
+	}
+	type fold struct {
+		index       int
+		train, test []int
+	}
+	tests := []struct {
+		name    string
+		dates   int
+		cfg     CVConfig
+		want    []fold
+		wantErr bool
+	}{
+		{"expanding", 6, CVConfig{Folds: 2, MinTrainPeriods: 2},
+			[]fold{{1, []int{0, 1}, []int{2, 3}}, {2, []int{0, 1, 2, 3}, []int{4, 5}}}, false},
+		// fold แรกเริ่ม 31 มี.ค. ตัดแถวตั้งแต่ 29 ก.พ. fold ที่สองเริ่ม 31 พ.ค. ตัดแถวตั้งแต่ 30 เม.ย.
+		{"purge", 6, CVConfig{Folds: 2, MinTrainPeriods: 2, PurgeDays: 31},
+			[]fold{{1, []int{0}, []int{2, 3}}, {2, []int{0, 1, 2}, []int{4, 5}}}, false},
+		{"purge plus embargo", 6, CVConfig{Folds: 2, MinTrainPeriods: 2, PurgeDays: 20, EmbargoDays: 11},
+			[]fold{{1, []int{0}, []int{2, 3}}, {2, []int{0, 1, 2}, []int{4, 5}}}, false},
+		{"purge one day short keeps the boundary row", 6, CVConfig{Folds: 2, MinTrainPeriods: 2, PurgeDays: 30},
+			[]fold{{1, []int{0, 1}, []int{2, 3}}, {2, []int{0, 1, 2, 3}, []int{4, 5}}}, false},
+		{"rolling window", 6, CVConfig{Folds: 2, MinTrainPeriods: 2, WindowPeriods: 1},
+			[]fold{{1, []int{1}, []int{2, 3}}, {2, []int{3}, []int{4, 5}}}, false},
+		// 31 มี.ค. - 60 วัน = 31 ม.ค. ไม่เหลือแถว train ใน fold แรก
+		{"fold without training rows is skipped", 6, CVConfig{Folds: 2, MinTrainPeriods: 2, PurgeDays: 60},
+			[]fold{{2, []int{0, 1, 2}, []int{4, 5}}}, false},
+		{"remainder goes to the first training set", 7, CVConfig{Folds: 2, MinTrainPeriods: 2},
+			[]fold{{1, []int{0, 1, 2}, []int{3, 4}}, {2, []int{0, 1, 2, 3, 4}, []int{5, 6}}}, false},
+		{"not enough dates", 6, CVConfig{Folds: 5, MinTrainPeriods: 2}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, rows := matrixOf(tt.dates)
+			folds, err := walkForwardFolds(m, rows, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v; want error %v", err, tt.wantErr)
+			}
+			if len(folds) != len(tt.want) {
+				t.Fatalf("got %d folds; want %d", len(folds), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := folds[i]
+				if got.Index != want.index || !slices.Equal(got.Train, want.train) || !slices.Equal(got.Test, want.test) {
+					t.Errorf("fold %d = %d train %v test %v; want %d train %v test %v",
+						i, got.Index, got.Train, got.Test, want.index, want.train, want.test)
+				}
+			}
+		})
+	}
+}
+
+func TestRankIC(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	a, b, c := day("2024-03-29"), day("2024-06-28"), day("2024-09-30")
+	tests := []struct {
+		name  string
+		dates []time.Time
+		y     []float64
+		pred  []float64
+		want  float64
+	}{
+		{"same order", []time.Time{a, a, a}, []float64{1, 2, 3}, []float64{10, 20, 30}, 1},
+		{"reversed order", []time.Time{a, a, a}, []float64{1, 2, 3}, []float64{30, 20, 10}, -1},
+		// วันที่ b: อันดับ (1,2,3) เทียบ (2,1,3) ได้ 0.5 เฉลี่ยกับวันที่ a ได้ 0.75
+		{"mean across dates", []time.Time{a, a, a, b, b, b}, []float64{1, 2, 3, 1, 2, 3}, []float64{1, 2, 3, 2, 1, 3}, 0.75},
+		{"small dates are skipped", []time.Time{a, a, a, c, c}, []float64{1, 2, 3, 1, 2}, []float64{1, 2, 3, 2, 1}, 1},
+		{"constant predictions", []time.Time{a, a, a}, []float64{1, 2, 3}, []float64{5, 5, 5}, math.NaN()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rankIC(tt.dates, tt.y, tt.pred)
+			if math.IsNaN(tt.want) != math.IsNaN(got) || (!math.IsNaN(got) && !approxEqual(got, tt.want)) {
+				t.Errorf("rankIC = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAUC(t *testing.T) {
+	tests := []struct {
+		name  string
+		y     []float64
+		score []float64
+		want  float64
+	}{
+		{"perfect", []float64{0, 0, 1, 1}, []float64{0.1, 0.2, 0.3, 0.4}, 1},
+		{"inverted", []float64{0, 0, 1, 1}, []float64{0.4, 0.3, 0.2, 0.1}, 0},
+		// คู่บวก-ลบ 4 คู่ ถูก 3 คู่
+		{"three of four pairs", []float64{0, 0, 1, 1}, []float64{0.1, 0.4, 0.35, 0.8}, 0.75},
+		// คะแนนเท่ากันนับครึ่งคู่: 0.5 + 1 + 2 = 3.5 จาก 4 คู่
+		{"ties count half", []float64{0, 1, 0, 1}, []float64{0.2, 0.2, 0.1, 0.9}, 0.875},
+		{"all tied", []float64{0, 1, 0, 1}, []float64{0.5, 0.5, 0.5, 0.5}, 0.5},
+		{"single class", []float64{1, 1}, []float64{0.2, 0.9}, math.NaN()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := auc(tt.y, tt.score)
+			if math.IsNaN(tt.want) != math.IsNaN(got) || (!math.IsNaN(got) && !approxEqual(got, tt.want)) {
+				t.Errorf("auc = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
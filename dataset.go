@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// hiveDefaultPartition - ชื่อพาร์ทิชันของแถวที่ไม่มีค่า (ชื่อเดียวกับที่ Hive/Spark ใช้)
+const hiveDefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// manifestDir - โฟลเดอร์เก็บ manifest ขึ้นต้นด้วย _ เพื่อให้ Spark/DuckDB ไม่อ่านเป็นข้อมูล
+const manifestDir = "_manifests"
+
+// DatasetOptions - ตัวเลือกของการเขียน dataset แบบแบ่งพาร์ทิชัน
+type DatasetOptions struct {
+	Root        string       // โฟลเดอร์ราก
+	RunID       string       // รหัสของการรันครั้งนี้ ใช้ในชื่อไฟล์ part และ manifest
+	AsOf        time.Time    // วันที่ของข้อมูลชุดนี้
+	Formats     []string     // csv และ/หรือ parquet
+	Compression string       // วิธีบีบอัด Parquet
+	Layout      ColumnLayout // คอลัมน์ของไฟล์ CSV
+}
+
+// DatasetManifest - สรุปไฟล์ทั้งหมดของการรันหนึ่งครั้ง
+// งานที่อ่านข้อมูลต่อควรอ่านเฉพาะไฟล์ใน manifest เพื่อให้ได้ข้อมูลชุดเดียวกันทั้งหมด
+type DatasetManifest struct {
+	RunID         string         `json:"run_id"`
+	AsOf          string         `json:"as_of"`
+	CreatedAt     time.Time      `json:"created_at"`
+	SchemaVersion string         `json:"schema_version"`
+	CSVColumns    []string       `json:"csv_columns,omitempty"`
+	SymbolCount   int            `json:"symbol_count"`
+	RowCounts     map[string]int `json:"row_counts"`
+	Files         []DatasetFile  `json:"files"`
+}
+
+// DatasetFile - ไฟล์หนึ่งไฟล์ใน dataset
+type DatasetFile struct {
+	Path      string `json:"path"` // path เทียบกับโฟลเดอร์ราก คั่นด้วย /
+	Type      string `json:"type"`
+	Partition string `json:"partition"`
+	Format    string `json:"format"`
+	Rows      int    `json:"rows"`
+	Bytes     int64  `json:"bytes"`
+	SHA256    string `json:"sha256"`
+}
+
+// newRunID - รหัสการรันจากเวลา UTC เรียงตามเวลาได้
+func newRunID(now time.Time) string {
+	return now.UTC().Format("20060102T150405Z")
+}
+
+// WriteDataset - เขียนข้อมูลเป็น dataset แบบ Hive partition
+//
+//	<root>/type=financials/calendar_year=YYYY/calendar_quarter=Q/part-<run>.{csv,parquet}
+//	<root>/type=prices/date=YYYY-MM-DD/part-<run>.parquet
+//	<root>/_manifests/<run>.json
+//
+// งบการเงินแบ่งตามไตรมาสปฏิทิน เพื่อให้บริษัทที่ปิดงบต่างเดือนกันอยู่ในพาร์ทิชันเดียวกัน
+// ชื่อพาร์ทิชันขึ้นต้นด้วย calendar_ เพื่อไม่ให้ชนกับคอลัมน์ year/quarter ของปีบัญชีในไฟล์
+// เมื่ออ่านด้วยเครื่องมือที่แปลงพาร์ทิชันเป็นคอลัมน์ (Spark, DuckDB, pyarrow)
+// ไฟล์ของแต่ละการรันมีชื่อต่างกัน จึงเก็บหลายการรันไว้ด้วยกันได้โดยไม่เขียนทับ
+func WriteDataset(opts DatasetOptions, data []FinancialData, history []EODPriceBySymbol) (DatasetManifest, error) {
+	if len(data) == 0 {
+		return DatasetManifest{}, errMsg("export.no_data")
+	}
+	if opts.RunID == "" || strings.ContainsAny(opts.RunID, `/\`) || strings.HasPrefix(opts.RunID, ".") {
+		return DatasetManifest{}, errMsg("dataset.invalid_run_id", opts.RunID)
+	}
+
+	manifest := DatasetManifest{
+		RunID:         opts.RunID,
+		AsOf:          opts.AsOf.Format("2006-01-02"),
+		CreatedAt:     time.Now().UTC(),
+		SchemaVersion: parquetSchemaVersion,
+		SymbolCount:   len(uniqueSymbols(data)),
+		RowCounts:     make(map[string]int),
+	}
+
+	// งบการเงินแยกตามไตรมาสปฏิทิน
+	financials := make(map[string][]FinancialData)
+	for _, item := range data {
+		financials[financialPartition(item)] = append(financials[financialPartition(item)], item)
+	}
+	for _, partition := range sortedKeys(financials) {
+		rows := financials[partition]
+		for _, format := range opts.Formats {
+			var write func(string) error
+			switch format {
+			case "csv":
+				write = func(path string) error { return writeCSVFile(rows, path, opts.Layout) }
+				manifest.CSVColumns = opts.Layout.Names()
+			case "parquet":
+				write = func(path string) error { return writeFinancialsParquet(rows, path, opts.Compression) }
+			default:
+				return manifest, errMsg("dataset.unknown_format", format)
+			}
+			file, err := writeDatasetPart(opts, "financials", partition, format, len(rows), write)
+			if err != nil {
+				return manifest, err
+			}
+			manifest.Files = append(manifest.Files, file)
+		}
+		manifest.RowCounts["financials"] += len(rows)
+	}
+
+	// ราคารายวันแยกตามวันที่ เขียนเป็น Parquet เท่านั้นเพราะจำนวนพาร์ทิชันมาก
+	prices := make(map[string][]EODPriceBySymbol)
+	for _, price := range history {
+		prices[pricePartition(price)] = append(prices[pricePartition(price)], price)
+	}
+	for _, partition := range sortedKeys(prices) {
+		rows := prices[partition]
+		file, err := writeDatasetPart(opts, "prices", partition, "parquet", len(rows), func(path string) error {
+			return writePricesParquet(rows, path, opts.Compression)
+		})
+		if err != nil {
+			return manifest, err
+		}
+		manifest.Files = append(manifest.Files, file)
+		manifest.RowCounts["prices"] += len(rows)
+	}
+
+	if err := writeManifest(opts.Root, manifest); err != nil {
+		return manifest, err
+	}
+
+	logMsg("dataset.done", opts.Root, opts.RunID, len(manifest.Files))
+	return manifest, nil
+}
+
+// financialPartition - พาร์ทิชันของงบการเงินตามไตรมาสปฏิทิน เช่น calendar_year=2024/calendar_quarter=1
+func financialPartition(item FinancialData) string {
+	period := calendarPeriodOf(item)
+	if period.IsZero() {
+		return "calendar_year=" + hiveDefaultPartition + "/calendar_quarter=" + hiveDefaultPartition
+	}
+	return fmt.Sprintf("calendar_year=%d/calendar_quarter=%d", period.Year, period.Quarter)
+}
+
+// pricePartition - พาร์ทิชันของราคารายวัน เช่น date=2024-03-29
+func pricePartition(price EODPriceBySymbol) string {
+	date, ok := parseAPIDate(price.Date)
+	if !ok {
+		return "date=" + hiveDefaultPartition
+	}
+	return "date=" + date.Format("2006-01-02")
+}
+
+// writeDatasetPart - เขียนไฟล์ part หนึ่งไฟล์แล้วคำนวณขนาดและ checksum
+func writeDatasetPart(opts DatasetOptions, kind, partition, format string, rows int, write func(path string) error) (DatasetFile, error) {
+	rel := filepath.Join("type="+kind, filepath.FromSlash(partition), "part-"+opts.RunID+"."+format)
+	path := filepath.Join(opts.Root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return DatasetFile{}, errMsg("dataset.mkdir", err)
+	}
+	if err := write(path); err != nil {
+		return DatasetFile{}, err
+	}
+
+	sum, size, err := fileChecksum(path)
+	if err != nil {
+		return DatasetFile{}, err
+	}
+	return DatasetFile{
+		Path:      filepath.ToSlash(rel),
+		Type:      kind,
+		Partition: partition,
+		Format:    format,
+		Rows:      rows,
+		Bytes:     size,
+		SHA256:    sum,
+	}, nil
+}
+
+// fileChecksum - SHA-256 และขนาดของไฟล์
+func fileChecksum(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, errMsg("dataset.checksum", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, errMsg("dataset.checksum", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// writeManifest - เขียน manifest แล้วจึงชี้ LATEST ไปที่การรันนี้
+// เขียนลงไฟล์ชั่วคราวแล้ว rename เพื่อไม่ให้งานที่อ่านอยู่เห็นไฟล์ที่เขียนไม่ครบ
+func writeManifest(root string, manifest DatasetManifest) error {
+	dir := filepath.Join(root, manifestDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errMsg("dataset.mkdir", err)
+	}
+
+	body, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errMsg("dataset.manifest", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, manifest.RunID+".json"), append(body, '\n')); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, "LATEST"), []byte(manifest.RunID+"\n"))
+}
+
+func writeFileAtomic(path string, body []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return errMsg("dataset.manifest", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return errMsg("dataset.manifest", err)
+	}
+	return nil
+}
+
+// readManifest - อ่าน manifest ของการรันที่ระบุ ถ้า runID ว่างจะใช้การรันล่าสุด
+func readManifest(root, runID string) (DatasetManifest, error) {
+	dir := filepath.Join(root, manifestDir)
+	if runID == "" {
+		latest, err := os.ReadFile(filepath.Join(dir, "LATEST"))
+		if err != nil {
+			return DatasetManifest{}, errMsg("dataset.manifest", err)
+		}
+		runID = strings.TrimSpace(string(latest))
+	}
+
+	body, err := os.ReadFile(filepath.Join(dir, runID+".json"))
+	if err != nil {
+		return DatasetManifest{}, errMsg("dataset.manifest", err)
+	}
+	var manifest DatasetManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return DatasetManifest{}, errMsg("dataset.manifest", err)
+	}
+	return manifest, nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDatasetPartitionsRoundTrip(t *testing.T) {
+	// งวดปีบัญชี 2024 Q1 ของบริษัทที่ปิดงบเดือนมีนาคมตรงกับไตรมาสปฏิทิน 2023 Q2
+	shifted := quarterItem(2024, 1)
+	shifted.Symbol = "BBB"
+	shifted.Calendar = Period{Year: 2023, Quarter: 2}
+	noPeriod := FinancialData{Symbol: "CCC", FinancialStatementType: "C"}
+
+	opts := DatasetOptions{
+		Root:        t.TempDir(),
+		RunID:       "20240701T000000Z",
+		AsOf:        time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		Formats:     []string{"parquet"},
+		Compression: "snappy",
+		Layout:      defaultColumnLayout(),
+	}
+	manifest, err := WriteDataset(opts, []FinancialData{quarterItem(2024, 1), shifted, noPeriod}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var partitions []string
+	for _, file := range manifest.Files {
+		partitions = append(partitions, file.Partition)
+	}
+	slices.Sort(partitions)
+	want := []string{
+		"calendar_year=2023/calendar_quarter=2",
+		"calendar_year=2024/calendar_quarter=1",
+		"calendar_year=" + hiveDefaultPartition + "/calendar_quarter=" + hiveDefaultPartition,
+	}
+	slices.Sort(want)
+	if !slices.Equal(partitions, want) {
+		t.Errorf("partitions = %v; want %v", partitions, want)
+	}
+
+	data, err := loadDatasetSnapshot(opts.Root, opts.RunID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		symbol string
+		year   string
+	}{
+		{"AAA", "2024"},
+		{"BBB", "2024"},
+		{"CCC", ""},
+	}
+	if len(data) != len(tests) {
+		t.Fatalf("got %d rows; want %d", len(data), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			// ปีในไฟล์ยังเป็นปีบัญชี ไม่ถูกแทนด้วยปีของพาร์ทิชัน
+			if data[i].Symbol != tt.symbol || data[i].Year != tt.year {
+				t.Errorf("row %d = %s %s; want %s %s", i, data[i].Symbol, data[i].Year, tt.symbol, tt.year)
+			}
+		})
+	}
+}
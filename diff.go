@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RecordKey - ตัวระบุงบการเงินหนึ่งงวด
+type RecordKey struct {
+	Symbol        string `json:"symbol"`
+	Year          string `json:"year"`
+	Quarter       string `json:"quarter"`
+	StatementType string `json:"statementType,omitempty"`
+}
+
+func recordKeyOf(item FinancialData) RecordKey {
+	return RecordKey{
+		Symbol:        item.Symbol,
+		Year:          item.Year,
+		Quarter:       item.Quarter,
+		StatementType: item.FinancialStatementType,
+	}
+}
+
+func (k RecordKey) String() string {
+	s := fmt.Sprintf("%s %sQ%s", k.Symbol, displayYearString(k.Year), k.Quarter)
+	if k.StatementType != "" {
+		s += " (" + k.StatementType + ")"
+	}
+	return s
+}
+
+// ValueChange - ค่าที่เปลี่ยนไปของคอลัมน์หนึ่ง
+// Old/New เป็น nil เมื่อไม่มีค่าในฝั่งนั้น (เช่นไม่มีราคา), AbsDelta/RelDelta มีเฉพาะค่าตัวเลข
+type ValueChange struct {
+	Key      RecordKey   `json:"key"`
+	Column   string      `json:"column"`
+	Old      interface{} `json:"old"`
+	New      interface{} `json:"new"`
+	AbsDelta *float64    `json:"absDelta,omitempty"`
+	RelDelta *float64    `json:"relDelta,omitempty"` // เทียบกับค่าเดิม ไม่มีค่าเมื่อค่าเดิมเป็นศูนย์
+}
+
+// SnapshotDiff - ความแตกต่างระหว่างข้อมูลสองชุด
+type SnapshotDiff struct {
+	Old             string        `json:"old"`
+	New             string        `json:"new"`
+	NewSymbols      []string      `json:"newSymbols"`
+	DelistedSymbols []string      `json:"delistedSymbols"`
+	NewQuarters     []RecordKey   `json:"newQuarters"`
+	RemovedQuarters []RecordKey   `json:"removedQuarters"`
+	ChangedValues   []ValueChange `json:"changedValues"`
+	PriceChanges    []ValueChange `json:"priceChanges"`
+}
+
+// DiffThreshold - เกณฑ์การรายงานค่าตัวเลขที่เปลี่ยน ต้องเกินทั้งสองเกณฑ์จึงรายงาน
+type DiffThreshold struct {
+	Abs float64 // ผลต่างสัมบูรณ์ขั้นต่ำ
+	Rel float64 // ผลต่างสัมพัทธ์ขั้นต่ำ (0.01 = 1%) ค่าเดิมเป็นศูนย์ถือว่าเกินเสมอ
+}
+
+// diffSnapshots - เปรียบเทียบข้อมูลสองชุดตาม (หุ้น, ปี, ไตรมาส, ประเภทงบ)
+// งวดใหม่ของหุ้นใหม่จะรายงานใน NewSymbols เท่านั้น ไม่ซ้ำใน NewQuarters
+func diffSnapshots(oldData, newData []FinancialData, threshold DiffThreshold) SnapshotDiff {
+	// ใช้ slice ว่างแทน nil เพื่อให้ JSON เป็น [] ไม่ใช่ null
+	diff := SnapshotDiff{
+		NewSymbols:      []string{},
+		DelistedSymbols: []string{},
+		NewQuarters:     []RecordKey{},
+		RemovedQuarters: []RecordKey{},
+		ChangedValues:   []ValueChange{},
+		PriceChanges:    []ValueChange{},
+	}
+
+	oldByKey := indexByKey(oldData)
+	newByKey := indexByKey(newData)
+	oldSymbols := symbolSet(oldData)
+	newSymbols := symbolSet(newData)
+
+	for symbol := range newSymbols {
+		if !oldSymbols[symbol] {
+			diff.NewSymbols = append(diff.NewSymbols, symbol)
+		}
+	}
+	for symbol := range oldSymbols {
+		if !newSymbols[symbol] {
+			diff.DelistedSymbols = append(diff.DelistedSymbols, symbol)
+		}
+	}
+	sort.Strings(diff.NewSymbols)
+	sort.Strings(diff.DelistedSymbols)
+
+	for _, key := range sortedRecordKeys(newByKey) {
+		newItem := newByKey[key]
+		oldItem, ok := oldByKey[key]
+		if !ok {
+			if oldSymbols[key.Symbol] {
+				diff.NewQuarters = append(diff.NewQuarters, key)
+			}
+			continue
+		}
+
+		for _, col := range exportColumns {
+			if isLineageColumn(col.Name) {
+				continue
+			}
+			change, ok := compareColumn(key, col, oldItem, newItem, threshold)
+			if !ok {
+				continue
+			}
+			if strings.HasPrefix(col.Name, "price_") {
+				diff.PriceChanges = append(diff.PriceChanges, change)
+			} else {
+				diff.ChangedValues = append(diff.ChangedValues, change)
+			}
+		}
+	}
+
+	for _, key := range sortedRecordKeys(oldByKey) {
+		if _, ok := newByKey[key]; !ok && newSymbols[key.Symbol] {
+			diff.RemovedQuarters = append(diff.RemovedQuarters, key)
+		}
+	}
+
+	return diff
+}
+
+// compareColumn - เปรียบเทียบค่าของคอลัมน์หนึ่ง คืน false ถ้าไม่เปลี่ยนหรือเปลี่ยนน้อยกว่าเกณฑ์
+func compareColumn(key RecordKey, col ExportColumn, oldItem, newItem FinancialData, threshold DiffThreshold) (ValueChange, bool) {
+	oldVal := col.value(oldItem)
+	newVal := col.value(newItem)
+	if reflect.DeepEqual(oldVal, newVal) {
+		return ValueChange{}, false
+	}
+
+	change := ValueChange{Key: key, Column: col.Name, Old: oldVal, New: newVal}
+
+	oldNum, oldIsNum := oldVal.(float64)
+	newNum, newIsNum := newVal.(float64)
+	if !oldIsNum || !newIsNum {
+		// ค่าที่ไม่ใช่ตัวเลข หรือมีค่าเพียงฝั่งเดียว รายงานเสมอ
+		return change, true
+	}
+
+	absDelta := newNum - oldNum
+	if math.Abs(absDelta) <= threshold.Abs {
+		return ValueChange{}, false
+	}
+	change.AbsDelta = &absDelta
+	if oldNum != 0 {
+		relDelta := absDelta / math.Abs(oldNum)
+		if math.Abs(relDelta) <= threshold.Rel {
+			return ValueChange{}, false
+		}
+		change.RelDelta = &relDelta
+	}
+	return change, true
+}
+
+func indexByKey(data []FinancialData) map[RecordKey]FinancialData {
+	index := make(map[RecordKey]FinancialData, len(data))
+	for _, item := range data {
+		index[recordKeyOf(item)] = item
+	}
+	return index
+}
+
+func symbolSet(data []FinancialData) map[string]bool {
+	set := make(map[string]bool)
+	for _, item := range data {
+		set[item.Symbol] = true
+	}
+	return set
+}
+
+// sortedRecordKeys - เรียงตามหุ้น (A-Z) แล้วตามปีและไตรมาส (ล่าสุดก่อน) เหมือน sortFinancialData
+func sortedRecordKeys(index map[RecordKey]FinancialData) []RecordKey {
+	keys := make([]RecordKey, 0, len(index))
+	for key := range index {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := index[keys[i]], index[keys[j]]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		pa, pb := calendarPeriodOf(a), calendarPeriodOf(b)
+		if pa != pb {
+			return pb.Before(pa)
+		}
+		return keys[i].StatementType < keys[j].StatementType
+	})
+	return keys
+}
+
+// writeDiffSummary - สรุปความแตกต่างสำหรับคนอ่าน แสดงรายละเอียดไม่เกิน limit รายการต่อหัวข้อ
+func writeDiffSummary(w io.Writer, diff SnapshotDiff, limit int) {
+	fmt.Fprint(w, T("diff.header", diff.Old, diff.New))
+
+	writeSection := func(key string, n int, item func(i int) string) {
+		fmt.Fprint(w, T(key, n))
+		for i := 0; i < n && i < limit; i++ {
+			fmt.Fprintf(w, "  %s\n", item(i))
+		}
+		if n > limit {
+			fmt.Fprint(w, T("diff.more", n-limit))
+		}
+	}
+
+	writeSection("diff.new_symbols", len(diff.NewSymbols), func(i int) string { return diff.NewSymbols[i] })
+	writeSection("diff.delisted_symbols", len(diff.DelistedSymbols), func(i int) string { return diff.DelistedSymbols[i] })
+	writeSection("diff.new_quarters", len(diff.NewQuarters), func(i int) string { return diff.NewQuarters[i].String() })
+	writeSection("diff.removed_quarters", len(diff.RemovedQuarters), func(i int) string { return diff.RemovedQuarters[i].String() })
+	writeSection("diff.changed_values", len(diff.ChangedValues), func(i int) string { return diff.ChangedValues[i].String() })
+	writeSection("diff.price_changes", len(diff.PriceChanges), func(i int) string { return diff.PriceChanges[i].String() })
+}
+
+func (c ValueChange) String() string {
+	s := fmt.Sprintf("%s %s: %s -> %s", c.Key, columnHeader(c.Column), diffValueString(c.Old), diffValueString(c.New))
+	if c.RelDelta != nil {
+		s += fmt.Sprintf(" (%+.2f%%)", *c.RelDelta*100)
+	}
+	return s
+}
+
+func diffValueString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "-"
+	case float64:
+		return formatValue(exportPrecision, "", val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// runDiff - คำสั่ง diff: stock-predict diff [flags] <ข้อมูลเดิม> <ข้อมูลใหม่>
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	absThreshold := fs.Float64("abs", 0, "รายงานเฉพาะค่าที่เปลี่ยนมากกว่าค่านี้ (ผลต่างสัมบูรณ์)")
+	relThreshold := fs.Float64("rel", 0, "รายงานเฉพาะค่าที่เปลี่ยนมากกว่าสัดส่วนนี้ของค่าเดิม เช่น 0.01 = 1%")
+	jsonOut := fs.String("json", "", "เขียนผลต่างแบบ JSON ลงไฟล์นี้ (- สำหรับ stdout)")
+	limit := fs.Int("limit", 20, "จำนวนรายการสูงสุดที่แสดงในสรุปต่อหัวข้อ")
+	lang := fs.String("lang", "th", "ภาษาของข้อความ / language (th, en)")
+	buddhistEra := fs.Bool("buddhist-era", false, "แสดงปีเป็นพุทธศักราช / show years in the Buddhist Era")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := SetLocale(*lang, *buddhistEra); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errMsg("diff.usage")
+	}
+
+	// JSON ออกทาง stdout ต้องย้ายข้อความอื่นไป stderr
+	summary := io.Writer(logOutput)
+	if *jsonOut == stdoutPath {
+		logOutput = os.Stderr
+		summary = logOutput
+	}
+
+	oldSpec, newSpec := fs.Arg(0), fs.Arg(1)
+	oldData, err := loadSnapshot(oldSpec)
+	if err != nil {
+		return err
+	}
+	newData, err := loadSnapshot(newSpec)
+	if err != nil {
+		return err
+	}
+
+	diff := diffSnapshots(oldData, newData, DiffThreshold{Abs: *absThreshold, Rel: *relThreshold})
+	diff.Old, diff.New = oldSpec, newSpec
+
+	writeDiffSummary(summary, diff, *limit)
+
+	if *jsonOut != "" {
+		out, err := createOutput(*jsonOut)
+		if err != nil {
+			return errMsg("export.create_file", "JSON", err)
+		}
+		defer out.Close()
+
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			return errMsg("export.write_row", err)
+		}
+		if err := out.Close(); err != nil {
+			return errMsg("export.save_file", "JSON", err)
+		}
+	}
+	return nil
+}
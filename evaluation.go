@@ -0,0 +1,230 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ประเภทของปัญหาที่โมเดลทำนาย
+const (
+	taskRegression     = "regression"
+	taskClassification = "classification"
+)
+
+// evaluate - ตัวชี้วัดของค่าที่ทำนายเทียบกับค่าจริง
+// regression: rmse, mae, r2 / classification (ค่าที่ทำนายคือความน่าจะเป็น): logloss, auc, accuracy
+// ตัวชี้วัดที่คำนวณไม่ได้ (เช่น auc เมื่อมีคลาสเดียว) จะไม่อยู่ในผลลัพธ์
+func evaluate(task string, y, pred []float64) map[string]float64 {
+	if len(y) == 0 {
+		return nil
+	}
+	var metrics map[string]float64
+	if task == taskClassification {
+		metrics = map[string]float64{
+			"logloss":  logLoss(y, pred),
+			"auc":      auc(y, pred),
+			"accuracy": accuracy(y, pred),
+		}
+	} else {
+		metrics = map[string]float64{
+			"rmse": rmse(y, pred),
+			"mae":  mae(y, pred),
+			"r2":   r2(y, pred),
+		}
+	}
+	for name, v := range metrics {
+		if math.IsNaN(v) {
+			delete(metrics, name)
+		}
+	}
+	return metrics
+}
+
+func rmse(y, pred []float64) float64 {
+	var sum float64
+	for i := range y {
+		d := y[i] - pred[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(y)))
+}
+
+func mae(y, pred []float64) float64 {
+	var sum float64
+	for i := range y {
+		sum += math.Abs(y[i] - pred[i])
+	}
+	return sum / float64(len(y))
+}
+
+// r2 - สัดส่วนความแปรปรวนที่อธิบายได้ (ติดลบได้ถ้าแย่กว่าทายค่าเฉลี่ย)
+func r2(y, pred []float64) float64 {
+	var mean float64
+	for _, v := range y {
+		mean += v
+	}
+	mean /= float64(len(y))
+	var ssRes, ssTot float64
+	for i := range y {
+		ssRes += (y[i] - pred[i]) * (y[i] - pred[i])
+		ssTot += (y[i] - mean) * (y[i] - mean)
+	}
+	if ssTot == 0 {
+		return math.NaN()
+	}
+	return 1 - ssRes/ssTot
+}
+
+// logLoss - binary cross-entropy ตัดความน่าจะเป็นไว้ที่ [1e-15, 1-1e-15]
+func logLoss(y, prob []float64) float64 {
+	var sum float64
+	for i := range y {
+		p := math.Min(math.Max(prob[i], 1e-15), 1-1e-15)
+		sum -= y[i]*math.Log(p) + (1-y[i])*math.Log(1-p)
+	}
+	return sum / float64(len(y))
+}
+
+// auc - พื้นที่ใต้ ROC จากอันดับของคะแนน (คะแนนเท่ากันได้อันดับเฉลี่ย)
+// คืน NaN ถ้ามีเพียงคลาสเดียว
+func auc(y, score []float64) float64 {
+	ranks := averageRanks(score)
+	var positives, rankSum float64
+	for i := range y {
+		if y[i] > 0.5 {
+			positives++
+			rankSum += ranks[i]
+		}
+	}
+	negatives := float64(len(y)) - positives
+	if positives == 0 || negatives == 0 {
+		return math.NaN()
+	}
+	return (rankSum - positives*(positives+1)/2) / (positives * negatives)
+}
+
+func accuracy(y, prob []float64) float64 {
+	var correct float64
+	for i := range y {
+		if (prob[i] >= 0.5) == (y[i] > 0.5) {
+			correct++
+		}
+	}
+	return correct / float64(len(y))
+}
+
+// averageRanks - อันดับ 1..n ของค่า ค่าที่เท่ากันได้อันดับเฉลี่ย
+func averageRanks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return values[order[a]] < values[order[b]] })
+	ranks := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+		rank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			ranks[order[k]] = rank
+		}
+		i = j + 1
+	}
+	return ranks
+}
+
+// ขนาดขั้นต่ำของกลุ่มวันที่ที่ใช้คำนวณ rank IC และผลตอบแทนของ decile บนสุด
+const (
+	minRankICSize = 3
+	minDecileSize = 10
+)
+
+// groupByDate - ตำแหน่งของค่าแยกตามวันที่ เรียงตามวันที่
+func groupByDate(dates []time.Time) [][]int {
+	index := make(map[time.Time]int)
+	var groups [][]int
+	for i, d := range dates {
+		g, ok := index[d]
+		if !ok {
+			g = len(groups)
+			index[d] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	sort.SliceStable(groups, func(a, b int) bool { return dates[groups[a][0]].Before(dates[groups[b][0]]) })
+	return groups
+}
+
+// rankIC - ค่าเฉลี่ยของสหสัมพันธ์แบบ Spearman ระหว่างค่าที่ทำนายกับค่าจริงในแต่ละวันที่ (cross-section)
+// ใช้เฉพาะวันที่ที่มีอย่างน้อย minRankICSize แถว คืน NaN ถ้าไม่มีวันที่ใดใช้ได้
+func rankIC(dates []time.Time, y, pred []float64) float64 {
+	var sum float64
+	var count int
+	for _, group := range groupByDate(dates) {
+		if len(group) < minRankICSize {
+			continue
+		}
+		a := make([]float64, len(group))
+		b := make([]float64, len(group))
+		for i, idx := range group {
+			a[i], b[i] = y[idx], pred[idx]
+		}
+		if ic := correlation(averageRanks(a), averageRanks(b)); !math.IsNaN(ic) {
+			sum += ic
+			count++
+		}
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / float64(count)
+}
+
+// topDecileReturn - ค่าเฉลี่ยของผลตอบแทนของหุ้น 10% ที่ได้คะแนนสูงสุดในแต่ละวันที่ (พอร์ตถ่วงน้ำหนักเท่ากัน)
+// ใช้เฉพาะวันที่ที่มีอย่างน้อย minDecileSize แถว ผลตอบแทนที่ขาด (NaN) ไม่นับ
+func topDecileReturn(dates []time.Time, returns, pred []float64) float64 {
+	var sum float64
+	var count int
+	for _, group := range groupByDate(dates) {
+		if len(group) < minDecileSize {
+			continue
+		}
+		sorted := append([]int(nil), group...)
+		sort.SliceStable(sorted, func(a, b int) bool { return pred[sorted[a]] > pred[sorted[b]] })
+		var total float64
+		var n int
+		for _, idx := range sorted[:int(math.Ceil(float64(len(sorted))/10))] {
+			if !math.IsNaN(returns[idx]) {
+				total += returns[idx]
+				n++
+			}
+		}
+		if n > 0 {
+			sum += total / float64(n)
+			count++
+		}
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / float64(count)
+}
+
+// correlation - สหสัมพันธ์แบบ Pearson คืน NaN ถ้าค่าชุดใดไม่แปรผัน
+func correlation(a, b []float64) float64 {
+	ma, mb := meanOf(a), meanOf(b)
+	var cov, va, vb float64
+	for i := range a {
+		cov += (a[i] - ma) * (b[i] - mb)
+		va += (a[i] - ma) * (a[i] - ma)
+		vb += (b[i] - mb) * (b[i] - mb)
+	}
+	if va == 0 || vb == 0 {
+		return math.NaN()
+	}
+	return cov / math.Sqrt(va*vb)
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ExportColumn - คอลัมน์ที่ส่งออกได้ อ่านจาก struct ด้วย reflection
+// ชื่อคอลัมน์คือชื่อ field ของ Go หรือค่าใน tag `col:"..."` ถ้ามี (`col:"-"` = ไม่ส่งออก)
+// field ที่เป็น struct จะถูกแตกเป็นหลายคอลัมน์โดยใช้ชื่อ field เป็น prefix เช่น Calendar.Year -> CalendarYear
+type ExportColumn struct {
+	Name  string
+	float bool // ค่าเป็น float64 ที่ -precision ปรับขนาดได้
+	value func(item FinancialData) interface{}
+	set   func(item *FinancialData, raw string) error // ใช้ตอนนำเข้าไฟล์ CSV กลับมา
+}
+
+// ColumnSpec - การเลือกคอลัมน์หนึ่งคอลัมน์ในไฟล์ส่งออก
+// Header ว่าง = ใช้ชื่อตามภาษาที่เลือก, Precision ว่าง = ใช้ตาม -precision
+type ColumnSpec struct {
+	Name      string `json:"name"`
+	Header    string `json:"header,omitempty"`
+	Precision string `json:"precision,omitempty"`
+}
+
+// ColumnLayout - ชุดคอลัมน์และลำดับของไฟล์ส่งออก
+type ColumnLayout struct {
+	Columns []ColumnSpec `json:"columns"`
+}
+
+// exportColumns - คอลัมน์ทั้งหมดที่ส่งออกได้ เรียงตามลำดับ field ใน struct
+var exportColumns, exportColumnsByName = buildExportColumns()
+
+func buildExportColumns() ([]ExportColumn, map[string]ExportColumn) {
+	var columns []ExportColumn
+
+	// คอลัมน์จาก FinancialData
+	financialType := reflect.TypeOf(FinancialData{})
+	for i := 0; i < financialType.NumField(); i++ {
+		field := financialType.Field(i)
+		name := columnTagName(field)
+		if name == "" {
+			continue
+		}
+
+		index := field.Index
+		if field.Type.Kind() == reflect.Struct {
+			for j := 0; j < field.Type.NumField(); j++ {
+				sub := field.Type.Field(j)
+				subName := columnTagName(sub)
+				if subName == "" {
+					continue
+				}
+				subIndex := append(append([]int{}, index...), sub.Index...)
+				columns = append(columns, ExportColumn{
+					Name:  name + subName,
+					float: sub.Type.Kind() == reflect.Float64,
+					value: func(item FinancialData) interface{} {
+						return reflect.ValueOf(item).FieldByIndex(subIndex).Interface()
+					},
+					set: func(item *FinancialData, raw string) error {
+						return setFieldValue(reflect.ValueOf(item).Elem().FieldByIndex(subIndex), raw)
+					},
+				})
+			}
+			continue
+		}
+
+		columns = append(columns, ExportColumn{
+			Name:  name,
+			float: field.Type.Kind() == reflect.Float64,
+			value: func(item FinancialData) interface{} {
+				return reflect.ValueOf(item).FieldByIndex(index).Interface()
+			},
+			set: func(item *FinancialData, raw string) error {
+				return setFieldValue(reflect.ValueOf(item).Elem().FieldByIndex(index), raw)
+			},
+		})
+	}
+
+	// คอลัมน์ราคา ณ สิ้นไตรมาส ใช้ json tag ของ PriceData เพราะเป็น key ที่เก็บใน map
+	priceType := reflect.TypeOf(PriceData{})
+	for i := 0; i < priceType.NumField(); i++ {
+		key := "price_" + jsonFieldName(priceType.Field(i))
+		kind := fieldKind(priceType.Field(i))
+		columns = append(columns, ExportColumn{
+			Name:  key,
+			float: kind == reflect.Float64,
+			value: func(item FinancialData) interface{} {
+				if item.PriceData == nil {
+					return nil
+				}
+				return item.PriceData[key]
+			},
+			set: func(item *FinancialData, raw string) error {
+				// ช่องว่าง = ไม่มีข้อมูลราคา ไม่ใส่ key ลงใน map
+				if raw == "" {
+					return nil
+				}
+				var val interface{} = raw
+				if kind == reflect.Float64 {
+					f, err := strconv.ParseFloat(raw, 64)
+					if err != nil {
+						return err
+					}
+					val = f
+				}
+				if item.PriceData == nil {
+					item.PriceData = make(map[string]interface{})
+				}
+				item.PriceData[key] = val
+				return nil
+			},
+		})
+	}
+
+	// คอลัมน์ตัวชี้วัดที่คำนวณเพิ่ม ตัวชี้วัดทางเทคนิค และเป้าหมาย ไม่มีค่า (nil) เมื่อคำนวณไม่ได้
+	for _, metric := range derivedMetrics {
+		columns = append(columns, metricMapColumn(derivedColumnPrefix, metric.Name, func(item *FinancialData) *map[string]float64 {
+			return &item.Metrics
+		}))
+	}
+	for _, indicator := range technicalIndicators {
+		columns = append(columns, metricMapColumn(technicalColumnPrefix, indicator.Name, func(item *FinancialData) *map[string]float64 {
+			return &item.Technicals
+		}))
+	}
+	for _, horizon := range labelHorizons {
+		for _, kind := range labelKinds {
+			columns = append(columns, metricMapColumn(labelColumnPrefix, labelName(kind, horizon), func(item *FinancialData) *map[string]float64 {
+				return &item.Labels
+			}))
+		}
+	}
+
+	byName := make(map[string]ExportColumn, len(columns))
+	for _, col := range columns {
+		byName[col.Name] = col
+	}
+	return columns, byName
+}
+
+// metricMapColumn - คอลัมน์ที่อ่านค่าจาก map ของ FinancialData (Metrics, Technicals)
+func metricMapColumn(prefix, key string, field func(item *FinancialData) *map[string]float64) ExportColumn {
+	return ExportColumn{
+		Name:  prefix + key,
+		float: true,
+		value: func(item FinancialData) interface{} {
+			if v, ok := (*field(&item))[key]; ok {
+				return v
+			}
+			return nil
+		},
+		set: func(item *FinancialData, raw string) error {
+			if raw == "" {
+				return nil
+			}
+			f, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return err
+			}
+			m := field(item)
+			if *m == nil {
+				*m = make(map[string]float64)
+			}
+			(*m)[key] = f
+			return nil
+		},
+	}
+}
+
+// setFieldValue - แปลงข้อความเป็นค่าตามชนิดของ field (ช่องว่างคือค่าศูนย์)
+func setFieldValue(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Float64:
+		if raw == "" {
+			field.SetFloat(0)
+			return nil
+		}
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		if raw == "" {
+			field.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		if raw == "" {
+			field.SetInt(0)
+			return nil
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	}
+	return nil
+}
+
+// columnTagName - ชื่อคอลัมน์ของ field คืนค่าว่างถ้าไม่ต้องส่งออก
+func columnTagName(field reflect.StructField) string {
+	tag := field.Tag.Get("col")
+	if tag == "-" {
+		return ""
+	}
+	if tag != "" {
+		return tag
+	}
+	return field.Name
+}
+
+// financialColumnValue - ค่าของคอลัมน์จาก FinancialData (float64, int, string หรือ nil ถ้าไม่มีค่า)
+func financialColumnValue(item FinancialData, col string) interface{} {
+	if c, ok := exportColumnsByName[col]; ok {
+		return c.value(item)
+	}
+	return nil
+}
+
+// defaultColumnLayout - คอลัมน์เริ่มต้น (ชุดเดิมของ ExportToCSV)
+func defaultColumnLayout() ColumnLayout {
+	var layout ColumnLayout
+	for _, col := range baseColumns {
+		layout.Columns = append(layout.Columns, ColumnSpec{Name: col})
+	}
+	for _, col := range priceColumns {
+		layout.Columns = append(layout.Columns, ColumnSpec{Name: col})
+	}
+	return layout
+}
+
+// allColumnLayout - ทุกคอลัมน์ที่ส่งออกได้
+func allColumnLayout() ColumnLayout {
+	var layout ColumnLayout
+	for _, col := range exportColumns {
+		layout.Columns = append(layout.Columns, ColumnSpec{Name: col.Name})
+	}
+	return layout
+}
+
+// derivedColumnLayout - คอลัมน์เริ่มต้นและคอลัมน์ derived_* ทั้งหมด
+func derivedColumnLayout() ColumnLayout {
+	layout := defaultColumnLayout()
+	for _, metric := range derivedMetrics {
+		layout.Columns = append(layout.Columns, ColumnSpec{Name: derivedColumnPrefix + metric.Name})
+	}
+	return layout
+}
+
+// parseColumnLayout - อ่านรายการคอลัมน์จาก command line
+// รูปแบบ: "Symbol,Year,ROE=roe_pct" (ใส่ =ชื่อใหม่ เพื่อเปลี่ยนหัวคอลัมน์), "all" สำหรับทุกคอลัมน์
+// หรือ "derived" สำหรับคอลัมน์เริ่มต้นตามด้วยตัวชี้วัดที่คำนวณเพิ่มทั้งหมด
+func parseColumnLayout(spec string) (ColumnLayout, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return defaultColumnLayout(), nil
+	}
+	if spec == "all" {
+		return allColumnLayout(), nil
+	}
+	if spec == "derived" {
+		return derivedColumnLayout(), nil
+	}
+
+	var layout ColumnLayout
+	for _, part := range strings.Split(spec, ",") {
+		name, header, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		layout.Columns = append(layout.Columns, ColumnSpec{Name: name, Header: header})
+	}
+	return layout, layout.validate()
+}
+
+// loadColumnLayout - อ่านรายการคอลัมน์จากไฟล์ JSON
+func loadColumnLayout(filename string) (ColumnLayout, error) {
+	body, err := os.ReadFile(filename)
+	if err != nil {
+		return ColumnLayout{}, errMsg("columns.read_file", err)
+	}
+
+	var layout ColumnLayout
+	if err := json.Unmarshal(body, &layout); err != nil {
+		return ColumnLayout{}, errMsg("columns.parse_file", err)
+	}
+	return layout, layout.validate()
+}
+
+// validate - ตรวจว่าทุกคอลัมน์มีอยู่จริงและกฎความละเอียดถูกต้อง
+func (l ColumnLayout) validate() error {
+	if len(l.Columns) == 0 {
+		return errMsg("columns.empty")
+	}
+	var unknown []string
+	for _, spec := range l.Columns {
+		if _, ok := exportColumnsByName[spec.Name]; !ok {
+			unknown = append(unknown, spec.Name)
+		}
+		if spec.Precision != "" {
+			if _, err := parsePrecisionRule(spec.Precision); err != nil {
+				return err
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		return errMsg("columns.unknown", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Names - ชื่อคอลัมน์ตามลำดับ
+func (l ColumnLayout) Names() []string {
+	names := make([]string, len(l.Columns))
+	for i, spec := range l.Columns {
+		names[i] = spec.Name
+	}
+	return names
+}
+
+// Headers - หัวคอลัมน์สำหรับแสดงผลตามภาษาปัจจุบัน
+func (l ColumnLayout) Headers() []string {
+	headers := make([]string, len(l.Columns))
+	for i, spec := range l.Columns {
+		if spec.Header != "" {
+			headers[i] = spec.Header
+		} else {
+			headers[i] = columnHeader(spec.Name)
+		}
+	}
+	return headers
+}
+
+// Precision - นโยบายความละเอียดของ layout นี้ (กฎใน layout มาก่อนกฎจาก command line)
+func (l ColumnLayout) Precision(base PrecisionPolicy) PrecisionPolicy {
+	policy := base
+	for _, spec := range l.Columns {
+		if spec.Precision == "" {
+			continue
+		}
+		if rule, err := parsePrecisionRule(spec.Precision); err == nil {
+			policy = policy.With(spec.Name, rule)
+		}
+	}
+	return policy
+}
@@ -3,36 +3,38 @@ package main
 import (
 	"encoding/csv"
 	"fmt"
-	"os"
+	"io"
 	"strconv"
 	"time"
 )
 
-// สำหรับการแปลงชื่อคอลัมน์เป็นภาษาไทย (ถ้าต้องการ)
-var columnThaiNames = map[string]string{
-	"Symbol":             "หุ้น",
-	"Year":               "ปี",
-	"Quarter":            "ไตรมาส",
-	"TotalAssets":        "สินทรัพย์รวม",
-	"TotalLiabilities":   "หนี้สินรวม",
-	"ShareholderEquity":  "ส่วนของผู้ถือหุ้น",
-	"TotalRevenue":       "รายได้รวม",
-	"NetProfit":          "กำไรสุทธิ",
-	"EPS":                "กำไรต่อหุ้น",
-	"ROE":                "อัตราผลตอบแทนส่วนของผู้ถือหุ้น",
-	"ROA":                "อัตราผลตอบแทนจากสินทรัพย์",
-	"DE":                 "อัตราส่วนหนี้สินต่อส่วนของผู้ถือหุ้น",
-	"PriceClose":         "ราคาปิด",
-	"PricePE":            "P/E",
-	"PricePBV":           "P/BV",
-	"PriceDividendYield": "อัตราเงินปันผลตอบแทน",
-	"PriceMarketCap":     "มูลค่าตลาด",
+// กำหนดคอลัมน์ที่ต้องการส่งออก
+// ส่วนของข้อมูลพื้นฐานจาก FinancialData
+var baseColumns = []string{
+	"Symbol", "Year", "Quarter", "CalendarYear", "CalendarQuarter", "FiscalYearEndMonth", "DateAsof", "TotalAssets", "TotalLiabilities",
+	"PaidupShareCapital", "ShareholderEquity", "TotalEquity",
+	"TotalRevenueQuarter", "TotalRevenueAccum", "TotalExpensesQuarter", "TotalExpensesAccum",
+	"EbitQuarter", "EbitAccum", "NetProfitQuarter", "NetProfitAccum",
+	"EpsQuarter", "EpsAccum", "OperatingCashFlow", "InvestingCashFlow", "FinancingCashFlow",
+	"ROE", "ROA", "NetProfitMarginQuarter", "NetProfitMarginAccum", "DE",
+	"FixedAssetTurnover", "TotalAssetTurnover",
+}
+
+// ส่วนของข้อมูลราคาจาก PriceData (ถ้ามี)
+var priceColumns = []string{
+	"price_close", "price_pe", "price_pbv", "price_dividendYield", "price_marketCap",
+	"price_totalVolume", "price_high", "price_low", "price_open", "price_prior",
 }
 
 // ExportToCSV - ส่งออกข้อมูลงบการเงินเป็นไฟล์ CSV
 func ExportToCSV(data []FinancialData, filename string) error {
+	return ExportToCSVWithLayout(data, filename, defaultColumnLayout())
+}
+
+// ExportToCSVWithLayout - ส่งออกข้อมูลงบการเงินเป็นไฟล์ CSV ตามคอลัมน์ที่กำหนด
+func ExportToCSVWithLayout(data []FinancialData, filename string, layout ColumnLayout) error {
 	if len(data) == 0 {
-		return fmt.Errorf("ไม่มีข้อมูลสำหรับส่งออก")
+		return errMsg("export.no_data")
 	}
 
 	// กำหนดชื่อไฟล์ถ้าไม่ได้ระบุ
@@ -41,159 +43,91 @@ func ExportToCSV(data []FinancialData, filename string) error {
 		filename = fmt.Sprintf("financial_data_%s.csv", timestamp)
 	}
 
+	if err := writeCSVFile(data, filename, layout); err != nil {
+		return err
+	}
+
+	logMsg("export.done", displayPath(filename), len(data))
+	return nil
+}
+
+// writeCSVFile - เขียนไฟล์ CSV โดยไม่แสดงข้อความ (ใช้ร่วมกับ dataset)
+func writeCSVFile(data []FinancialData, filename string, layout ColumnLayout) error {
 	// สร้างไฟล์ CSV
-	file, err := os.Create(filename)
+	file, err := createOutput(filename)
 	if err != nil {
-		return fmt.Errorf("ไม่สามารถสร้างไฟล์ CSV: %v", err)
+		return errMsg("export.create_file", "CSV", err)
 	}
 	defer file.Close()
 
-	_, err = file.Write([]byte{0xEF, 0xBB, 0xBF})
+	writer, err := newCSVRecordWriter(file, layout)
 	if err != nil {
 		return err
 	}
+	if err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return errMsg("export.save_file", "CSV", err)
+	}
+	return nil
+}
 
-	// สร้าง writer สำหรับเขียนไฟล์ CSV
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// กำหนดคอลัมน์ที่ต้องการส่งออก
-	// ส่วนของข้อมูลพื้นฐานจาก FinancialData
-	baseColumns := []string{
-		"Symbol", "Year", "Quarter", "DateAsof", "TotalAssets", "TotalLiabilities",
-		"PaidupShareCapital", "ShareholderEquity", "TotalEquity",
-		"TotalRevenueQuarter", "TotalRevenueAccum", "TotalExpensesQuarter", "TotalExpensesAccum",
-		"EbitQuarter", "EbitAccum", "NetProfitQuarter", "NetProfitAccum",
-		"EpsQuarter", "EpsAccum", "OperatingCashFlow", "InvestingCashFlow", "FinancingCashFlow",
-		"ROE", "ROA", "NetProfitMarginQuarter", "NetProfitMarginAccum", "DE",
-		"FixedAssetTurnover", "TotalAssetTurnover",
-	}
-
-	// ส่วนของข้อมูลราคาจาก PriceData (ถ้ามี)
-	priceColumns := []string{
-		"price_close", "price_pe", "price_pbv", "price_dividendYield", "price_marketCap",
-		"price_totalVolume", "price_high", "price_low", "price_open", "price_prior",
-	}
-
-	// รวมคอลัมน์ทั้งหมด
-	allColumns := append(baseColumns, priceColumns...)
-
-	// แปลงเป็นภาษาไทย (ถ้าต้องการ)
-	thaiColumnNames := make([]string, len(allColumns))
-	for i, col := range allColumns {
-		if thaiName, ok := columnThaiNames[col]; ok {
-			thaiColumnNames[i] = thaiName
-		} else if thaiName, ok := columnThaiNames[col[6:]]; ok && len(col) > 6 && col[:6] == "price_" {
-			// สำหรับคอลัมน์ราคาที่ขึ้นต้นด้วย price_
-			thaiColumnNames[i] = thaiName
-		} else {
-			thaiColumnNames[i] = col
-		}
+// csvRecordWriter - เขียนข้อมูลงบการเงินเป็นแถว CSV ทีละชุด
+// ใช้ร่วมกันระหว่างการส่งออกทั้งไฟล์และการเขียนแบบ streaming ระหว่างดึงข้อมูล
+type csvRecordWriter struct {
+	writer    *csv.Writer
+	columns   []string
+	precision PrecisionPolicy
+}
+
+// newCSVRecordWriter - สร้าง writer และเขียน BOM กับหัวคอลัมน์
+func newCSVRecordWriter(w io.Writer, layout ColumnLayout) (*csvRecordWriter, error) {
+	// ใส่ BOM เพื่อให้ Excel แสดงหัวคอลัมน์ภาษาไทยได้ถูกต้อง
+	if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+		return nil, err
 	}
 
-	// เขียนหัวคอลัมน์
-	if err := writer.Write(thaiColumnNames); err != nil {
-		return fmt.Errorf("ไม่สามารถเขียนหัวคอลัมน์: %v", err)
+	writer := csv.NewWriter(w)
+
+	// เขียนหัวคอลัมน์ตามภาษาที่เลือก
+	if err := writer.Write(layout.Headers()); err != nil {
+		return nil, errMsg("export.write_header", err)
 	}
 
-	// เขียนข้อมูลแต่ละแถว
-	for _, item := range data {
-		// สร้างแถวข้อมูลว่าง
-		row := make([]string, len(allColumns))
-
-		// เติมข้อมูลพื้นฐาน
-		for i, col := range baseColumns {
-			switch col {
-			case "Symbol":
-				row[i] = item.Symbol
-			case "Year":
-				row[i] = item.Year
-			case "Quarter":
-				row[i] = item.Quarter
-			case "DateAsof":
-				row[i] = item.DateAsof
-			case "TotalAssets":
-				row[i] = formatFloat(item.TotalAssets)
-			case "TotalLiabilities":
-				row[i] = formatFloat(item.TotalLiabilities)
-			case "PaidupShareCapital":
-				row[i] = formatFloat(item.PaidupShareCapital)
-			case "ShareholderEquity":
-				row[i] = formatFloat(item.ShareholderEquity)
-			case "TotalEquity":
-				row[i] = formatFloat(item.TotalEquity)
-			case "TotalRevenueQuarter":
-				row[i] = formatFloat(item.TotalRevenueQuarter)
-			case "TotalRevenueAccum":
-				row[i] = formatFloat(item.TotalRevenueAccum)
-			case "TotalExpensesQuarter":
-				row[i] = formatFloat(item.TotalExpensesQuarter)
-			case "TotalExpensesAccum":
-				row[i] = formatFloat(item.TotalExpensesAccum)
-			case "EbitQuarter":
-				row[i] = formatFloat(item.EbitQuarter)
-			case "EbitAccum":
-				row[i] = formatFloat(item.EbitAccum)
-			case "NetProfitQuarter":
-				row[i] = formatFloat(item.NetProfitQuarter)
-			case "NetProfitAccum":
-				row[i] = formatFloat(item.NetProfitAccum)
-			case "EpsQuarter":
-				row[i] = formatFloat(item.EpsQuarter)
-			case "EpsAccum":
-				row[i] = formatFloat(item.EpsAccum)
-			case "OperatingCashFlow":
-				row[i] = formatFloat(item.OperatingCashFlow)
-			case "InvestingCashFlow":
-				row[i] = formatFloat(item.InvestingCashFlow)
-			case "FinancingCashFlow":
-				row[i] = formatFloat(item.FinancingCashFlow)
-			case "ROE":
-				row[i] = formatFloat(item.Roe)
-			case "ROA":
-				row[i] = formatFloat(item.Roa)
-			case "NetProfitMarginQuarter":
-				row[i] = formatFloat(item.NetProfitMarginQuarter)
-			case "NetProfitMarginAccum":
-				row[i] = formatFloat(item.NetProfitMarginAccum)
-			case "DE":
-				row[i] = formatFloat(item.De)
-			case "FixedAssetTurnover":
-				row[i] = formatFloat(item.FixedAssetTurnover)
-			case "TotalAssetTurnover":
-				row[i] = formatFloat(item.TotalAssetTurnover)
-			}
-		}
+	return &csvRecordWriter{
+		writer:    writer,
+		columns:   layout.Names(),
+		precision: layout.Precision(exportPrecision),
+	}, nil
+}
 
-		// เติมข้อมูลราคา (ถ้ามี)
-		if item.PriceData != nil {
-			for i, col := range priceColumns {
-				idx := i + len(baseColumns)
-				if val, ok := item.PriceData[col]; ok {
-					// แปลงค่าตามประเภทข้อมูล
-					switch v := val.(type) {
-					case float64:
-						row[idx] = formatFloat(v)
-					case string:
-						row[idx] = v
-					default:
-						row[idx] = fmt.Sprintf("%v", v)
-					}
-				}
-			}
+// Write - เขียนข้อมูลแต่ละแถว
+func (c *csvRecordWriter) Write(data []FinancialData) error {
+	for _, item := range data {
+		row := make([]string, len(c.columns))
+		for i, col := range c.columns {
+			row[i] = formatValue(c.precision, col, localizeValue(col, financialColumnValue(item, col)))
 		}
 
 		// เขียนแถวข้อมูล
-		if err := writer.Write(row); err != nil {
-			return fmt.Errorf("ไม่สามารถเขียนข้อมูลแถว: %v", err)
+		if err := c.writer.Write(row); err != nil {
+			return errMsg("export.write_row", err)
 		}
 	}
-
-	fmt.Printf("ส่งออกข้อมูลเรียบร้อยแล้วที่ %s จำนวน %d รายการ\n", filename, len(data))
 	return nil
 }
 
-// formatFloat - ฟังก์ชันช่วยแปลงตัวเลขทศนิยมเป็นสตริง
+// Flush - เขียนข้อมูลที่ค้างอยู่ใน buffer ลงไฟล์
+func (c *csvRecordWriter) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// formatFloat - ฟังก์ชันช่วยแปลงตัวเลขทศนิยมเป็นสตริง (รูปแบบเดิม ใช้กับโหมด auto ของ PrecisionPolicy)
 func formatFloat(f float64) string {
 	if f == 0 {
 		return "0"
@@ -209,3 +143,51 @@ func formatFloat(f float64) string {
 	// สำหรับตัวเลขทั่วไป แสดง 4 ตำแหน่งทศนิยม
 	return strconv.FormatFloat(f, 'f', 4, 64)
 }
+
+// formatValue - แปลงค่าของคอลัมน์เป็นสตริงตามประเภทข้อมูล ตัวเลขทศนิยมใช้กฎความละเอียดของคอลัมน์
+func formatValue(precision PrecisionPolicy, col string, val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case float64:
+		return precision.Format(col, v)
+	case int:
+		return strconv.Itoa(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// writeReportCSV - เขียนรายงานเป็นไฟล์ CSV (มี BOM และหัวคอลัมน์ตามภาษาที่เลือก)
+// ใช้กับรายงานที่ไม่ได้เป็นข้อมูลงบการเงินรายงวด เช่น ผลการตรวจสอบข้อมูล
+func writeReportCSV(filename string, columns []string, rows [][]string) error {
+	file, err := createOutput(filename)
+	if err != nil {
+		return errMsg("export.create_file", "CSV", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+		return errMsg("export.write_header", err)
+	}
+
+	writer := csv.NewWriter(file)
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = columnHeader(col)
+	}
+	if err := writer.Write(headers); err != nil {
+		return errMsg("export.write_header", err)
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return errMsg("export.write_row", err)
+	}
+	if err := file.Close(); err != nil {
+		return errMsg("export.save_file", "CSV", err)
+	}
+
+	logMsg("export.done", displayPath(filename), len(rows))
+	return nil
+}
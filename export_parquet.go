@@ -0,0 +1,355 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+)
+
+// parquetSchemaVersion - เพิ่มเลขนี้ทุกครั้งที่เปลี่ยนโครงสร้างคอลัมน์ของไฟล์ Parquet
+// 2: เพิ่มคอลัมน์ lineage_*
+const parquetSchemaVersion = "2"
+
+// financialParquetRow - โครงสร้างแถวของไฟล์ Parquet ข้อมูลงบการเงิน + ราคา ณ สิ้นไตรมาส
+// ลำดับและชื่อคอลัมน์ต้องคงที่ เพราะมีโค้ดฝั่ง Python/Spark อ่านตามชื่อคอลัมน์
+type financialParquetRow struct {
+	Symbol                 string   `parquet:"symbol"`
+	Year                   *int32   `parquet:"year"`
+	Quarter                *int32   `parquet:"quarter"`
+	CalendarYear           *int32   `parquet:"calendar_year"`
+	CalendarQuarter        *int32   `parquet:"calendar_quarter"`
+	FiscalYearEndMonth     int32    `parquet:"fiscal_year_end_month"`
+	FinancialStatementType string   `parquet:"financial_statement_type"`
+	DateAsof               int32    `parquet:"date_asof,optional,date"`
+	AccountPeriod          string   `parquet:"account_period"`
+	TotalAssets            float64  `parquet:"total_assets"`
+	TotalLiabilities       float64  `parquet:"total_liabilities"`
+	PaidupShareCapital     float64  `parquet:"paidup_share_capital"`
+	ShareholderEquity      float64  `parquet:"shareholder_equity"`
+	TotalEquity            float64  `parquet:"total_equity"`
+	TotalRevenueQuarter    float64  `parquet:"total_revenue_quarter"`
+	TotalRevenueAccum      float64  `parquet:"total_revenue_accum"`
+	TotalExpensesQuarter   float64  `parquet:"total_expenses_quarter"`
+	TotalExpensesAccum     float64  `parquet:"total_expenses_accum"`
+	EbitQuarter            float64  `parquet:"ebit_quarter"`
+	EbitAccum              float64  `parquet:"ebit_accum"`
+	NetProfitQuarter       float64  `parquet:"net_profit_quarter"`
+	NetProfitAccum         float64  `parquet:"net_profit_accum"`
+	EpsQuarter             float64  `parquet:"eps_quarter"`
+	EpsAccum               float64  `parquet:"eps_accum"`
+	OperatingCashFlow      float64  `parquet:"operating_cash_flow"`
+	InvestingCashFlow      float64  `parquet:"investing_cash_flow"`
+	FinancingCashFlow      float64  `parquet:"financing_cash_flow"`
+	Roe                    float64  `parquet:"roe"`
+	Roa                    float64  `parquet:"roa"`
+	NetProfitMarginQuarter float64  `parquet:"net_profit_margin_quarter"`
+	NetProfitMarginAccum   float64  `parquet:"net_profit_margin_accum"`
+	De                     float64  `parquet:"de"`
+	FixedAssetTurnover     float64  `parquet:"fixed_asset_turnover"`
+	TotalAssetTurnover     float64  `parquet:"total_asset_turnover"`
+	PriceDate              int32    `parquet:"price_date,optional,date"`
+	PricePrior             *float64 `parquet:"price_prior"`
+	PriceOpen              *float64 `parquet:"price_open"`
+	PriceHigh              *float64 `parquet:"price_high"`
+	PriceLow               *float64 `parquet:"price_low"`
+	PriceClose             *float64 `parquet:"price_close"`
+	PriceAverage           *float64 `parquet:"price_average"`
+	PriceTotalVolume       *float64 `parquet:"price_total_volume"`
+	PriceTotalValue        *float64 `parquet:"price_total_value"`
+	PricePe                *float64 `parquet:"price_pe"`
+	PricePbv               *float64 `parquet:"price_pbv"`
+	PriceBvps              *float64 `parquet:"price_bvps"`
+	PriceDividendYield     *float64 `parquet:"price_dividend_yield"`
+	PriceMarketCap         *float64 `parquet:"price_market_cap"`
+	PriceVolumeTurnover    *float64 `parquet:"price_volume_turnover"`
+	LineageRunID           string   `parquet:"lineage_run_id"`
+	LineageEndpoint        string   `parquet:"lineage_endpoint"`
+	LineageParams          string   `parquet:"lineage_params"`
+	LineageFetchedAt       string   `parquet:"lineage_fetched_at"`
+	LineageResponseHash    string   `parquet:"lineage_response_hash"`
+	LineagePriceEndpoint   string   `parquet:"lineage_price_endpoint"`
+	LineagePriceParams     string   `parquet:"lineage_price_params"`
+	LineagePriceFetchedAt  string   `parquet:"lineage_price_fetched_at"`
+	LineagePriceHash       string   `parquet:"lineage_price_response_hash"`
+	LineagePriceRequested  string   `parquet:"lineage_price_requested_date"`
+	LineagePriceDate       string   `parquet:"lineage_price_date"`
+	LineagePriceExactDate  bool     `parquet:"lineage_price_exact_date"`
+}
+
+// priceParquetRow - โครงสร้างแถวของไฟล์ Parquet ราคารายวัน
+type priceParquetRow struct {
+	Symbol            string   `parquet:"symbol"`
+	Date              int32    `parquet:"date,optional,date"`
+	SecurityType      string   `parquet:"security_type"`
+	AdjustedPriceFlag string   `parquet:"adjusted_price_flag"`
+	Prior             float64  `parquet:"prior"`
+	Open              float64  `parquet:"open"`
+	High              float64  `parquet:"high"`
+	Low               float64  `parquet:"low"`
+	Close             float64  `parquet:"close"`
+	Average           float64  `parquet:"average"`
+	AomVolume         float64  `parquet:"aom_volume"`
+	AomValue          float64  `parquet:"aom_value"`
+	TrVolume          *float64 `parquet:"tr_volume"`
+	TrValue           *float64 `parquet:"tr_value"`
+	TotalVolume       float64  `parquet:"total_volume"`
+	TotalValue        float64  `parquet:"total_value"`
+	Pe                *float64 `parquet:"pe"`
+	Pbv               *float64 `parquet:"pbv"`
+	Bvps              *float64 `parquet:"bvps"`
+	DividendYield     *float64 `parquet:"dividend_yield"`
+	MarketCap         *float64 `parquet:"market_cap"`
+	VolumeTurnover    *float64 `parquet:"volume_turnover"`
+}
+
+// parquetCodec - แปลงชื่อวิธีบีบอัดจาก command line เป็น codec ของ Parquet
+func parquetCodec(name string) (compress.Codec, error) {
+	switch strings.ToLower(name) {
+	case "", "snappy":
+		return &parquet.Snappy, nil
+	case "zstd":
+		return &parquet.Zstd, nil
+	case "gzip":
+		return &parquet.Gzip, nil
+	case "none", "uncompressed":
+		return &parquet.Uncompressed, nil
+	default:
+		return nil, errMsg("export.unknown_compression", name)
+	}
+}
+
+// ExportFinancialsToParquet - ส่งออกข้อมูลงบการเงินพร้อมราคา ณ สิ้นไตรมาสเป็นไฟล์ Parquet
+func ExportFinancialsToParquet(data []FinancialData, filename, compression string) error {
+	if len(data) == 0 {
+		return errMsg("export.no_data")
+	}
+
+	if err := writeFinancialsParquet(data, filename, compression); err != nil {
+		return err
+	}
+
+	logMsg("export.done", displayPath(filename), len(data))
+	return nil
+}
+
+// writeFinancialsParquet - เขียนไฟล์ Parquet ข้อมูลงบการเงินโดยไม่แสดงข้อความ (ใช้ร่วมกับ dataset)
+func writeFinancialsParquet(data []FinancialData, filename, compression string) error {
+	rows := make([]financialParquetRow, len(data))
+	for i, item := range data {
+		rows[i] = toFinancialParquetRow(item)
+		applyParquetPrecision(&rows[i])
+	}
+	return writeParquetFile(filename, compression, rows)
+}
+
+// ExportPricesToParquet - ส่งออกราคารายวันเป็นไฟล์ Parquet
+func ExportPricesToParquet(history []EODPriceBySymbol, filename, compression string) error {
+	if len(history) == 0 {
+		return errMsg("export.no_price_data")
+	}
+
+	if err := writePricesParquet(history, filename, compression); err != nil {
+		return err
+	}
+
+	logMsg("export.prices_done", displayPath(filename), len(history))
+	return nil
+}
+
+// writePricesParquet - เขียนไฟล์ Parquet ราคารายวันโดยไม่แสดงข้อความ (ใช้ร่วมกับ dataset)
+func writePricesParquet(history []EODPriceBySymbol, filename, compression string) error {
+	rows := make([]priceParquetRow, len(history))
+	for i, price := range history {
+		rows[i] = priceParquetRow{
+			Symbol:            price.Symbol,
+			Date:              parquetDate(price.Date),
+			SecurityType:      price.SecurityType,
+			AdjustedPriceFlag: price.AdjustedPriceFlag,
+			Prior:             price.Prior,
+			Open:              price.Open,
+			High:              price.High,
+			Low:               price.Low,
+			Close:             price.Close,
+			Average:           price.Average,
+			AomVolume:         price.AomVolume,
+			AomValue:          price.AomValue,
+			TrVolume:          price.TrVolume,
+			TrValue:           price.TrValue,
+			TotalVolume:       price.TotalVolume,
+			TotalValue:        price.TotalValue,
+			Pe:                price.Pe,
+			Pbv:               price.Pbv,
+			Bvps:              price.Bvps,
+			DividendYield:     price.DividendYield,
+			MarketCap:         price.MarketCap,
+			VolumeTurnover:    price.VolumeTurnover,
+		}
+	}
+
+	for i := range rows {
+		applyParquetPrecision(&rows[i])
+	}
+
+	return writeParquetFile(filename, compression, rows)
+}
+
+// writeParquetFile - เขียนแถวทั้งหมดลงไฟล์ Parquet พร้อม metadata ของ schema
+func writeParquetFile[T any](filename, compression string, rows []T) error {
+	codec, err := parquetCodec(compression)
+	if err != nil {
+		return err
+	}
+
+	file, err := createOutput(filename)
+	if err != nil {
+		return errMsg("export.create_file", "Parquet", err)
+	}
+	defer file.Close()
+
+	writer := parquet.NewGenericWriter[T](file,
+		parquet.Compression(codec),
+		parquet.KeyValueMetadata("stock_predict.schema_version", parquetSchemaVersion),
+		parquet.CreatedBy("stock-predict", parquetSchemaVersion, ""),
+	)
+
+	if _, err := writer.Write(rows); err != nil {
+		return errMsg("export.parquet_write", err)
+	}
+	if err := writer.Close(); err != nil {
+		return errMsg("export.parquet_close", err)
+	}
+	if err := file.Close(); err != nil {
+		return errMsg("export.save_file", "Parquet", err)
+	}
+	return nil
+}
+
+func toFinancialParquetRow(item FinancialData) financialParquetRow {
+	row := financialParquetRow{
+		Symbol:                 item.Symbol,
+		Year:                   parquetInt(item.Year),
+		Quarter:                parquetInt(item.Quarter),
+		FiscalYearEndMonth:     int32(item.Fiscal.YearEndMonth),
+		FinancialStatementType: item.FinancialStatementType,
+		DateAsof:               parquetDate(item.DateAsof),
+		AccountPeriod:          item.AccountPeriod,
+		TotalAssets:            item.TotalAssets,
+		TotalLiabilities:       item.TotalLiabilities,
+		PaidupShareCapital:     item.PaidupShareCapital,
+		ShareholderEquity:      item.ShareholderEquity,
+		TotalEquity:            item.TotalEquity,
+		TotalRevenueQuarter:    item.TotalRevenueQuarter,
+		TotalRevenueAccum:      item.TotalRevenueAccum,
+		TotalExpensesQuarter:   item.TotalExpensesQuarter,
+		TotalExpensesAccum:     item.TotalExpensesAccum,
+		EbitQuarter:            item.EbitQuarter,
+		EbitAccum:              item.EbitAccum,
+		NetProfitQuarter:       item.NetProfitQuarter,
+		NetProfitAccum:         item.NetProfitAccum,
+		EpsQuarter:             item.EpsQuarter,
+		EpsAccum:               item.EpsAccum,
+		OperatingCashFlow:      item.OperatingCashFlow,
+		InvestingCashFlow:      item.InvestingCashFlow,
+		FinancingCashFlow:      item.FinancingCashFlow,
+		Roe:                    item.Roe,
+		Roa:                    item.Roa,
+		NetProfitMarginQuarter: item.NetProfitMarginQuarter,
+		NetProfitMarginAccum:   item.NetProfitMarginAccum,
+		De:                     item.De,
+		FixedAssetTurnover:     item.FixedAssetTurnover,
+		TotalAssetTurnover:     item.TotalAssetTurnover,
+		LineageRunID:           item.Lineage.RunID,
+		LineageEndpoint:        item.Lineage.Endpoint,
+		LineageParams:          item.Lineage.Params,
+		LineageFetchedAt:       item.Lineage.FetchedAt,
+		LineageResponseHash:    item.Lineage.ResponseHash,
+		LineagePriceEndpoint:   item.Lineage.PriceEndpoint,
+		LineagePriceParams:     item.Lineage.PriceParams,
+		LineagePriceFetchedAt:  item.Lineage.PriceFetchedAt,
+		LineagePriceHash:       item.Lineage.PriceResponseHash,
+		LineagePriceRequested:  item.Lineage.PriceRequestedDate,
+		LineagePriceDate:       item.Lineage.PriceDate,
+		LineagePriceExactDate:  item.Lineage.PriceExactDate,
+	}
+
+	if calendar := calendarPeriodOf(item); !calendar.IsZero() {
+		year, quarter := int32(calendar.Year), int32(calendar.Quarter)
+		row.CalendarYear = &year
+		row.CalendarQuarter = &quarter
+	}
+
+	// ข้อมูลราคาที่ไม่มี (ดึงไม่สำเร็จหรือ API ส่ง null) จะเป็น null ในไฟล์
+	if item.PriceData != nil {
+		if date, ok := item.PriceData["price_date"].(string); ok {
+			row.PriceDate = parquetDate(date)
+		}
+		row.PricePrior = priceValue(item, "prior")
+		row.PriceOpen = priceValue(item, "open")
+		row.PriceHigh = priceValue(item, "high")
+		row.PriceLow = priceValue(item, "low")
+		row.PriceClose = priceValue(item, "close")
+		row.PriceAverage = priceValue(item, "average")
+		row.PriceTotalVolume = priceValue(item, "totalVolume")
+		row.PriceTotalValue = priceValue(item, "totalValue")
+		row.PricePe = priceValue(item, "pe")
+		row.PricePbv = priceValue(item, "pbv")
+		row.PriceBvps = priceValue(item, "bvps")
+		row.PriceDividendYield = priceValue(item, "dividendYield")
+		row.PriceMarketCap = priceValue(item, "marketCap")
+		row.PriceVolumeTurnover = priceValue(item, "volumeTurnover")
+	}
+
+	return row
+}
+
+// priceValue - อ่านค่าราคาจาก PriceData ตามชื่อ field ของ API คืน nil ถ้าไม่มีค่า
+func priceValue(item FinancialData, key string) *float64 {
+	if item.PriceData == nil {
+		return nil
+	}
+	if v, ok := item.PriceData["price_"+key].(float64); ok {
+		return &v
+	}
+	return nil
+}
+
+func parquetInt(s string) *int32 {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return nil
+	}
+	v := int32(n)
+	return &v
+}
+
+// parquetDate - แปลงวันที่เป็นจำนวนวันนับจาก 1970-01-01 ตามชนิด DATE ของ Parquet
+// คืน 0 ถ้าไม่มีวันที่ คอลัมน์วันที่เป็น optional จึงเขียน 0 เป็น null
+func parquetDate(s string) int32 {
+	t, ok := parseAPIDate(s)
+	if !ok {
+		return 0
+	}
+	return int32(t.Unix() / int64(24*time.Hour/time.Second))
+}
+
+// applyParquetPrecision - ปรับตัวเลขในแถวตาม exportPrecision โดยอ้างอิงชื่อคอลัมน์ Parquet
+// ค่าเริ่มต้นเป็น raw จึงไม่เปลี่ยนค่าใด ๆ ถ้าไม่ได้กำหนด -precision
+func applyParquetPrecision(row interface{}) {
+	v := reflect.ValueOf(row).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		col := strings.Split(t.Field(i).Tag.Get("parquet"), ",")[0]
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.Float64:
+			field.SetFloat(exportPrecision.Apply(col, field.Float()))
+		case field.Kind() == reflect.Pointer && !field.IsNil() && field.Elem().Kind() == reflect.Float64:
+			adjusted := exportPrecision.Apply(col, field.Elem().Float())
+			field.Set(reflect.ValueOf(&adjusted))
+		}
+	}
+}
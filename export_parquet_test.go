@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestNullPriceFieldsStayNullInParquet(t *testing.T) {
+	body := `[{"date":"2024-03-28","symbol":"AAA","close":12.5,"pe":null,"pbv":1.5,"marketCap":null}]`
+	var prices []PriceData
+	if err := json.Unmarshal([]byte(body), &prices); err != nil {
+		t.Fatal(err)
+	}
+	item := quarterItem(2024, 1)
+	item.PriceData = priceDataMap(prices[0])
+
+	filename := filepath.Join(t.TempDir(), "financials.parquet")
+	if err := writeFinancialsParquet([]FinancialData{item}, filename, "snappy"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := parquet.ReadFile[financialParquetRow](filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("got %d rows; want 1", len(rows))
+	}
+
+	tests := []struct {
+		name string
+		got  *float64
+		want *float64
+	}{
+		{"null pe", rows[0].PricePe, nil},
+		{"null marketCap", rows[0].PriceMarketCap, nil},
+		{"pbv", rows[0].PricePbv, ptr(1.5)},
+		{"close", rows[0].PriceClose, ptr(12.5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.got == nil) != (tt.want == nil) || (tt.got != nil && *tt.got != *tt.want) {
+				t.Errorf("got %v; want %v", deref(tt.got), deref(tt.want))
+			}
+		})
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
+
+func deref(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
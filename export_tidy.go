@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ที่มาของค่าในรูปแบบ long/tidy
+const (
+	sourceFinancial = "financial"
+	sourcePrice     = "price"
+	sourceDerived   = "derived"
+	sourceTechnical = "technical"
+	sourceLabel     = "label"
+)
+
+// TidyRow - หนึ่งแถวต่อหนึ่งค่า (หุ้น, งวด, ตัวชี้วัด) เหมาะกับการ plot และการเพิ่มตัวชี้วัดใหม่
+type TidyRow struct {
+	Symbol         string  `json:"symbol"`
+	Year           string  `json:"year"`
+	Quarter        string  `json:"quarter"`
+	CalendarPeriod string  `json:"calendarPeriod"`
+	StatementType  string  `json:"statementType"`
+	Metric         string  `json:"metric"`
+	Value          float64 `json:"value"`
+	Source         string  `json:"source"`
+}
+
+var tidyColumns = []string{"symbol", "year", "quarter", "calendarPeriod", "statementType", "metric", "value", "source"}
+
+// financialMetricFields - field ตัวเลขทั้งหมดของ FinancialData (อ่านจาก struct จึงไม่ต้องแก้เมื่อเพิ่ม field)
+var financialMetricFields = func() []reflect.StructField {
+	var fields []reflect.StructField
+	t := reflect.TypeOf(FinancialData{})
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() == reflect.Float64 {
+			fields = append(fields, t.Field(i))
+		}
+	}
+	return fields
+}()
+
+// tidyRows - แปลงข้อมูลแบบกว้าง (หนึ่งแถวต่องวด) เป็นแบบยาว (หนึ่งแถวต่อค่า)
+func tidyRows(data []FinancialData) []TidyRow {
+	var rows []TidyRow
+	for _, item := range data {
+		base := TidyRow{
+			Symbol:         item.Symbol,
+			Year:           item.Year,
+			Quarter:        item.Quarter,
+			CalendarPeriod: calendarPeriodOf(item).String(),
+			StatementType:  item.FinancialStatementType,
+		}
+
+		// ข้อมูลจากงบการเงิน
+		v := reflect.ValueOf(item)
+		for _, field := range financialMetricFields {
+			row := base
+			row.Metric = jsonFieldName(field)
+			row.Value = v.FieldByIndex(field.Index).Float()
+			row.Source = sourceFinancial
+			rows = append(rows, row)
+		}
+
+		// ข้อมูลราคา ณ สิ้นไตรมาส (เฉพาะค่าตัวเลข)
+		keys := make([]string, 0, len(item.PriceData))
+		for key := range item.PriceData {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if value, ok := item.PriceData[key].(float64); ok {
+				row := base
+				row.Metric = key
+				row.Value = value
+				row.Source = sourcePrice
+				rows = append(rows, row)
+			}
+		}
+
+		// ตัวชี้วัดที่คำนวณเพิ่ม (คำนวณไว้แล้วด้วย computeDerivedMetrics)
+		for _, metric := range derivedMetrics {
+			if value, ok := item.Metrics[metric.Name]; ok {
+				row := base
+				row.Metric = metric.Name
+				row.Value = value
+				row.Source = sourceDerived
+				rows = append(rows, row)
+			}
+		}
+
+		// ตัวชี้วัดทางเทคนิค (มีเฉพาะเมื่อใช้ -technicals)
+		for _, indicator := range technicalIndicators {
+			if value, ok := item.Technicals[indicator.Name]; ok {
+				row := base
+				row.Metric = indicator.Name
+				row.Value = value
+				row.Source = sourceTechnical
+				rows = append(rows, row)
+			}
+		}
+
+		// เป้าหมาย (มีเฉพาะเมื่อใช้ -labels)
+		for _, horizon := range labelHorizons {
+			for _, kind := range labelKinds {
+				name := labelName(kind, horizon)
+				if value, ok := item.Labels[name]; ok {
+					row := base
+					row.Metric = name
+					row.Value = value
+					row.Source = sourceLabel
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// tidyCSVRecordWriter - เขียนข้อมูลแบบ long/tidy เป็นแถว CSV ทีละชุด (ใช้ร่วมกับการเขียนแบบ streaming)
+type tidyCSVRecordWriter struct {
+	writer *csv.Writer
+	rows   int
+}
+
+func newTidyCSVRecordWriter(w io.Writer) (*tidyCSVRecordWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(tidyColumns); err != nil {
+		return nil, errMsg("export.write_header", err)
+	}
+	return &tidyCSVRecordWriter{writer: writer}, nil
+}
+
+func (t *tidyCSVRecordWriter) Write(data []FinancialData) error {
+	for _, row := range tidyRows(data) {
+		record := []string{
+			row.Symbol, row.Year, row.Quarter, row.CalendarPeriod, row.StatementType,
+			row.Metric, exportPrecision.Format(row.Metric, row.Value), row.Source,
+		}
+		if err := t.writer.Write(record); err != nil {
+			return errMsg("export.write_row", err)
+		}
+		t.rows++
+	}
+	return nil
+}
+
+func (t *tidyCSVRecordWriter) Flush() error {
+	t.writer.Flush()
+	if err := t.writer.Error(); err != nil {
+		return errMsg("export.write_row", err)
+	}
+	return nil
+}
+
+// tidyJSONLRecordWriter - เขียนข้อมูลแบบ long/tidy เป็น JSON Lines ทีละชุด
+type tidyJSONLRecordWriter struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+	rows    int
+}
+
+func newTidyJSONLRecordWriter(w io.Writer) *tidyJSONLRecordWriter {
+	buf := bufio.NewWriter(w)
+	return &tidyJSONLRecordWriter{buf: buf, encoder: json.NewEncoder(buf)}
+}
+
+func (t *tidyJSONLRecordWriter) Write(data []FinancialData) error {
+	for _, row := range tidyRows(data) {
+		row.Value = exportPrecision.Apply(row.Metric, row.Value)
+		if err := t.encoder.Encode(row); err != nil {
+			return errMsg("export.write_row", err)
+		}
+		t.rows++
+	}
+	return nil
+}
+
+func (t *tidyJSONLRecordWriter) Flush() error {
+	return t.buf.Flush()
+}
+
+// ExportTidyCSV - ส่งออกข้อมูลแบบ long/tidy เป็นไฟล์ CSV
+func ExportTidyCSV(data []FinancialData, filename string) error {
+	if len(data) == 0 {
+		return errMsg("export.no_data")
+	}
+
+	file, err := createOutput(filename)
+	if err != nil {
+		return errMsg("export.create_file", "CSV", err)
+	}
+	defer file.Close()
+
+	writer, err := newTidyCSVRecordWriter(file)
+	if err != nil {
+		return err
+	}
+	if err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return errMsg("export.save_file", "CSV", err)
+	}
+
+	logMsg("export.done", displayPath(filename), writer.rows)
+	return nil
+}
+
+// ExportTidyJSONL - ส่งออกข้อมูลแบบ long/tidy เป็นไฟล์ JSON Lines
+func ExportTidyJSONL(data []FinancialData, filename string) error {
+	if len(data) == 0 {
+		return errMsg("export.no_data")
+	}
+
+	file, err := createOutput(filename)
+	if err != nil {
+		return errMsg("export.create_file", "JSON Lines", err)
+	}
+	defer file.Close()
+
+	writer := newTidyJSONLRecordWriter(file)
+	if err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return errMsg("export.save_file", "JSON Lines", err)
+	}
+
+	logMsg("export.done", displayPath(filename), writer.rows)
+	return nil
+}
+
+// jsonFieldName - ชื่อ field ตาม json tag (ใช้ชื่อ field ของ Go ถ้าไม่มี tag)
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// fieldKind - ชนิดของ field โดยไม่นับ pointer (*float64 ถือเป็น float64)
+func fieldKind(field reflect.StructField) reflect.Kind {
+	if field.Type.Kind() == reflect.Pointer {
+		return field.Type.Elem().Kind()
+	}
+	return field.Type.Kind()
+}
+
+// safeDiv - หารโดยคืน false เมื่อตัวหารเป็นศูนย์
+func safeDiv(a, b float64) (float64, bool) {
+	if b == 0 {
+		return 0, false
+	}
+	return a / b, true
+}
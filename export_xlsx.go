@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ชื่อชีตในไฟล์ Excel
+const (
+	sheetFinancials = "Financials"
+	sheetPrices     = "PriceSnapshots"
+	sheetSummary    = "Summary"
+	sheetErrors     = "FetchErrors"
+)
+
+// รูปแบบตัวเลขใน Excel
+// - จำนวนเงินแสดงเป็นล้านบาท (ใช้ ,, เพื่อหารด้วยล้านตอนแสดงผลโดยไม่เปลี่ยนค่าจริงในเซลล์)
+// - อัตราส่วนที่ API ส่งมาเป็นเปอร์เซ็นต์อยู่แล้ว จึงแสดงเครื่องหมาย % โดยไม่คูณ 100
+const (
+	numFmtMillions = `#,##0.00,,`
+	numFmtPercent  = `0.00"%"`
+	numFmtDecimal  = `#,##0.0000`
+	numFmtInteger  = `0`
+)
+
+// คอลัมน์ที่เป็นจำนวนเงิน (บาท)
+var moneyColumns = map[string]bool{
+	"TotalAssets": true, "TotalLiabilities": true, "PaidupShareCapital": true,
+	"ShareholderEquity": true, "TotalEquity": true,
+	"TotalRevenueQuarter": true, "TotalRevenueAccum": true,
+	"TotalExpensesQuarter": true, "TotalExpensesAccum": true,
+	"EbitQuarter": true, "EbitAccum": true, "NetProfitQuarter": true, "NetProfitAccum": true,
+	"OperatingCashFlow": true, "InvestingCashFlow": true, "FinancingCashFlow": true,
+	"price_marketCap": true, "price_totalValue": true,
+}
+
+// คอลัมน์ที่เป็นเปอร์เซ็นต์
+var percentColumns = map[string]bool{
+	"ROE": true, "ROA": true, "NetProfitMarginQuarter": true, "NetProfitMarginAccum": true,
+	"price_dividendYield": true,
+}
+
+// คอลัมน์ที่เป็นจำนวนเต็ม
+var integerColumns = map[string]bool{
+	"CalendarYear": true, "CalendarQuarter": true, "FiscalYearEndMonth": true,
+	"Quarters": true, "MissingPrices": true,
+}
+
+// columnNumFmt - รูปแบบตัวเลขของคอลัมน์ ใช้กฎจาก -precision ก่อน (ค่าเริ่มต้นเป็นทศนิยม 4 ตำแหน่ง)
+func columnNumFmt(col string) string {
+	if numFmt := exportPrecision.NumFmt(col); numFmt != "" {
+		return numFmt
+	}
+	switch {
+	case moneyColumns[col]:
+		return numFmtMillions
+	case percentColumns[col]:
+		return numFmtPercent
+	case integerColumns[col]:
+		return numFmtInteger
+	}
+	return numFmtDecimal
+}
+
+// ExportToXLSX - ส่งออกข้อมูลเป็นไฟล์ Excel แยกชีตงบการเงิน ราคา สรุปรายหุ้น และข้อผิดพลาด
+func ExportToXLSX(data []FinancialData, fetchErrors []FetchError, filename string) error {
+	if len(data) == 0 {
+		return errMsg("export.no_data")
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	w := &xlsxWriter{file: f, styles: make(map[string]int)}
+
+	// ชีตงบการเงิน
+	financialRows := make([][]interface{}, len(data))
+	for i, item := range data {
+		row := make([]interface{}, len(baseColumns))
+		for j, col := range baseColumns {
+			row[j] = localizeValue(col, financialColumnValue(item, col))
+		}
+		financialRows[i] = row
+	}
+	if err := w.writeSheet(sheetFinancials, baseColumns, financialRows); err != nil {
+		return err
+	}
+
+	// ชีตราคา ณ สิ้นไตรมาส
+	priceSheetColumns := append([]string{"Symbol", "Year", "Quarter", "CalendarYear", "CalendarQuarter", "price_date"}, priceColumns...)
+	var priceRows [][]interface{}
+	for _, item := range data {
+		if item.PriceData == nil {
+			continue
+		}
+		row := make([]interface{}, len(priceSheetColumns))
+		for j, col := range priceSheetColumns {
+			if strings.HasPrefix(col, "price_") {
+				row[j] = item.PriceData[col]
+			} else {
+				row[j] = localizeValue(col, financialColumnValue(item, col))
+			}
+		}
+		priceRows = append(priceRows, row)
+	}
+	if err := w.writeSheet(sheetPrices, priceSheetColumns, priceRows); err != nil {
+		return err
+	}
+
+	// ชีตสรุปรายหุ้น
+	if err := w.writeSheet(sheetSummary, summaryColumns, symbolSummaryRows(data)); err != nil {
+		return err
+	}
+
+	// ชีตข้อผิดพลาดจากการดึงข้อมูล
+	errorRows := make([][]interface{}, len(fetchErrors))
+	for i, fe := range fetchErrors {
+		errorRows[i] = []interface{}{fe.Symbol, fe.Stage, fe.Message}
+	}
+	if err := w.writeSheet(sheetErrors, []string{"Symbol", "Stage", "Message"}, errorRows); err != nil {
+		return err
+	}
+
+	// ลบชีตเริ่มต้นที่ excelize สร้างไว้ให้
+	if err := f.DeleteSheet("Sheet1"); err != nil {
+		return err
+	}
+
+	out, err := createOutput(filename)
+	if err != nil {
+		return errMsg("export.create_file", "Excel", err)
+	}
+	defer out.Close()
+	if err := f.Write(out); err != nil {
+		return errMsg("export.save_file", "Excel", err)
+	}
+	if err := out.Close(); err != nil {
+		return errMsg("export.save_file", "Excel", err)
+	}
+
+	logMsg("export.done", displayPath(filename), len(data))
+	return nil
+}
+
+// summaryColumns - คอลัมน์ของชีตสรุปรายหุ้น
+var summaryColumns = []string{
+	"Symbol", "FiscalYearEndMonth", "Quarters", "FirstPeriod", "LastPeriod", "MissingPrices",
+	"TotalAssets", "TotalEquity", "TotalRevenueAccum", "NetProfitAccum", "ROE", "ROA", "DE",
+	"price_close", "price_marketCap",
+}
+
+// symbolSummaryRows - สรุปข้อมูลล่าสุดของแต่ละหุ้น (ข้อมูลต้องเรียงแบบ sortFinancialData แล้ว)
+func symbolSummaryRows(data []FinancialData) [][]interface{} {
+	var rows [][]interface{}
+	for start := 0; start < len(data); {
+		end := start
+		missingPrices := 0
+		for end < len(data) && data[end].Symbol == data[start].Symbol {
+			if data[end].PriceData == nil {
+				missingPrices++
+			}
+			end++
+		}
+
+		latest, oldest := data[start], data[end-1]
+		row := []interface{}{
+			latest.Symbol, latest.Fiscal.YearEndMonth, end - start,
+			displayPeriod(calendarPeriodOf(oldest)), displayPeriod(calendarPeriodOf(latest)), missingPrices,
+			latest.TotalAssets, latest.TotalEquity, latest.TotalRevenueAccum, latest.NetProfitAccum,
+			latest.Roe, latest.Roa, latest.De, nil, nil,
+		}
+		if latest.PriceData != nil {
+			row[len(row)-2] = latest.PriceData["price_close"]
+			row[len(row)-1] = latest.PriceData["price_marketCap"]
+		}
+		rows = append(rows, row)
+		start = end
+	}
+	return rows
+}
+
+// xlsxWriter - ตัวช่วยเขียนชีตพร้อมรูปแบบหัวตาราง ตัวกรอง และรูปแบบตัวเลข
+type xlsxWriter struct {
+	file   *excelize.File
+	styles map[string]int
+}
+
+func (w *xlsxWriter) writeSheet(key string, columns []string, rows [][]interface{}) error {
+	sheet := key
+	if name, ok := sheetNames[key]; ok {
+		sheet = name.String()
+	}
+	if _, err := w.file.NewSheet(sheet); err != nil {
+		return errMsg("export.create_sheet", sheet, err)
+	}
+
+	headerStyle, err := w.file.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#DDEBF7"}},
+	})
+	if err != nil {
+		return err
+	}
+
+	headers := make([]interface{}, len(columns))
+	for i, col := range columns {
+		headers[i] = columnHeader(col)
+	}
+	if err := w.file.SetSheetRow(sheet, "A1", &headers); err != nil {
+		return err
+	}
+
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := w.file.SetSheetRow(sheet, cell, &row); err != nil {
+			return errMsg("export.write_row", err)
+		}
+	}
+
+	lastCol, _ := excelize.ColumnNumberToName(len(columns))
+	for i, col := range columns {
+		name, _ := excelize.ColumnNumberToName(i + 1)
+		if style, err := w.numberStyle(col); err != nil {
+			return err
+		} else if style != 0 {
+			if err := w.file.SetColStyle(sheet, name, style); err != nil {
+				return err
+			}
+		}
+		if err := w.file.SetColWidth(sheet, name, name, 16); err != nil {
+			return err
+		}
+	}
+	if err := w.file.SetCellStyle(sheet, "A1", lastCol+"1", headerStyle); err != nil {
+		return err
+	}
+
+	// ตรึงแถวหัวตารางและเปิดตัวกรอง
+	if err := w.file.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return err
+	}
+	lastRow := len(rows) + 1
+	return w.file.AutoFilter(sheet, fmt.Sprintf("A1:%s%d", lastCol, lastRow), nil)
+}
+
+// numberStyle - style ของคอลัมน์ตัวเลข คืน 0 ถ้าเป็นคอลัมน์ข้อความ
+func (w *xlsxWriter) numberStyle(col string) (int, error) {
+	switch col {
+	case "Symbol", "Year", "Quarter", "DateAsof", "price_date", "Stage", "Message", "FirstPeriod", "LastPeriod":
+		return 0, nil
+	}
+
+	numFmt := columnNumFmt(col)
+	if style, ok := w.styles[numFmt]; ok {
+		return style, nil
+	}
+	style, err := w.file.NewStyle(&excelize.Style{CustomNumFmt: &numFmt})
+	if err != nil {
+		return 0, err
+	}
+	w.styles[numFmt] = style
+	return style, nil
+}
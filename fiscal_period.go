@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period - ไตรมาสตามปีปฏิทิน (หรือปีบัญชี ขึ้นกับบริบท) ในรูปแบบ ปี + ไตรมาส 1..4
+type Period struct {
+	Year    int `json:"year"`
+	Quarter int `json:"quarter"`
+}
+
+// periodFromIndex - สร้าง Period จากลำดับไตรมาสต่อเนื่อง (Year*4 + Quarter-1)
+func periodFromIndex(idx int) Period {
+	year := idx / 4
+	q := idx % 4
+	if q < 0 {
+		q += 4
+		year--
+	}
+	return Period{Year: year, Quarter: q + 1}
+}
+
+// periodOfDate - หาไตรมาสปฏิทินที่วันที่นั้นตกอยู่
+func periodOfDate(t time.Time) Period {
+	return Period{Year: t.Year(), Quarter: (int(t.Month())-1)/3 + 1}
+}
+
+// Index - ลำดับไตรมาสต่อเนื่อง ใช้สำหรับเปรียบเทียบและคำนวณระยะห่าง
+func (p Period) Index() int {
+	return p.Year*4 + p.Quarter - 1
+}
+
+// Add - เลื่อนไตรมาสไป n ไตรมาส (ติดลบได้)
+func (p Period) Add(n int) Period {
+	return periodFromIndex(p.Index() + n)
+}
+
+// Before - true ถ้า p อยู่ก่อน o
+func (p Period) Before(o Period) bool {
+	return p.Index() < o.Index()
+}
+
+// IsZero - true ถ้ายังไม่ได้กำหนดค่า
+func (p Period) IsZero() bool {
+	return p.Year == 0 && p.Quarter == 0
+}
+
+// EndMonth - เดือนสุดท้ายของไตรมาส
+func (p Period) EndMonth() int {
+	return p.Quarter * 3
+}
+
+// EndDate - วันสุดท้ายของไตรมาส
+func (p Period) EndDate() time.Time {
+	return time.Date(p.Year, time.Month(p.EndMonth())+1, 0, 0, 0, 0, 0, time.UTC)
+}
+
+func (p Period) String() string {
+	return fmt.Sprintf("%dQ%d", p.Year, p.Quarter)
+}
+
+// FiscalCalendar - ปฏิทินปีบัญชีของแต่ละบริษัท
+// Shift คือจำนวนไตรมาสที่ต้องบวกเข้ากับไตรมาสปีบัญชีเพื่อให้ได้ไตรมาสปฏิทิน
+// (บริษัทที่ปิดงบเดือนธันวาคมจะมี Shift = 0)
+type FiscalCalendar struct {
+	YearEndMonth int `json:"yearEndMonth"`
+	Shift        int `json:"shift"`
+}
+
+// calendarYearEnd - ปฏิทินมาตรฐานที่ใช้เมื่อไม่มีข้อมูลวันที่ให้ตรวจสอบ
+var calendarYearEnd = FiscalCalendar{YearEndMonth: 12, Shift: 0}
+
+// ToCalendar - แปลงไตรมาสปีบัญชีเป็นไตรมาสปฏิทิน
+func (c FiscalCalendar) ToCalendar(fiscal Period) Period {
+	return fiscal.Add(c.Shift)
+}
+
+// ToFiscal - แปลงไตรมาสปฏิทินเป็นไตรมาสปีบัญชี
+func (c FiscalCalendar) ToFiscal(calendar Period) Period {
+	return calendar.Add(-c.Shift)
+}
+
+// IsCalendarYear - true ถ้าปีบัญชีตรงกับปีปฏิทิน
+func (c FiscalCalendar) IsCalendarYear() bool {
+	return c.Shift == 0
+}
+
+// fiscalPeriodOf - อ่านปีและไตรมาสตามที่ API ส่งมา (ปีบัญชี)
+func fiscalPeriodOf(item FinancialData) (Period, bool) {
+	year, err := strconv.Atoi(strings.TrimSpace(item.Year))
+	if err != nil {
+		return Period{}, false
+	}
+	quarter, err := strconv.Atoi(strings.TrimSpace(item.Quarter))
+	if err != nil || quarter < 1 || quarter > 4 {
+		// งบปี (เช่น quarter = "9" หรือ "Y") ให้ถือเป็นไตรมาส 4 ของปีบัญชี
+		quarter = 4
+	}
+	return Period{Year: year, Quarter: quarter}, true
+}
+
+// periodEndDateOf - หาวันสิ้นงวดของงบจาก DateAsof หรือ AccountPeriod
+func periodEndDateOf(item FinancialData) (time.Time, bool) {
+	if t, ok := parseAPIDate(item.DateAsof); ok {
+		return t, true
+	}
+
+	// AccountPeriod อาจอยู่ในรูปแบบช่วงวันที่ ให้ใช้วันที่ตัวท้ายสุด
+	fields := strings.FieldsFunc(item.AccountPeriod, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '~'
+	})
+	for i := len(fields) - 1; i >= 0; i-- {
+		if t, ok := parseAPIDate(fields[i]); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseAPIDate - แปลงวันที่จาก API ซึ่งมีได้หลายรูปแบบ
+func parseAPIDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	layouts := []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+		"02/01/2006",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// deriveFiscalCalendar - หาปฏิทินปีบัญชีของบริษัทจากวันสิ้นงวดของงบ
+// ใช้ค่า Shift ที่พบบ่อยที่สุด เพื่อไม่ให้ข้อมูลผิดพลาดบางรายการทำให้ทั้งบริษัทเพี้ยน
+func deriveFiscalCalendar(records []FinancialData) FiscalCalendar {
+	votes := make(map[int]int)
+	for _, item := range records {
+		fiscal, ok := fiscalPeriodOf(item)
+		if !ok {
+			continue
+		}
+		end, ok := periodEndDateOf(item)
+		if !ok {
+			continue
+		}
+		votes[periodOfDate(end).Index()-fiscal.Index()]++
+	}
+
+	if len(votes) == 0 {
+		return calendarYearEnd
+	}
+
+	bestShift, bestCount := 0, -1
+	for shift, count := range votes {
+		if count > bestCount || (count == bestCount && abs(shift) < abs(bestShift)) {
+			bestShift, bestCount = shift, count
+		}
+	}
+
+	// เดือนปิดงบ = เดือนสุดท้ายของไตรมาสปฏิทินที่ตรงกับไตรมาส 4 ของปีบัญชี
+	yearEnd := Period{Year: 2000, Quarter: 4}.Add(bestShift)
+	return FiscalCalendar{YearEndMonth: yearEnd.EndMonth(), Shift: bestShift}
+}
+
+// alignFiscalPeriods - กำหนดปฏิทินปีบัญชีและไตรมาสปฏิทินให้กับงบการเงินทุกรายการ
+// ข้อมูลที่ส่งเข้ามาอาจมีหลายบริษัทปนกัน จะแยกคำนวณปฏิทินตามหุ้น
+func alignFiscalPeriods(data []FinancialData) {
+	bySymbol := make(map[string][]int)
+	for i := range data {
+		bySymbol[data[i].Symbol] = append(bySymbol[data[i].Symbol], i)
+	}
+
+	for _, idxs := range bySymbol {
+		records := make([]FinancialData, len(idxs))
+		for j, idx := range idxs {
+			records[j] = data[idx]
+		}
+		calendar := deriveFiscalCalendar(records)
+
+		for _, idx := range idxs {
+			data[idx].Fiscal = calendar
+			if fiscal, ok := fiscalPeriodOf(data[idx]); ok {
+				data[idx].Calendar = calendar.ToCalendar(fiscal)
+			}
+		}
+	}
+}
+
+// calendarPeriodOf - ไตรมาสปฏิทินของงบ ถ้ายังไม่ได้ align จะถือว่าปีบัญชีตรงกับปีปฏิทิน
+func calendarPeriodOf(item FinancialData) Period {
+	if !item.Calendar.IsZero() {
+		return item.Calendar
+	}
+	fiscal, _ := fiscalPeriodOf(item)
+	return fiscal
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
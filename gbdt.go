@@ -0,0 +1,448 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// objective ของ gradient boosting
+const (
+	objectiveRegression = "regression" // squared error
+	objectiveBinary     = "binary"     // logloss ค่าที่ทำนายคือความน่าจะเป็นของคลาส 1
+)
+
+// GBDTConfig - การตั้งค่าการฝึก gradient-boosted decision trees
+type GBDTConfig struct {
+	Objective     string  `json:"objective"`
+	Trees         int     `json:"trees"`          // จำนวนต้นไม้สูงสุด
+	LearningRate  float64 `json:"learning_rate"`  // ย่อค่าของใบแต่ละต้น
+	MaxDepth      int     `json:"max_depth"`      // ความลึกสูงสุดของต้นไม้
+	MinLeaf       int     `json:"min_leaf"`       // จำนวนแถวขั้นต่ำในแต่ละใบ
+	L2            float64 `json:"l2"`             // regularization ของค่าในใบ
+	Subsample     float64 `json:"subsample"`      // สัดส่วนแถวที่สุ่มใช้ต่อต้น
+	ColSample     float64 `json:"colsample"`      // สัดส่วน feature ที่สุ่มใช้ต่อต้น
+	Bins          int     `json:"bins"`           // จำนวนช่วงสูงสุดของ histogram ต่อ feature
+	EarlyStopping int     `json:"early_stopping"` // หยุดเมื่อ validation ไม่ดีขึ้นกี่ต้นติดกัน (0 = ไม่หยุด)
+	Seed          int64   `json:"seed"`
+}
+
+// GBDTNode - โหนดของต้นไม้ โหนดที่ Left เป็น 0 คือใบ (โหนด 0 เป็นรากจึงไม่เป็นลูกของโหนดใด)
+// แถวที่ค่า feature <= Threshold ไปทางซ้าย ค่าที่ขาดไปทางซ้ายถ้า MissingLeft
+type GBDTNode struct {
+	Feature     int     `json:"f,omitempty"`
+	Threshold   float64 `json:"t,omitempty"`
+	MissingLeft bool    `json:"m,omitempty"`
+	Left        int     `json:"l,omitempty"`
+	Right       int     `json:"r,omitempty"`
+	Value       float64 `json:"v,omitempty"`
+	Gain        float64 `json:"g,omitempty"` // gain ของการแบ่งที่โหนดนี้
+}
+
+// GBDTTree - ต้นไม้หนึ่งต้นเก็บเป็นรายการโหนด
+type GBDTTree struct {
+	Nodes []GBDTNode `json:"nodes"`
+}
+
+func (t GBDTTree) predict(x []float64) float64 {
+	node := t.Nodes[0]
+	for node.Left != 0 {
+		v := x[node.Feature]
+		left := v <= node.Threshold
+		if math.IsNaN(v) {
+			left = node.MissingLeft
+		}
+		if left {
+			node = t.Nodes[node.Left]
+		} else {
+			node = t.Nodes[node.Right]
+		}
+	}
+	return node.Value
+}
+
+// GBDTModel - gradient-boosted decision trees ที่ฝึกแล้ว
+// Gain และ Splits คือผลรวมของ gain และจำนวนครั้งที่ใช้แบ่งของแต่ละ feature (ความสำคัญของ feature)
+type GBDTModel struct {
+	Config        GBDTConfig `json:"config"`
+	BaseScore     float64    `json:"base_score"`
+	Trees         []GBDTTree `json:"trees"`
+	BestIteration int        `json:"best_iteration"`
+	ValidLoss     float64    `json:"valid_loss,omitempty"`
+	Gain          []float64  `json:"gain"`
+	Splits        []int      `json:"splits"`
+}
+
+// Predict - ค่าที่ทำนาย (binary คืนความน่าจะเป็นของคลาส 1)
+func (m *GBDTModel) Predict(x []float64) float64 {
+	score := m.BaseScore
+	for _, tree := range m.Trees {
+		score += tree.predict(x)
+	}
+	if m.Config.Objective == objectiveBinary {
+		return sigmoid(score)
+	}
+	return score
+}
+
+// gbdtHistogram - ผลรวม gradient, hessian และจำนวนแถวในแต่ละช่วงของ feature หนึ่งตัว ช่องสุดท้ายคือค่าที่ขาด
+type gbdtHistogram struct {
+	Grad, Hess []float64
+	Count      []int
+}
+
+// gbdtSplit - การแบ่งที่ดีที่สุดของโหนด
+type gbdtSplit struct {
+	Feature     int
+	Bin         int // แถวที่อยู่ในช่วง <= Bin ไปทางซ้าย
+	MissingLeft bool
+	Gain        float64
+}
+
+// gbdtTrainer - สถานะระหว่างฝึก
+type gbdtTrainer struct {
+	cfg   GBDTConfig
+	model *GBDTModel
+	bins  [][]uint16  // [feature][แถว] ช่วงของค่า ช่วง len(edges[j])+1 คือค่าที่ขาด
+	edges [][]float64 // ขอบบนของแต่ละช่วง (ช่วงสุดท้ายไม่มีขอบบน)
+	grad  []float64
+	hess  []float64
+}
+
+// FitGBDT - ฝึก gradient-boosted trees จาก x (แถว × feature, NaN = ไม่มีค่า) และเป้าหมาย y
+// ถ้ามี validX จะหยุดเมื่อ loss ของ validation ไม่ดีขึ้นตาม EarlyStopping แล้วเก็บเฉพาะต้นไม้จนถึงรอบที่ดีที่สุด
+// validX ควรเป็นแถวที่อยู่หลังแถว train ตามเวลาเพื่อไม่ให้เลือกจำนวนต้นจากอนาคตที่รู้ล่วงหน้า
+func FitGBDT(x [][]float64, y []float64, validX [][]float64, validY []float64, cfg GBDTConfig) (*GBDTModel, error) {
+	if cfg.Objective != objectiveRegression && cfg.Objective != objectiveBinary {
+		return nil, errMsg("model.unknown_objective", cfg.Objective)
+	}
+	if cfg.Trees <= 0 || cfg.MaxDepth <= 0 || cfg.MinLeaf <= 0 || cfg.LearningRate <= 0 || cfg.L2 < 0 ||
+		cfg.Subsample <= 0 || cfg.Subsample > 1 || cfg.ColSample <= 0 || cfg.ColSample > 1 || cfg.Bins < 2 || cfg.Bins > math.MaxUint16 {
+		return nil, errMsg("model.invalid_gbdt")
+	}
+	if len(y) == 0 {
+		return nil, errMsg("model.no_rows")
+	}
+	if cfg.Objective == objectiveBinary && (!isBinary(y) || !isBinary(validY)) {
+		return nil, errMsg("model.binary_target")
+	}
+
+	p := len(x[0])
+	t := &gbdtTrainer{
+		cfg:   cfg,
+		model: &GBDTModel{Config: cfg},
+		grad:  make([]float64, len(y)),
+		hess:  make([]float64, len(y)),
+	}
+	t.buildBins(x)
+
+	mean := meanOf(y)
+	t.model.BaseScore = mean
+	if cfg.Objective == objectiveBinary {
+		rate := math.Min(math.Max(mean, 1e-6), 1-1e-6)
+		t.model.BaseScore = math.Log(rate / (1 - rate))
+	}
+	score := make([]float64, len(y))
+	validScore := make([]float64, len(validY))
+	for i := range score {
+		score[i] = t.model.BaseScore
+	}
+	for i := range validScore {
+		validScore[i] = t.model.BaseScore
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	bestLoss, sinceBest := math.Inf(1), 0
+	for iter := 1; iter <= cfg.Trees; iter++ {
+		t.gradients(y, score)
+		tree := t.buildTree(t.sampleRows(rng, len(y)), t.sampleFeatures(rng, p))
+		t.model.Trees = append(t.model.Trees, tree)
+		for i := range score {
+			score[i] += tree.predict(x[i])
+		}
+		if len(validY) == 0 {
+			t.model.BestIteration = iter
+			continue
+		}
+
+		for i := range validScore {
+			validScore[i] += tree.predict(validX[i])
+		}
+		loss := t.loss(validY, validScore)
+		if loss < bestLoss-1e-12 {
+			bestLoss, sinceBest = loss, 0
+			t.model.BestIteration = iter
+		} else if sinceBest++; cfg.EarlyStopping > 0 && sinceBest >= cfg.EarlyStopping {
+			break
+		}
+	}
+	if len(validY) > 0 {
+		t.model.ValidLoss = bestLoss
+		t.model.Trees = t.model.Trees[:t.model.BestIteration]
+	}
+	t.model.importance(p)
+	return t.model, nil
+}
+
+// buildBins - แบ่งค่าของแต่ละ feature เป็นช่วงตามควอนไทล์ของแถว train
+// feature ที่มีค่าต่างกันไม่เกินจำนวนช่วงใช้ค่าจริงเป็นขอบ จึงแบ่งได้ทุกตำแหน่ง
+func (t *gbdtTrainer) buildBins(x [][]float64) {
+	p := len(x[0])
+	t.bins = make([][]uint16, p)
+	t.edges = make([][]float64, p)
+	for j := 0; j < p; j++ {
+		var values []float64
+		for _, row := range x {
+			if !math.IsNaN(row[j]) {
+				values = append(values, row[j])
+			}
+		}
+		sort.Float64s(values)
+		unique := values[:0:0]
+		for i, v := range values {
+			if i == 0 || v != values[i-1] {
+				unique = append(unique, v)
+			}
+		}
+
+		var edges []float64
+		if len(unique) <= t.cfg.Bins {
+			if len(unique) > 1 {
+				edges = unique[:len(unique)-1]
+			}
+		} else {
+			for b := 1; b < t.cfg.Bins; b++ {
+				edge := quantile(values, float64(b)/float64(t.cfg.Bins))
+				if len(edges) == 0 || edge > edges[len(edges)-1] {
+					edges = append(edges, edge)
+				}
+			}
+		}
+		t.edges[j] = edges
+
+		missing := uint16(len(edges) + 1)
+		t.bins[j] = make([]uint16, len(x))
+		for i, row := range x {
+			if math.IsNaN(row[j]) {
+				t.bins[j][i] = missing
+			} else {
+				t.bins[j][i] = uint16(sort.SearchFloat64s(edges, row[j]))
+			}
+		}
+	}
+}
+
+// gradients - อนุพันธ์อันดับหนึ่งและสองของ loss เทียบกับคะแนนปัจจุบัน
+func (t *gbdtTrainer) gradients(y, score []float64) {
+	for i := range y {
+		if t.cfg.Objective == objectiveBinary {
+			p := sigmoid(score[i])
+			t.grad[i] = p - y[i]
+			t.hess[i] = math.Max(p*(1-p), 1e-12)
+		} else {
+			t.grad[i] = score[i] - y[i]
+			t.hess[i] = 1
+		}
+	}
+}
+
+// loss - rmse สำหรับ regression และ logloss สำหรับ binary
+func (t *gbdtTrainer) loss(y, score []float64) float64 {
+	if t.cfg.Objective == objectiveBinary {
+		prob := make([]float64, len(score))
+		for i, s := range score {
+			prob[i] = sigmoid(s)
+		}
+		return logLoss(y, prob)
+	}
+	return rmse(y, score)
+}
+
+// sampleRows - สุ่มแถวตามสัดส่วน Subsample โดยไม่ซ้ำ เรียงตามลำดับเดิม
+func (t *gbdtTrainer) sampleRows(rng *rand.Rand, n int) []int {
+	k := max(1, int(math.Round(float64(n)*t.cfg.Subsample)))
+	rows := rng.Perm(n)[:k]
+	if k == n {
+		for i := range rows {
+			rows[i] = i
+		}
+	}
+	sort.Ints(rows)
+	return rows
+}
+
+// sampleFeatures - สุ่ม feature ตามสัดส่วน ColSample โดยไม่ซ้ำ
+func (t *gbdtTrainer) sampleFeatures(rng *rand.Rand, p int) []int {
+	k := max(1, int(math.Round(float64(p)*t.cfg.ColSample)))
+	features := rng.Perm(p)[:k]
+	sort.Ints(features)
+	return features
+}
+
+// buildTree - สร้างต้นไม้หนึ่งต้นแบบลงลึกทีละโหนด
+func (t *gbdtTrainer) buildTree(rows, features []int) GBDTTree {
+	var tree GBDTTree
+	t.grow(&tree, rows, features, 0)
+	return tree
+}
+
+// grow - สร้างโหนดของแถว rows แล้วคืนตำแหน่งของโหนด
+func (t *gbdtTrainer) grow(tree *GBDTTree, rows, features []int, depth int) int {
+	index := len(tree.Nodes)
+	tree.Nodes = append(tree.Nodes, GBDTNode{})
+	var g, h float64
+	for _, r := range rows {
+		g += t.grad[r]
+		h += t.hess[r]
+	}
+	leaf := GBDTNode{Value: -g / (h + t.cfg.L2) * t.cfg.LearningRate}
+
+	if depth >= t.cfg.MaxDepth || len(rows) < 2*t.cfg.MinLeaf {
+		tree.Nodes[index] = leaf
+		return index
+	}
+	split, ok := t.bestSplit(rows, features, g, h)
+	if !ok {
+		tree.Nodes[index] = leaf
+		return index
+	}
+
+	missing := uint16(len(t.edges[split.Feature]) + 1)
+	var left, right []int
+	for _, r := range rows {
+		bin := t.bins[split.Feature][r]
+		if (bin == missing && split.MissingLeft) || (bin != missing && int(bin) <= split.Bin) {
+			left = append(left, r)
+		} else {
+			right = append(right, r)
+		}
+	}
+
+	node := GBDTNode{Feature: split.Feature, Threshold: math.MaxFloat64, MissingLeft: split.MissingLeft, Gain: split.Gain}
+	if split.Bin < len(t.edges[split.Feature]) {
+		node.Threshold = t.edges[split.Feature][split.Bin]
+	}
+	node.Left = t.grow(tree, left, features, depth+1)
+	node.Right = t.grow(tree, right, features, depth+1)
+	tree.Nodes[index] = node
+	return index
+}
+
+// bestSplit - การแบ่งที่ให้ gain มากที่สุด gain = G_L²/(H_L+λ) + G_R²/(H_R+λ) - G²/(H+λ)
+// ค่าที่ขาดลองทั้งสองทาง ถ้าโหนดนี้ไม่มีค่าที่ขาดจะส่งไปทางที่มีแถวมากกว่า
+func (t *gbdtTrainer) bestSplit(rows, features []int, g, h float64) (gbdtSplit, bool) {
+	best := gbdtSplit{Gain: 1e-12}
+	found := false
+	parent := g * g / (h + t.cfg.L2)
+	score := func(g, h float64) float64 { return g * g / (h + t.cfg.L2) }
+
+	for _, j := range features {
+		hist := t.histogram(rows, j)
+		missing := len(hist.Count) - 1
+		gM, hM, cM := hist.Grad[missing], hist.Hess[missing], hist.Count[missing]
+
+		var gL, hL float64
+		var cL int
+		// bin สุดท้ายที่ไม่ใช่ค่าที่ขาดแบ่งได้เฉพาะเมื่อมีค่าที่ขาด (แยกค่าที่ขาดออกไปทางขวา)
+		for b := 0; b < missing; b++ {
+			gL += hist.Grad[b]
+			hL += hist.Hess[b]
+			cL += hist.Count[b]
+			gR, hR, cR := g-gM-gL, h-hM-hL, len(rows)-cM-cL
+
+			for _, missingLeft := range []bool{false, true} {
+				if missingLeft && cM == 0 {
+					continue
+				}
+				lg, lh, lc, rg, rh, rc := gL, hL, cL, gR+gM, hR+hM, cR+cM
+				if missingLeft {
+					lg, lh, lc, rg, rh, rc = gL+gM, hL+hM, cL+cM, gR, hR, cR
+				}
+				if lc < t.cfg.MinLeaf || rc < t.cfg.MinLeaf {
+					continue
+				}
+				if gain := score(lg, lh) + score(rg, rh) - parent; gain > best.Gain {
+					best = gbdtSplit{Feature: j, Bin: b, MissingLeft: missingLeft, Gain: gain}
+					if cM == 0 {
+						best.MissingLeft = lc >= rc
+					}
+					found = true
+				}
+			}
+		}
+	}
+	return best, found
+}
+
+// histogram - ผลรวมของ gradient และ hessian ในแต่ละช่วงของ feature j
+func (t *gbdtTrainer) histogram(rows []int, j int) gbdtHistogram {
+	n := len(t.edges[j]) + 2
+	hist := gbdtHistogram{Grad: make([]float64, n), Hess: make([]float64, n), Count: make([]int, n)}
+	bins := t.bins[j]
+	for _, r := range rows {
+		b := bins[r]
+		hist.Grad[b] += t.grad[r]
+		hist.Hess[b] += t.hess[r]
+		hist.Count[b]++
+	}
+	return hist
+}
+
+// importance - ผลรวม gain และจำนวนครั้งที่ใช้แบ่งของแต่ละ feature จากต้นไม้ที่เก็บไว้
+func (m *GBDTModel) importance(p int) {
+	m.Gain = make([]float64, p)
+	m.Splits = make([]int, p)
+	for _, tree := range m.Trees {
+		for _, node := range tree.Nodes {
+			if node.Left != 0 {
+				m.Gain[node.Feature] += node.Gain
+				m.Splits[node.Feature]++
+			}
+		}
+	}
+}
+
+// ExportImportance - เขียนความสำคัญของ feature เรียงตาม gain จากมากไปน้อย
+func ExportImportance(m *GBDTModel, features []string, filename string) error {
+	columns := []string{"Feature", "Gain", "GainShare", "Splits"}
+	var total float64
+	for _, g := range m.Gain {
+		total += g
+	}
+	records := make([][]string, 0, len(features))
+	for _, j := range importanceOrder(m) {
+		share := 0.0
+		if total > 0 {
+			share = m.Gain[j] / total
+		}
+		records = append(records, []string{
+			features[j], formatModelValue(m.Gain[j]), strconv.FormatFloat(share, 'f', 4, 64), strconv.Itoa(m.Splits[j]),
+		})
+	}
+	return writeReportCSV(filename, columns, records)
+}
+
+func importanceOrder(m *GBDTModel) []int {
+	order := make([]int, len(m.Gain))
+	for j := range order {
+		order[j] = j
+	}
+	sort.SliceStable(order, func(a, b int) bool { return m.Gain[order[a]] > m.Gain[order[b]] })
+	return order
+}
+
+// logImportance - แสดงจำนวนต้นไม้และ feature ที่สำคัญที่สุดไม่เกิน 10 ตัว
+func logImportance(m *GBDTModel, features []string) {
+	logMsg("model.trees", len(m.Trees), m.Config.Trees)
+	var total float64
+	for _, g := range m.Gain {
+		total += g
+	}
+	for i, j := range importanceOrder(m) {
+		if i == 10 || m.Gain[j] == 0 {
+			break
+		}
+		logMsg("model.importance", features[j], m.Gain[j]*100/total, m.Splits[j])
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGBDTTreeRoutesMissingValues(t *testing.T) {
+	tree := func(missingLeft bool) GBDTTree {
+		return GBDTTree{Nodes: []GBDTNode{
+			{Feature: 0, Threshold: 5, MissingLeft: missingLeft, Left: 1, Right: 2},
+			{Value: -1},
+			{Value: 1},
+		}}
+	}
+	tests := []struct {
+		name        string
+		missingLeft bool
+		x           float64
+		want        float64
+	}{
+		{"below threshold", false, 3, -1},
+		{"at threshold goes left", false, 5, -1},
+		{"above threshold", false, 7, 1},
+		{"missing goes right", false, nan, 1},
+		{"missing goes left", true, nan, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tree(tt.missingLeft).predict([]float64{tt.x}); got != tt.want {
+				t.Errorf("predict(%v) = %v; want %v", tt.x, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFitGBDTLearnsMissingDirection(t *testing.T) {
+	// stump หนึ่งต้น learning rate 1 และไม่มี L2 ค่าในใบจึงเป็นค่าเฉลี่ยของ y ในใบพอดี
+	cfg := GBDTConfig{Objective: objectiveRegression, Trees: 1, LearningRate: 1, MaxDepth: 1, MinLeaf: 1, Subsample: 1, ColSample: 1, Bins: 10}
+	tests := []struct {
+		name  string
+		x     []float64
+		y     []float64
+		probe []float64
+		want  []float64
+	}{
+		// แยกค่าที่ขาดออกไปใบขวา (gain 100) ดีกว่าแบ่งระหว่าง 1 กับ 2
+		{"missing split off on its own", []float64{1, 2, nan, nan}, []float64{0, 0, 10, 10}, []float64{nan, 1.5, 100}, []float64{10, 0, 0}},
+		// แบ่งที่ 1 แล้วรวมค่าที่ขาดไว้ทางซ้าย (gain 75) ดีกว่าไว้ทางขวา (gain 8.33)
+		{"missing joins the left leaf", []float64{1, 2, nan, nan}, []float64{0, 10, 0, 0}, []float64{nan, 1, 2}, []float64{0, 0, 10}},
+		// ไม่มีค่าที่ขาดตอนฝึก ส่งไปทางที่มีแถวมากกว่า (ขวา 3 แถว)
+		{"unseen missing follows the larger side", []float64{1, 2, 3, 4, 5}, []float64{0, 0, 10, 10, 10}, []float64{nan, 2, 3}, []float64{10, 0, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := make([][]float64, len(tt.x))
+			for i, v := range tt.x {
+				x[i] = []float64{v}
+			}
+			m, err := FitGBDT(x, tt.y, nil, nil, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range tt.probe {
+				if got := m.Predict([]float64{v}); math.Abs(got-tt.want[i]) > 1e-9 {
+					t.Errorf("Predict(%v) = %v; want %v", v, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
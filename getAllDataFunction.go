@@ -21,49 +21,73 @@ import (
 // สร้าง global rate limiter เพื่อป้องกันการส่ง request มากเกินไป
 var limiter = rate.NewLimiter(rate.Every(50*time.Millisecond), 10) // 20 req/sec
 
-func getAllFinancialDataCombined() ([]FinancialData, error) {
-	// คำนวณวันที่ปัจจุบันและวันที่ย้อนหลัง 5 ปี
-	now := time.Now()
-	fiveYearsAgo := now.AddDate(-5, 0, 0)
+// FetchError - ข้อผิดพลาดที่เกิดขึ้นระหว่างดึงข้อมูลของหุ้นแต่ละตัว
+type FetchError struct {
+	Symbol  string `json:"symbol"`
+	Stage   string `json:"stage"` // "financial" หรือ "price"
+	Message string `json:"message"`
+}
 
-	currentYear := now.Year()
-	currentMonth := int(now.Month())
-	currentQuarter := ((currentMonth - 1) / 3) + 1
+// getAllFinancialDataCombined - ดึงข้อมูลทั้งหมดเก็บไว้ในหน่วยความจำ แล้วเรียงตามหุ้นและไตรมาส
+func getAllFinancialDataCombined() ([]FinancialData, []FetchError, error) {
+	var mutex sync.Mutex
+	var combinedData []FinancialData
+
+	symbolCount, fetchErrors, err := fetchAllFinancialData(func(records []FinancialData) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		combinedData = append(combinedData, records...)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-	startYear := fiveYearsAgo.Year()
-	startMonth := int(fiveYearsAgo.Month())
-	startQuarter := ((startMonth - 1) / 3) + 1
+	// เรียงลำดับข้อมูลตามชื่อหุ้น และไตรมาสปฏิทิน (ล่าสุดก่อน)
+	sortFinancialData(combinedData)
+
+	logMsg("fetch.summary", len(combinedData), symbolCount)
+
+	return combinedData, fetchErrors, nil
+}
+
+// fetchAllFinancialData - ดึงงบการเงินและราคาของหุ้นทุกตัวแบบขนาน
+// onSymbol ถูกเรียกทันทีที่หุ้นแต่ละตัวดึงเสร็จ (อาจถูกเรียกพร้อมกันจากหลาย goroutine)
+// ถ้า onSymbol คืน error จะหยุดการดึงข้อมูลทั้งหมดและคืน error นั้น
+func fetchAllFinancialData(onSymbol func(records []FinancialData) error) (int, []FetchError, error) {
+	now := time.Now()
+	start, end := fetchWindow(now)
 
 	currentDateStr := now.Format("2006-01-02")
 
 	// 1. ดึงรายชื่อหุ้นทั้งหมด
 	symbols, err := getAllSymbols(currentDateStr)
 	if err != nil {
-		return nil, fmt.Errorf("ไม่สามารถดึงรายชื่อหุ้นได้: %v", err)
+		return 0, nil, errMsg("fetch.symbols_failed", err)
 	}
 
-	fmt.Printf("พบหุ้นทั้งหมด %d ตัว\n", len(symbols))
-
-	// 2. สร้าง channel สำหรับรับข้อมูลจาก goroutines
-	resultsChan := make(chan []FinancialData, len(symbols))
+	logMsg("fetch.symbols_found", len(symbols))
 
-	// 3. สร้าง context พร้อม timeout
+	// 2. สร้าง context พร้อม timeout
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(30*len(symbols))*time.Second)
 	defer cancel()
 
-	// 4. ใช้ conc pool สำหรับการทำงานแบบขนาน
-	p := pool.New().WithContext(ctx).WithMaxGoroutines(20) // ปรับจำนวน goroutines ให้เหมาะสม
+	// 3. ใช้ conc pool สำหรับการทำงานแบบขนาน
+	// ยกเลิกงานที่เหลือเมื่อเขียนผลลัพธ์ไม่สำเร็จ เพราะทำต่อไปก็เก็บข้อมูลไม่ได้
+	p := pool.New().WithContext(ctx).WithCancelOnError().WithMaxGoroutines(20) // ปรับจำนวน goroutines ให้เหมาะสม
 
-	// 5. สร้าง ErrorCollector สำหรับเก็บข้อผิดพลาด
+	// 4. สร้าง ErrorCollector สำหรับเก็บข้อผิดพลาด
 	var errorCollector sync.Map
+	var sinkErr error
+	var sinkErrOnce sync.Once
 
-	// 6. วนลูปดึงข้อมูลแต่ละบริษัท
+	// 5. วนลูปดึงข้อมูลแต่ละบริษัท
 	for i, symbol := range symbols {
 		symbol := symbol // ป้องกัน closure capturing loop variable
 		idx := i
 
 		p.Go(func(ctx context.Context) error {
-			fmt.Printf("กำลังดึงข้อมูลของ %s (%d/%d)\n", symbol, idx+1, len(symbols))
+			logMsg("fetch.start_symbol", symbol, idx+1, len(symbols))
 
 			// สร้าง HTTP client ที่สามารถยกเลิกได้ด้วย context
 			client := &http.Client{
@@ -71,9 +95,9 @@ func getAllFinancialDataCombined() ([]FinancialData, error) {
 			}
 
 			// ดึงข้อมูลงบการเงิน
-			financialData, err := fetchFinancialData(ctx, client, symbol, startYear, startQuarter, currentYear, currentQuarter)
+			financialData, err := fetchFinancialData(ctx, client, symbol, start.Year, start.Quarter, end.Year, end.Quarter)
 			if err != nil {
-				errorCollector.Store(symbol, fmt.Sprintf("ข้อมูลงบการเงิน: %v", err))
+				errorCollector.Store(symbol, FetchError{Symbol: symbol, Stage: "financial", Message: err.Error()})
 				return nil // ไม่ต้องการให้หยุดทั้งหมดเมื่อบริษัทเดียวล้มเหลว
 			}
 
@@ -82,102 +106,99 @@ func getAllFinancialDataCombined() ([]FinancialData, error) {
 				err = fetchPriceData(ctx, client, financialData)
 				if err != nil {
 					// บันทึกข้อผิดพลาดแต่ยังคงส่งข้อมูลงบการเงินที่มีอยู่
-					errorCollector.Store(symbol+"-price", fmt.Sprintf("ข้อมูลราคา: %v", err))
+					errorCollector.Store(symbol+"-price", FetchError{Symbol: symbol, Stage: "price", Message: err.Error()})
 				}
 			}
 
-			// ส่งข้อมูลกลับเข้า channel (เฉพาะเมื่อมีข้อมูล)
+			// ตัวชี้วัดที่คำนวณเพิ่มใช้เฉพาะข้อมูลของหุ้นตัวเดียว จึงคำนวณได้ทันทีก่อนส่งต่อ
+			computeDerivedMetrics(financialData)
+
+			// ส่งข้อมูลต่อทันที (เฉพาะเมื่อมีข้อมูล)
 			if len(financialData) > 0 {
-				select {
-				case resultsChan <- financialData:
-					// ส่งข้อมูลเรียบร้อย
-				case <-ctx.Done():
+				if ctx.Err() != nil {
 					// ถูกยกเลิกหรือ timeout
 					return ctx.Err()
 				}
+				if err := onSymbol(financialData); err != nil {
+					sinkErrOnce.Do(func() { sinkErr = err })
+					return err
+				}
 			}
 
 			return nil
 		})
 
-		fmt.Printf("เสร็จงาน %s (%d/%d)\n", symbol, idx+1, len(symbols))
+		logMsg("fetch.done_symbol", symbol, idx+1, len(symbols))
 	}
 
-	// 7. รอให้งานทั้งหมดเสร็จสิ้น
+	// 6. รอให้งานทั้งหมดเสร็จสิ้น
 	if err := p.Wait(); err != nil {
-		fmt.Printf("เกิดข้อผิดพลาดในการดึงข้อมูล: %v\n", err)
+		logMsg("fetch.failed", err)
 		// ทำต่อแม้จะมี error บางส่วน
 	}
 
-	// 8. ปิด channel resultsChan
-	close(resultsChan)
-
-	// 9. รวบรวมข้อมูลทั้งหมดจาก channel
-	var combinedData []FinancialData
-	for data := range resultsChan {
-		combinedData = append(combinedData, data...)
-	}
+	// 7. บันทึกข้อผิดพลาดที่เกิดขึ้นระหว่างการทำงาน
+	var fetchErrors []FetchError
+	errorCollector.Range(func(key, value interface{}) bool {
+		fetchErrors = append(fetchErrors, value.(FetchError))
+		return true
+	})
+	sort.Slice(fetchErrors, func(i, j int) bool {
+		if fetchErrors[i].Symbol != fetchErrors[j].Symbol {
+			return fetchErrors[i].Symbol < fetchErrors[j].Symbol
+		}
+		return fetchErrors[i].Stage < fetchErrors[j].Stage
+	})
 
-	// 10. บันทึกข้อผิดพลาดที่เกิดขึ้นระหว่างการทำงาน
 	errorsLogFile := "fetch_errors.log"
-	var errorsFound int
 	errorLog, err := os.Create(errorsLogFile)
 	if err == nil {
 		defer errorLog.Close()
-		fmt.Fprintf(errorLog, "--- ข้อผิดพลาดในการดึงข้อมูลวันที่ %s ---\n", time.Now().Format("2006-01-02 15:04:05"))
-		errorCollector.Range(func(key, value interface{}) bool {
-			fmt.Fprintf(errorLog, "%v: %v\n", key, value)
-			errorsFound++
-			return true
-		})
-		if errorsFound > 0 {
-			fmt.Printf("พบข้อผิดพลาด %d รายการ บันทึกไว้ที่ %s\n", errorsFound, errorsLogFile)
+		fmt.Fprint(errorLog, T("fetch.errors_log_header", time.Now().Format("2006-01-02 15:04:05")))
+		for _, fe := range fetchErrors {
+			fmt.Fprintf(errorLog, "%s: %s\n", fe.key(), fe.describe())
+		}
+		if len(fetchErrors) > 0 {
+			logMsg("fetch.errors_logged", len(fetchErrors), errorsLogFile)
 		}
 	}
 
-	// 11. เรียงลำดับข้อมูลตามชื่อหุ้น ปี และไตรมาส (ล่าสุดก่อน)
-	if len(combinedData) > 0 {
-		sort.Slice(combinedData, func(i, j int) bool {
-			// เรียงตามชื่อหุ้น (A-Z)
-			if combinedData[i].Symbol != combinedData[j].Symbol {
-				return combinedData[i].Symbol < combinedData[j].Symbol
-			}
-
-			// แปลงปีเป็นตัวเลข
-			yearI, _ := strconv.Atoi(combinedData[i].Year)
-			yearJ, _ := strconv.Atoi(combinedData[j].Year)
-
-			// เรียงตามปี (ล่าสุดก่อน)
-			if yearI != yearJ {
-				return yearI > yearJ
-			}
+	return len(symbols), fetchErrors, sinkErr
+}
 
-			// แปลงไตรมาสเป็นตัวเลข
-			quarterI, _ := strconv.Atoi(combinedData[i].Quarter)
-			quarterJ, _ := strconv.Atoi(combinedData[j].Quarter)
+// fetchWindow - ช่วงไตรมาสที่ขอจาก API: ย้อนหลัง 5 ปีจนถึงไตรมาสปัจจุบัน
+func fetchWindow(now time.Time) (start, end Period) {
+	return periodOfDate(now.AddDate(-5, 0, 0)), periodOfDate(now)
+}
 
-			// เรียงตามไตรมาส (ล่าสุดก่อน)
-			return quarterI > quarterJ
-		})
+// key - คีย์เดิมที่ใช้ในไฟล์ fetch_errors.log
+func (fe FetchError) key() string {
+	if fe.Stage == "price" {
+		return fe.Symbol + "-price"
 	}
+	return fe.Symbol
+}
 
-	fmt.Printf("ดึงข้อมูลสำเร็จ: %d รายการ จาก %d บริษัท\n", len(combinedData), len(symbols))
-
-	return combinedData, nil
+// describe - ข้อความอธิบายข้อผิดพลาดพร้อมประเภทข้อมูล
+func (fe FetchError) describe() string {
+	if fe.Stage == "price" {
+		return T("fetch.error_price", fe.Message)
+	}
+	return T("fetch.error_financial", fe.Message)
 }
 
 // แยกการดึงข้อมูลงบการเงินเป็นฟังก์ชันแยก
 func fetchFinancialData(ctx context.Context, client *http.Client, symbol string, startYear, startQuarter, endYear, endQuarter int) ([]FinancialData, error) {
 	// จำกัดอัตราการเรียก API
 	if err := limiter.Wait(ctx); err != nil {
-		return nil, fmt.Errorf("rate limit error: %v", err)
+		return nil, errMsg("http.rate_limit", err)
 	}
 
 	url := "https://www.setsmart.com/api/listed-company-api/financial-data-and-ratio-by-symbol"
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("สร้างคำขอไม่สำเร็จ: %v", err)
+		return nil, errMsg("http.create_request", err)
 	}
 
 	// เพิ่ม headers
@@ -195,30 +216,49 @@ func fetchFinancialData(ctx context.Context, client *http.Client, symbol string,
 	// ส่งคำขอ
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("ส่งคำขอไม่สำเร็จ: %v", err)
+		return nil, errMsg("http.send_request", err)
 	}
 	defer resp.Body.Close()
 
 	// อ่านข้อมูลที่ได้รับ
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("อ่านข้อมูลไม่สำเร็จ: %v", err)
+		return nil, errMsg("http.read_body", err)
 	}
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API ตอบสถานะ: %d", resp.StatusCode)
+		return nil, errMsg("http.status", resp.StatusCode)
 	}
 
 	// แปลง JSON เป็นโครงสร้างข้อมูล
 	var data []FinancialData
 	err = json.Unmarshal(body, &data)
 	if err != nil {
-		return nil, fmt.Errorf("แปลงข้อมูล JSON ไม่สำเร็จ: %v", err)
+		return nil, errMsg("http.decode_json", err)
 	}
 
+	// บันทึกที่มาของข้อมูลทุกรายการใน response นี้
+	lineage := newLineage(url, q, body, time.Now())
+	for i := range data {
+		data[i].Lineage = lineage
+	}
+
+	// แปลงงวดปีบัญชีเป็นไตรมาสปฏิทิน (บริษัทที่ไม่ได้ปิดงบเดือนธันวาคม)
+	alignFiscalPeriods(data)
+
 	return data, nil
 }
 
+// sortFinancialData - เรียงข้อมูลตามชื่อหุ้น (A-Z) แล้วตามไตรมาสปฏิทิน (ล่าสุดก่อน)
+func sortFinancialData(data []FinancialData) {
+	sort.SliceStable(data, func(i, j int) bool {
+		if data[i].Symbol != data[j].Symbol {
+			return data[i].Symbol < data[j].Symbol
+		}
+		return calendarPeriodOf(data[j]).Before(calendarPeriodOf(data[i]))
+	})
+}
+
 // แยกการดึงข้อมูลราคาเป็นฟังก์ชันแยก
 func fetchPriceData(ctx context.Context, client *http.Client, financialData []FinancialData) error {
 	// สร้าง wait group เพื่อรอให้การดึงข้อมูลราคาทั้งหมดเสร็จสิ้น
@@ -248,24 +288,18 @@ func fetchPriceData(ctx context.Context, client *http.Client, financialData []Fi
 			quarter := financialData[idx].Quarter
 			symbol := financialData[idx].Symbol
 
-			// หาวันที่สิ้นสุดไตรมาส (ประมาณการ)
-			var quarterEndMonth int
-			switch quarter {
-			case "1":
-				quarterEndMonth = 3
-			case "2":
-				quarterEndMonth = 6
-			case "3":
-				quarterEndMonth = 9
-			default: // quarter == "4"
-				quarterEndMonth = 12
+			// หาวันที่สิ้นสุดไตรมาสตามปฏิทิน (ประมาณการ)
+			// ใช้ไตรมาสปฏิทินแทนไตรมาสปีบัญชี เพื่อให้ราคาตรงกับวันสิ้นงวดจริงของบริษัทที่ไม่ได้ปิดงบเดือนธันวาคม
+			calendar := calendarPeriodOf(financialData[idx])
+			if calendar.IsZero() {
+				logMsg("price.no_period", symbol, quarter, displayYearString(quarterYear))
+				return nil
 			}
-
-			quarterEndDate := fmt.Sprintf("%s-%02d-28", quarterYear, quarterEndMonth)
+			quarterEndDate := fmt.Sprintf("%04d-%02d-28", calendar.Year, calendar.EndMonth())
 
 			// จำกัดอัตราการเรียก API
 			if err := limiter.Wait(subCtx); err != nil {
-				fmt.Printf("%s Q%s/%s: rate limit error: %v", symbol, quarter, quarterYear, err)
+				logMsg("price.quarter_error", symbol, quarter, displayYearString(quarterYear), errMsg("http.rate_limit", err))
 				return err
 			}
 
@@ -274,7 +308,7 @@ func fetchPriceData(ctx context.Context, client *http.Client, financialData []Fi
 
 			priceReq, err := http.NewRequestWithContext(subCtx, "GET", priceUrl, nil)
 			if err != nil {
-				fmt.Printf("%s Q%s/%s: สร้างคำขอราคาไม่สำเร็จ: %v", symbol, quarter, quarterYear, err)
+				logMsg("price.quarter_error", symbol, quarter, displayYearString(quarterYear), errMsg("http.create_request", err))
 				return err
 			}
 
@@ -291,7 +325,7 @@ func fetchPriceData(ctx context.Context, client *http.Client, financialData []Fi
 			// ส่งคำขอ
 			priceResp, err := client.Do(priceReq)
 			if err != nil {
-				fmt.Printf("%s Q%s/%s: ส่งคำขอราคาไม่สำเร็จ: %v", symbol, quarter, quarterYear, err)
+				logMsg("price.quarter_error", symbol, quarter, displayYearString(quarterYear), errMsg("http.send_request", err))
 				return err
 			}
 
@@ -299,12 +333,13 @@ func fetchPriceData(ctx context.Context, client *http.Client, financialData []Fi
 			priceBody, err := io.ReadAll(priceResp.Body)
 			priceResp.Body.Close()
 			if err != nil {
-				fmt.Printf("%s Q%s/%s: อ่านข้อมูลราคาไม่สำเร็จ: %v", symbol, quarter, quarterYear, err)
+				logMsg("price.quarter_error", symbol, quarter, displayYearString(quarterYear), errMsg("http.read_body", err))
 				return err
 			}
 
 			if priceResp.StatusCode != 200 {
-				fmt.Printf("%s Q%s/%s: API ราคาตอบสถานะ: %d", symbol, quarter, quarterYear, priceResp.StatusCode)
+				err := errMsg("http.status", priceResp.StatusCode)
+				logMsg("price.quarter_error", symbol, quarter, displayYearString(quarterYear), err)
 				return err
 			}
 
@@ -312,29 +347,25 @@ func fetchPriceData(ctx context.Context, client *http.Client, financialData []Fi
 			var priceData []PriceData
 			err = json.Unmarshal(priceBody, &priceData)
 			if err != nil {
-				fmt.Printf("%s Q%s/%s: แปลงข้อมูล JSON ของราคาไม่สำเร็จ: %v", symbol, quarter, quarterYear, err)
+				logMsg("price.quarter_error", symbol, quarter, displayYearString(quarterYear), errMsg("http.decode_json", err))
 				return err
 			}
 
+			priceLineage := newLineage(priceUrl, pq, priceBody, time.Now())
+			priceDate := ""
 			if len(priceData) > 0 {
-				// ล็อคเพื่อป้องกันการเขียนข้อมูลพร้อมกัน
-				mutex.Lock()
-
-				// แปลงข้อมูลราคาเป็น map[string]interface{} เพื่อเก็บใน PriceData
-				financialData[idx].PriceData = make(map[string]interface{})
+				priceDate = priceData[0].Date
+			}
 
-				// สร้าง map จาก PriceData
-				latestPrice := priceData[0]
-				priceJSON, _ := json.Marshal(latestPrice)
-				var priceMap map[string]interface{}
-				json.Unmarshal(priceJSON, &priceMap)
+			// ล็อคเพื่อป้องกันการเขียนข้อมูลพร้อมกัน
+			mutex.Lock()
+			defer mutex.Unlock()
 
-				// เพิ่มข้อมูลราคาลงใน PriceData
-				for key, value := range priceMap {
-					financialData[idx].PriceData["price_"+key] = value
-				}
+			financialData[idx].Lineage = financialData[idx].Lineage.withPrice(priceLineage, quarterEndDate, priceDate, calendar.EndDate())
 
-				mutex.Unlock()
+			if len(priceData) > 0 {
+				// แปลงข้อมูลราคาเป็น map[string]interface{} เพื่อเก็บใน PriceData
+				financialData[idx].PriceData = priceDataMap(priceData[0])
 			}
 			return nil
 		})
@@ -344,8 +375,24 @@ func fetchPriceData(ctx context.Context, client *http.Client, financialData []Fi
 	err := wg.Wait()
 
 	if err != nil {
-		return fmt.Errorf("พบข้อผิดพลาดในการดึงข้อมูลราคา %v", err)
+		return errMsg("price.failed", err)
 	}
 
 	return nil
 }
+
+// priceDataMap - แปลงราคาเป็น map สำหรับ FinancialData.PriceData (key = "price_" + ชื่อ field ของ API)
+// ค่าที่ API ส่งเป็น null ไม่ใส่ลงใน map จึงส่งออกเป็นค่าว่าง/null แทนศูนย์
+func priceDataMap(price PriceData) map[string]interface{} {
+	priceJSON, _ := json.Marshal(price)
+	var priceMap map[string]interface{}
+	json.Unmarshal(priceJSON, &priceMap)
+
+	prices := make(map[string]interface{}, len(priceMap))
+	for key, value := range priceMap {
+		if value != nil {
+			prices["price_"+key] = value
+		}
+	}
+	return prices
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// cagrYears - จำนวนปีของอัตราการเติบโตเฉลี่ยต่อปี (CAGR)
+const cagrYears = 3
+
+// growthSeries - ตัวเลขที่ใช้คำนวณการเติบโต
+// Flow = ยอดของช่วงเวลา (รายได้, กำไร) รวมเป็น TTM ได้, ไม่ใช่ Flow = ยอดคงเหลือ ณ สิ้นงวด (ส่วนของผู้ถือหุ้น)
+type growthSeries struct {
+	Name  string
+	Flow  bool
+	Value func(item FinancialData, history []FinancialData) (float64, bool)
+}
+
+// growthSeriesList - ชุดตัวเลขที่คำนวณการเติบโต ทุกค่าเป็นยอดรายไตรมาส (หรือยอดคงเหลือ) ของไตรมาสปฏิทิน
+var growthSeriesList = []growthSeries{
+	{Name: "revenue", Flow: true, Value: quarterField(func(d FinancialData) float64 { return d.TotalRevenueQuarter })},
+	{Name: "ebit", Flow: true, Value: quarterField(func(d FinancialData) float64 { return d.EbitQuarter })},
+	{Name: "netProfit", Flow: true, Value: quarterField(func(d FinancialData) float64 { return d.NetProfitQuarter })},
+	{Name: "eps", Flow: true, Value: quarterField(func(d FinancialData) float64 { return d.EpsQuarter })},
+	{Name: "equity", Flow: false, Value: quarterField(func(d FinancialData) float64 { return d.ShareholderEquity })},
+	{Name: "operatingCashFlow", Flow: true, Value: quarterlyFromAccum(func(d FinancialData) float64 { return d.OperatingCashFlow })},
+}
+
+// growthMetrics - ตัวชี้วัดการเติบโตของทุกชุดตัวเลข
+//
+//	<ชื่อ>QoQ       เทียบไตรมาสก่อนหน้า
+//	<ชื่อ>YoY       เทียบไตรมาสเดียวกันของปีก่อน
+//	<ชื่อ>TTMYoY    ยอด 4 ไตรมาสล่าสุดเทียบกับ 4 ไตรมาสก่อนหน้านั้น (เฉพาะ Flow)
+//	<ชื่อ>CAGR3Y    อัตราเติบโตเฉลี่ยต่อปีย้อนหลัง cagrYears ปี (Flow ใช้ยอด TTM)
+//
+// ไตรมาสที่ใช้เทียบต้องมีอยู่จริงตามไตรมาสปฏิทิน (ไม่ข้ามไตรมาสที่ขาด) บริษัทที่ปีบัญชีไม่ตรงปีปฏิทิน
+// จึงเทียบกับไตรมาสปฏิทินเดียวกันเสมอ
+func growthMetrics() []derivedMetric {
+	var metrics []derivedMetric
+	for _, s := range growthSeriesList {
+		s := s
+		metrics = append(metrics,
+			derivedMetric{Name: s.Name + "QoQ", Compute: func(item FinancialData, history []FinancialData) (float64, bool) {
+				return growthBetween(s.at(item, history, 0), s.at(item, history, 1))
+			}},
+			derivedMetric{Name: s.Name + "YoY", Compute: func(item FinancialData, history []FinancialData) (float64, bool) {
+				return growthBetween(s.at(item, history, 0), s.at(item, history, 4))
+			}},
+		)
+		if s.Flow {
+			metrics = append(metrics, derivedMetric{Name: s.Name + "TTMYoY", Compute: func(item FinancialData, history []FinancialData) (float64, bool) {
+				return growthBetween(s.ttm(item, history, 0), s.ttm(item, history, 4))
+			}})
+		}
+		metrics = append(metrics, derivedMetric{Name: fmt.Sprintf("%sCAGR%dY", s.Name, cagrYears), Compute: func(item FinancialData, history []FinancialData) (float64, bool) {
+			if s.Flow {
+				return cagr(s.ttm(item, history, 0), s.ttm(item, history, 4*cagrYears), cagrYears)
+			}
+			return cagr(s.at(item, history, 0), s.at(item, history, 4*cagrYears), cagrYears)
+		}})
+	}
+	return metrics
+}
+
+// optionalValue - ค่าที่อาจไม่มี ใช้ส่งผลลัพธ์ของ at/ttm ต่อให้ growthBetween และ cagr
+type optionalValue struct {
+	Value float64
+	OK    bool
+}
+
+// at - ค่าของไตรมาสที่ย้อนหลังไป lag ไตรมาสปฏิทิน
+func (s growthSeries) at(item FinancialData, history []FinancialData, lag int) optionalValue {
+	if lag == 0 {
+		v, ok := s.Value(item, history)
+		return optionalValue{v, ok}
+	}
+	target := calendarPeriodOf(item).Add(-lag)
+	for i, prev := range history {
+		p := calendarPeriodOf(prev)
+		if p == target {
+			v, ok := s.Value(prev, history[i+1:])
+			return optionalValue{v, ok}
+		}
+		if p.Before(target) {
+			break
+		}
+	}
+	return optionalValue{}
+}
+
+// ttm - ผลรวม 4 ไตรมาสติดกันที่สิ้นสุดก่อนหน้า item ไป lag ไตรมาส
+func (s growthSeries) ttm(item FinancialData, history []FinancialData, lag int) optionalValue {
+	var sum float64
+	for i := lag; i < lag+4; i++ {
+		v := s.at(item, history, i)
+		if !v.OK {
+			return optionalValue{}
+		}
+		sum += v.Value
+	}
+	return optionalValue{sum, true}
+}
+
+// growthBetween - (ค่าปัจจุบัน - ฐาน) / |ฐาน|
+// ใช้ค่าสัมบูรณ์ของฐานเพื่อให้การขาดทุนที่ลดลงเป็นการเติบโตบวก เช่น -10 -> -5 = +50%
+// ฐานเป็นศูนย์คำนวณไม่ได้
+func growthBetween(current, base optionalValue) (float64, bool) {
+	if !current.OK || !base.OK || base.Value == 0 {
+		return 0, false
+	}
+	return (current.Value - base.Value) / math.Abs(base.Value), true
+}
+
+// cagr - อัตราเติบโตเฉลี่ยต่อปี ต้องเป็นค่าบวกทั้งสองฝั่ง (CAGR ของค่าติดลบไม่มีความหมาย)
+func cagr(current, base optionalValue, years int) (float64, bool) {
+	if !current.OK || !base.OK || current.Value <= 0 || base.Value <= 0 {
+		return 0, false
+	}
+	return math.Pow(current.Value/base.Value, 1/float64(years)) - 1, true
+}
+
+// quarterField - ค่าที่ API ให้เป็นรายไตรมาสอยู่แล้ว
+func quarterField(field func(FinancialData) float64) func(FinancialData, []FinancialData) (float64, bool) {
+	return func(item FinancialData, _ []FinancialData) (float64, bool) {
+		return field(item), true
+	}
+}
+
+// quarterlyFromAccum - แปลงยอดสะสมตั้งแต่ต้นปีบัญชีเป็นยอดรายไตรมาส
+// ไตรมาสแรกของปีบัญชีใช้ยอดสะสมได้เลย ไตรมาสอื่นต้องมีงวดก่อนหน้าของปีบัญชีเดียวกันมาลบ
+func quarterlyFromAccum(field func(FinancialData) float64) func(FinancialData, []FinancialData) (float64, bool) {
+	return func(item FinancialData, history []FinancialData) (float64, bool) {
+		fiscal, ok := fiscalPeriodOf(item)
+		if !ok {
+			return 0, false
+		}
+		if fiscal.Quarter == 1 {
+			return field(item), true
+		}
+		if len(history) == 0 || calendarPeriodOf(history[0]) != calendarPeriodOf(item).Add(-1) {
+			return 0, false
+		}
+		prevFiscal, ok := fiscalPeriodOf(history[0])
+		if !ok || prevFiscal != fiscal.Add(-1) {
+			return 0, false
+		}
+		return field(item) - field(history[0]), true
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+func TestGrowthBetween(t *testing.T) {
+	tests := []struct {
+		name          string
+		current, base optionalValue
+		want          float64
+		wantOK        bool
+	}{
+		{"positive growth", optionalValue{10, true}, optionalValue{8, true}, 0.25, true},
+		{"decline", optionalValue{6, true}, optionalValue{8, true}, -0.25, true},
+		{"smaller loss is positive growth", optionalValue{-5, true}, optionalValue{-10, true}, 0.5, true},
+		{"larger loss is negative growth", optionalValue{-15, true}, optionalValue{-10, true}, -0.5, true},
+		{"loss to profit", optionalValue{5, true}, optionalValue{-10, true}, 1.5, true},
+		{"zero base", optionalValue{5, true}, optionalValue{0, true}, 0, false},
+		{"missing base", optionalValue{5, true}, optionalValue{}, 0, false},
+		{"missing current", optionalValue{}, optionalValue{5, true}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := growthBetween(tt.current, tt.base)
+			if ok != tt.wantOK || (ok && !approxEqual(got, tt.want)) {
+				t.Errorf("growthBetween(%v, %v) = %v, %v; want %v, %v", tt.current, tt.base, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCagr(t *testing.T) {
+	tests := []struct {
+		name          string
+		current, base optionalValue
+		years         int
+		want          float64
+		wantOK        bool
+	}{
+		{"two years of 10%", optionalValue{121, true}, optionalValue{100, true}, 2, 0.1, true},
+		{"three years of halving", optionalValue{12.5, true}, optionalValue{100, true}, 3, -0.5, true},
+		{"negative base", optionalValue{121, true}, optionalValue{-100, true}, 2, 0, false},
+		{"zero current", optionalValue{0, true}, optionalValue{100, true}, 2, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cagr(tt.current, tt.base, tt.years)
+			if ok != tt.wantOK || (ok && !approxEqual(got, tt.want)) {
+				t.Errorf("cagr = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGrowthMetricsUseCalendarQuarters(t *testing.T) {
+	withRevenue := func(item FinancialData, revenue float64) FinancialData {
+		item.TotalRevenueQuarter = revenue
+		return item
+	}
+	item := withRevenue(quarterItem(2024, 1), 120)
+	full := []FinancialData{
+		withRevenue(quarterItem(2023, 4), 100),
+		withRevenue(quarterItem(2023, 3), 90),
+		withRevenue(quarterItem(2023, 2), 85),
+		withRevenue(quarterItem(2023, 1), 80),
+	}
+	// ไม่มี 2023Q4 ต้องไม่เทียบกับ 2023Q3 แทน
+	missingPrevious := full[1:]
+
+	tests := []struct {
+		name    string
+		history []FinancialData
+		metric  string
+		want    float64
+		wantOK  bool
+	}{
+		{"QoQ", full, "revenueQoQ", 0.2, true},
+		{"YoY", full, "revenueYoY", 0.5, true},
+		{"QoQ skips a missing quarter", missingPrevious, "revenueQoQ", 0, false},
+		{"YoY still finds the same quarter", missingPrevious, "revenueYoY", 0.5, true},
+		{"TTMYoY needs eight quarters", full, "revenueTTMYoY", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := derivedMetricsOf(item, tt.history)[tt.metric]
+			if ok != tt.wantOK || (ok && !approxEqual(got, tt.want)) {
+				t.Errorf("%s = %v, %v; want %v, %v", tt.metric, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ImportIssue - ปัญหาที่พบระหว่างนำเข้าไฟล์ CSV
+// Line นับจาก 1 ตามบรรทัดในไฟล์ (บรรทัดที่ 1 คือหัวคอลัมน์)
+type ImportIssue struct {
+	Line    int
+	Column  string
+	Value   string
+	Message string
+}
+
+func (i ImportIssue) String() string {
+	if i.Column == "" {
+		return T("import.issue_line", i.Line, i.Message)
+	}
+	return T("import.issue_column", i.Line, i.Column, i.Value, i.Message)
+}
+
+// ImportCSV - อ่านไฟล์ที่ส่งออกด้วย ExportToCSV กลับเป็น []FinancialData
+// รองรับหัวคอลัมน์ทั้งภาษาไทย ภาษาอังกฤษ และชื่อคอลัมน์เดิม, ไฟล์ที่มีหรือไม่มี BOM,
+// ปีแบบพุทธศักราช และไฟล์ .gz/.zst หรือ "-" (stdin)
+// แถวที่อ่านไม่ได้จะถูกข้ามและรายงานใน []ImportIssue โดยไม่ทำให้การนำเข้าทั้งหมดล้มเหลว
+// ค่าที่ exportPrecision เปลี่ยนหน่วยไว้จะถูกแปลงกลับ เช่นเดียวกับที่ ExportToCSV ใช้ตอนเขียน
+func ImportCSV(filename string) ([]FinancialData, []ImportIssue, error) {
+	return ImportCSVWithLayout(filename, ColumnLayout{}, exportPrecision)
+}
+
+// ImportCSVWithLayout - อ่านไฟล์ที่ส่งออกด้วย ExportToCSVWithLayout โดยใช้ layout และนโยบายความละเอียดชุดเดียวกับตอนส่งออก
+// หัวคอลัมน์ที่ตั้งเองใน layout จะจับคู่กลับเป็นคอลัมน์เดิม และค่าที่ส่งออกเป็น thousands, millions หรือ percent
+// จะถูกแปลงกลับเป็นหน่วยเดิม (ทศนิยมที่ถูกปัดไปแล้วคืนไม่ได้)
+func ImportCSVWithLayout(filename string, layout ColumnLayout, precision PrecisionPolicy) ([]FinancialData, []ImportIssue, error) {
+	file, err := openInput(filename)
+	if err != nil {
+		return nil, nil, errMsg("import.open_file", err)
+	}
+	defer file.Close()
+
+	data, issues, err := ReadFinancialCSVWithLayout(file, layout, precision)
+	if err != nil {
+		return nil, issues, err
+	}
+
+	logMsg("import.done", displayPath(filename), len(data), len(issues))
+	return data, issues, nil
+}
+
+// readFinancialCSVFile - เหมือน ImportCSV แต่ไม่แสดงข้อความ (ใช้ตอนอ่าน dataset หลายไฟล์)
+func readFinancialCSVFile(filename string) ([]FinancialData, []ImportIssue, error) {
+	file, err := openInput(filename)
+	if err != nil {
+		return nil, nil, errMsg("import.open_file", err)
+	}
+	defer file.Close()
+	return ReadFinancialCSV(file)
+}
+
+// ReadFinancialCSV - อ่านข้อมูลงบการเงินจาก CSV ที่ส่งออกด้วยคอลัมน์และความละเอียดเริ่มต้น
+func ReadFinancialCSV(r io.Reader) ([]FinancialData, []ImportIssue, error) {
+	return ReadFinancialCSVWithLayout(r, ColumnLayout{}, PrecisionPolicy{Default: losslessRule})
+}
+
+// ReadFinancialCSVWithLayout - อ่านข้อมูลงบการเงินจาก CSV ตาม layout และนโยบายความละเอียดของไฟล์
+func ReadFinancialCSVWithLayout(r io.Reader, layout ColumnLayout, precision PrecisionPolicy) ([]FinancialData, []ImportIssue, error) {
+	reader := csv.NewReader(skipBOM(r))
+	reader.FieldsPerRecord = -1 // ตรวจจำนวนคอลัมน์เองเพื่อรายงานเป็นรายแถว
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errMsg("export.no_data")
+	}
+	if err != nil {
+		return nil, nil, errMsg("import.read_header", err)
+	}
+
+	columns, issues := resolveImportHeader(header, layout)
+	precision = layout.Precision(precision)
+	hasColumn := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		if col != nil {
+			hasColumn[col.Name] = true
+		}
+	}
+	if !hasColumn["Symbol"] {
+		return nil, issues, errMsg("import.missing_column", columnHeader("Symbol"))
+	}
+
+	var data []FinancialData
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// แถวที่ csv อ่านไม่ได้ เช่น เครื่องหมายคำพูดไม่ครบ
+			issues = append(issues, ImportIssue{Line: line, Message: err.Error()})
+			continue
+		}
+		if len(record) != len(header) {
+			issues = append(issues, ImportIssue{Line: line, Message: T("import.field_count", len(record), len(header))})
+			continue
+		}
+
+		item, issue, ok := parseImportRow(record, columns, precision, line)
+		if !ok {
+			issues = append(issues, issue)
+			continue
+		}
+		data = append(data, item)
+	}
+
+	restoreFiscalPeriods(data, hasColumn)
+	sortFinancialData(data)
+	return data, issues, nil
+}
+
+// skipBOM - ข้าม UTF-8 BOM ที่ ExportToCSV ใส่ไว้ให้ Excel (ถ้ามี)
+func skipBOM(r io.Reader) io.Reader {
+	buf := bufio.NewReader(r)
+	if prefix, err := buf.Peek(3); err == nil && bytes.Equal(prefix, []byte{0xEF, 0xBB, 0xBF}) {
+		buf.Discard(3)
+	}
+	return buf
+}
+
+// resolveImportHeader - จับคู่หัวคอลัมน์กับคอลัมน์ที่รู้จัก
+// หัวคอลัมน์ที่ตั้งเองใน layout (-columns Name=Header) มาก่อนชื่อมาตรฐาน
+// หัวคอลัมน์ที่ไม่รู้จักจะถูกข้ามและรายงานไว้
+func resolveImportHeader(header []string, layout ColumnLayout) ([]*ExportColumn, []ImportIssue) {
+	lookup := make(map[string]*ExportColumn)
+	for i := range exportColumns {
+		col := &exportColumns[i]
+		lookup[importHeaderKey(col.Name)] = col
+		if name, ok := columnNames[col.Name]; ok {
+			lookup[importHeaderKey(name.th)] = col
+			lookup[importHeaderKey(name.en)] = col
+		}
+	}
+	for _, spec := range layout.Columns {
+		if col, ok := exportColumnsByName[spec.Name]; ok && spec.Header != "" {
+			lookup[importHeaderKey(spec.Header)] = &col
+		}
+	}
+
+	var issues []ImportIssue
+	columns := make([]*ExportColumn, len(header))
+	for i, h := range header {
+		col, ok := lookup[importHeaderKey(h)]
+		if !ok {
+			issues = append(issues, ImportIssue{Line: 1, Column: h, Message: T("import.unknown_header")})
+			continue
+		}
+		columns[i] = col
+	}
+	return columns, issues
+}
+
+func importHeaderKey(h string) string {
+	return strings.ToLower(strings.TrimSpace(h))
+}
+
+// parseImportRow - แปลงหนึ่งแถวเป็น FinancialData คืน false ถ้าแถวนี้ใช้ไม่ได้
+// ค่าทศนิยมในคอลัมน์ที่ precision เปลี่ยนหน่วยไว้จะถูกแปลงกลับเป็นหน่วยเดิมก่อนเก็บ
+func parseImportRow(record []string, columns []*ExportColumn, precision PrecisionPolicy, line int) (FinancialData, ImportIssue, bool) {
+	var item FinancialData
+	for i, raw := range record {
+		col := columns[i]
+		if col == nil {
+			continue
+		}
+		raw = strings.TrimSpace(raw)
+		if yearColumns[col.Name] {
+			raw = parseDisplayYear(raw)
+		}
+		if rule := precision.Rule(col.Name); col.float && raw != "" && rule.scaled() {
+			f, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return FinancialData{}, ImportIssue{Line: line, Column: col.Name, Value: raw, Message: err.Error()}, false
+			}
+			raw = strconv.FormatFloat(rule.unscale(f), 'g', -1, 64)
+		}
+		if err := col.set(&item, raw); err != nil {
+			return FinancialData{}, ImportIssue{Line: line, Column: col.Name, Value: raw, Message: err.Error()}, false
+		}
+	}
+
+	if item.Symbol == "" {
+		return FinancialData{}, ImportIssue{Line: line, Column: "Symbol", Message: T("import.empty_symbol")}, false
+	}
+	return item, ImportIssue{}, true
+}
+
+// restoreFiscalPeriods - คืนค่าปฏิทินปีบัญชีที่ไม่ได้ส่งออกไว้ในไฟล์
+// ถ้าไฟล์มีไตรมาสปฏิทินจะคำนวณ Shift จากผลต่างกับไตรมาสของปีบัญชี ไม่เช่นนั้นคำนวณใหม่จากวันที่ของงบ
+func restoreFiscalPeriods(data []FinancialData, hasColumn map[string]bool) {
+	if !hasColumn["CalendarYear"] || !hasColumn["CalendarQuarter"] {
+		alignFiscalPeriods(data)
+		return
+	}
+
+	for i := range data {
+		item := &data[i]
+		if hasColumn["FiscalShift"] {
+			continue
+		}
+		fiscal, ok := fiscalPeriodOf(*item)
+		if !ok || item.Calendar.IsZero() {
+			continue
+		}
+		item.Fiscal.Shift = item.Calendar.Index() - fiscal.Index()
+		if item.Fiscal.YearEndMonth == 0 {
+			item.Fiscal.YearEndMonth = Period{Year: 2000, Quarter: 4}.Add(item.Fiscal.Shift).EndMonth()
+		}
+	}
+}
+
+// logImportIssues - แสดงปัญหาที่พบระหว่างนำเข้า
+func logImportIssues(issues []ImportIssue) {
+	for _, issue := range issues {
+		fmt.Fprintln(logOutput, issue.String())
+	}
+}
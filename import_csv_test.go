@@ -0,0 +1,144 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCSVRoundTripWithPrecisionAndHeaders(t *testing.T) {
+	item := quarterItem(2024, 1)
+	item.TotalAssets = 2500000000
+	item.TotalLiabilities = 1500
+	item.Roe = 0.125
+	item.EpsQuarter = 0.37
+	item.PriceData = map[string]interface{}{"price_marketCap": 42000000.0}
+
+	layout, err := parseColumnLayout("Symbol,Year,Quarter,TotalAssets=assets_m,TotalLiabilities=liabilities_k,ROE=roe_pct,EpsQuarter,price_marketCap=mcap_m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := parsePrecisionPolicy("TotalAssets=millions,TotalLiabilities=thousands,ROE=percent,price_marketCap=millions")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := exportPrecision
+	exportPrecision = policy
+	t.Cleanup(func() { exportPrecision = saved })
+
+	filename := filepath.Join(t.TempDir(), "financials.csv")
+	if err := ExportToCSVWithLayout([]FinancialData{item}, filename, layout); err != nil {
+		t.Fatal(err)
+	}
+
+	data, issues, err := ImportCSVWithLayout(filename, layout, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 || len(data) != 1 {
+		t.Fatalf("got %d rows and issues %v; want 1 row and no issues", len(data), issues)
+	}
+
+	got := data[0]
+	tests := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"symbol", got.Symbol, "AAA"},
+		{"millions", got.TotalAssets, 2500000000.0},
+		{"thousands", got.TotalLiabilities, 1500.0},
+		{"percent", got.Roe, 0.125},
+		{"unscaled", got.EpsQuarter, 0.37},
+		{"price millions", got.PriceData["price_marketCap"], 42000000.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v; want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportToCSVImportCSVRoundTrip(t *testing.T) {
+	item := quarterItem(2024, 1)
+	item.TotalAssets = 2500000000
+	item.Roe = 0.125
+	item.PriceData = map[string]interface{}{"price_close": 12.5}
+
+	saved := exportPrecision
+	t.Cleanup(func() { exportPrecision = saved })
+
+	tests := []struct {
+		name      string
+		precision string
+	}{
+		{"raw", ""},
+		{"scaled", "*=millions,ROE=percent,price_close=fixed:2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := parsePrecisionPolicy(tt.precision)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exportPrecision = policy
+
+			filename := filepath.Join(t.TempDir(), "financials.csv")
+			if err := ExportToCSV([]FinancialData{item}, filename); err != nil {
+				t.Fatal(err)
+			}
+			data, _, err := ImportCSV(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(data) != 1 {
+				t.Fatalf("got %d rows; want 1", len(data))
+			}
+			got := data[0]
+			if got.TotalAssets != item.TotalAssets || got.Roe != item.Roe || got.PriceData["price_close"] != 12.5 {
+				t.Errorf("got TotalAssets %v, ROE %v, price_close %v; want %v, %v, 12.5",
+					got.TotalAssets, got.Roe, got.PriceData["price_close"], item.TotalAssets, item.Roe)
+			}
+		})
+	}
+}
+
+func TestImportCSVWithoutLayoutSkipsRenamedHeaders(t *testing.T) {
+	layout, err := parseColumnLayout("Symbol,Year,Quarter,TotalAssets=assets_m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := quarterItem(2024, 1)
+	item.TotalAssets = 100
+
+	filename := filepath.Join(t.TempDir(), "financials.csv")
+	if err := ExportToCSVWithLayout([]FinancialData{item}, filename, layout); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		layout     ColumnLayout
+		wantAssets float64
+		wantIssues int
+	}{
+		{"default headers only", ColumnLayout{}, 0, 1},
+		{"layout of the export", layout, 100, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, issues, err := ImportCSVWithLayout(filename, tt.layout, PrecisionPolicy{Default: losslessRule})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(data) != 1 {
+				t.Fatalf("got %d rows; want 1", len(data))
+			}
+			if data[0].TotalAssets != tt.wantAssets || len(issues) != tt.wantIssues {
+				t.Errorf("got TotalAssets %v, %d issues; want %v, %d issues",
+					data[0].TotalAssets, len(issues), tt.wantAssets, tt.wantIssues)
+			}
+		})
+	}
+}
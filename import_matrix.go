@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// ReadMatrix - อ่าน training matrix ที่เขียนด้วยคำสั่ง matrix (CSV หรือ Parquet)
+// ถ้ามีไฟล์ _features.json คู่กันจะใช้ลำดับคอลัมน์ กลุ่ม และการตั้งค่าจากไฟล์นั้น
+// ถ้าไม่มีจะเดากลุ่มจาก prefix ของชื่อคอลัมน์
+func ReadMatrix(filename string) (TrainingMatrix, error) {
+	var m TrainingMatrix
+	var err error
+	name := strings.TrimSuffix(strings.TrimSuffix(filename, gzipExt), zstdExt)
+	if strings.HasSuffix(name, ".parquet") {
+		m, err = readMatrixParquet(filename)
+	} else {
+		m, err = readMatrixCSV(filename)
+	}
+	if err != nil {
+		return m, err
+	}
+
+	if meta, ok := readMatrixMetadata(filename); ok {
+		m.Config, m.Dropped, m.Impute, m.Transform = meta.Config, meta.Dropped, meta.Impute, meta.Transform
+		byName := make(map[string]MatrixColumn, len(meta.Columns))
+		for _, col := range meta.Columns {
+			byName[col.Name] = col
+		}
+		for i, col := range m.Columns {
+			if known, ok := byName[col.Name]; ok {
+				m.Columns[i].Group, m.Columns[i].Header = known.Group, known.Header
+			}
+		}
+		m.reorderColumns(meta.Columns)
+	}
+	m.countNonMissing()
+	return m, nil
+}
+
+// matrixMetadataPath - ชื่อไฟล์ _features.json ของไฟล์ matrix
+func matrixMetadataPath(filename string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(filename, gzipExt), zstdExt)
+	base = strings.TrimSuffix(strings.TrimSuffix(base, ".csv"), ".parquet")
+	return base + "_features.json"
+}
+
+func readMatrixMetadata(filename string) (MatrixMetadata, bool) {
+	var meta MatrixMetadata
+	body, err := os.ReadFile(matrixMetadataPath(filename))
+	if err != nil {
+		return meta, false
+	}
+	if err := json.Unmarshal(body, &meta); err != nil {
+		return meta, false
+	}
+	return meta, true
+}
+
+// reorderColumns - เรียงคอลัมน์ตามลำดับใน metadata (Parquet เก็บคอลัมน์เรียงตามชื่อ)
+func (m *TrainingMatrix) reorderColumns(order []MatrixColumn) {
+	position := make(map[string]int, len(m.Columns))
+	for i, col := range m.Columns {
+		position[col.Name] = i
+	}
+	var index []int
+	for _, col := range order {
+		if i, ok := position[col.Name]; ok {
+			index = append(index, i)
+			delete(position, col.Name)
+		}
+	}
+	// คอลัมน์ที่ไม่มีใน metadata ต่อท้าย
+	for i, col := range m.Columns {
+		if _, ok := position[col.Name]; ok {
+			index = append(index, i)
+		}
+	}
+
+	columns := make([]MatrixColumn, len(index))
+	for j, i := range index {
+		columns[j] = m.Columns[i]
+	}
+	m.Columns = columns
+	for r := range m.Rows {
+		values := make([]float64, len(index))
+		for j, i := range index {
+			values[j] = m.Rows[r].Values[i]
+		}
+		m.Rows[r].Values = values
+	}
+}
+
+// matrixColumnGroup - กลุ่มของคอลัมน์จากชื่อ ใช้เมื่อไม่มีไฟล์ metadata
+func matrixColumnGroup(name string) string {
+	switch {
+	case strings.HasPrefix(name, labelColumnPrefix):
+		return groupLabel
+	case strings.HasPrefix(name, imputedColumnPrefix):
+		return groupIndicator
+	case strings.HasPrefix(name, technicalColumnPrefix):
+		return groupTechnical
+	case strings.HasPrefix(name, derivedColumnPrefix):
+		return groupDerived
+	case strings.HasPrefix(name, "price_"):
+		return groupPrice
+	}
+	return groupFundamental
+}
+
+func newMatrixColumn(name string) MatrixColumn {
+	return MatrixColumn{Name: name, Group: matrixColumnGroup(name), Header: columnHeader(name)}
+}
+
+func readMatrixCSV(filename string) (TrainingMatrix, error) {
+	var m TrainingMatrix
+	file, err := openInput(filename)
+	if err != nil {
+		return m, errMsg("import.open_file", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(skipBOM(file))
+	header, err := reader.Read()
+	if err != nil {
+		return m, errMsg("import.read_header", err)
+	}
+
+	keys := make(map[string]int)
+	var valueIndex []int
+	for i, name := range header {
+		if isMatrixKeyColumn(name) {
+			keys[name] = i
+			continue
+		}
+		m.Columns = append(m.Columns, newMatrixColumn(name))
+		valueIndex = append(valueIndex, i)
+	}
+	for _, key := range matrixKeyColumns {
+		if _, ok := keys[key]; !ok {
+			return m, errMsg("import.missing_column", key)
+		}
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, errMsg("matrix.read_row", line, err)
+		}
+		row, err := matrixRowFromKeys(func(key string) string { return record[keys[key]] })
+		if err != nil {
+			return m, errMsg("matrix.read_row", line, err)
+		}
+		row.Values = make([]float64, len(valueIndex))
+		for j, i := range valueIndex {
+			row.Values[j] = math.NaN()
+			if record[i] == "" {
+				continue
+			}
+			if row.Values[j], err = strconv.ParseFloat(record[i], 64); err != nil {
+				return m, errMsg("matrix.read_row", line, err)
+			}
+		}
+		m.Rows = append(m.Rows, row)
+	}
+	return m, nil
+}
+
+func readMatrixParquet(filename string) (TrainingMatrix, error) {
+	var m TrainingMatrix
+	file, err := os.Open(filename)
+	if err != nil {
+		return m, errMsg("import.open_file", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return m, errMsg("import.open_file", err)
+	}
+	pf, err := parquet.OpenFile(file, info.Size())
+	if err != nil {
+		return m, errMsg("import.read_parquet", err)
+	}
+
+	// ตำแหน่งคอลัมน์ใน schema -> ตำแหน่งใน Values (-1 = คอลัมน์ key)
+	paths := pf.Schema().Columns()
+	position := make([]int, len(paths))
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = path[0]
+		position[i] = -1
+		if !isMatrixKeyColumn(names[i]) {
+			position[i] = len(m.Columns)
+			m.Columns = append(m.Columns, newMatrixColumn(names[i]))
+		}
+	}
+
+	reader := parquet.NewReader(pf)
+	defer reader.Close()
+	buf := make([]parquet.Row, 1)
+	for line := 1; ; line++ {
+		n, err := reader.ReadRows(buf)
+		if n == 0 {
+			if err != nil && err != io.EOF {
+				return m, errMsg("import.read_parquet", err)
+			}
+			break
+		}
+		keys := make(map[string]string, len(matrixKeyColumns))
+		values := make([]float64, len(m.Columns))
+		for j := range values {
+			values[j] = math.NaN()
+		}
+		for _, v := range buf[0] {
+			col := v.Column()
+			switch {
+			case v.IsNull():
+			case position[col] >= 0:
+				values[position[col]] = v.Double()
+			case v.Kind() == parquet.Int32:
+				keys[names[col]] = parquetDateString(v.Int32())
+			default:
+				keys[names[col]] = v.String()
+			}
+		}
+		row, err := matrixRowFromKeys(func(key string) string { return keys[key] })
+		if err != nil {
+			return m, errMsg("matrix.read_row", line, err)
+		}
+		row.Values = values
+		m.Rows = append(m.Rows, row)
+	}
+	return m, nil
+}
+
+func isMatrixKeyColumn(name string) bool {
+	for _, key := range matrixKeyColumns {
+		if key == name {
+			return true
+		}
+	}
+	return false
+}
+
+// matrixRowFromKeys - สร้างแถวจากค่าของคอลัมน์ระบุแถว (รูปแบบเดียวกับ MatrixRow.keyValues)
+func matrixRowFromKeys(value func(key string) string) (MatrixRow, error) {
+	row := MatrixRow{
+		Symbol:        value("Symbol"),
+		StatementType: value("FinancialStatementType"),
+		Split:         value("Split"),
+	}
+	var err error
+	if row.SnapshotDate, err = time.Parse("2006-01-02", value("SnapshotDate")); err != nil {
+		return row, err
+	}
+	if row.AvailableDate, err = time.Parse("2006-01-02", value("FundamentalAvailableDate")); err != nil {
+		return row, err
+	}
+	if row.FundamentalPeriod, err = parsePeriod(value("FundamentalPeriod")); err != nil {
+		return row, err
+	}
+	return row, nil
+}
+
+// parsePeriod - อ่านไตรมาสในรูปแบบของ Period.String เช่น 2023Q4
+func parsePeriod(s string) (Period, error) {
+	year, quarter, ok := strings.Cut(s, "Q")
+	y, err1 := strconv.Atoi(year)
+	q, err2 := strconv.Atoi(quarter)
+	if !ok || err1 != nil || err2 != nil || q < 1 || q > 4 {
+		return Period{}, errMsg("matrix.invalid_period", s)
+	}
+	return Period{Year: y, Quarter: q}, nil
+}
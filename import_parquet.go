@@ -0,0 +1,168 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// ReadFinancialsParquet - อ่านไฟล์ Parquet ที่เขียนด้วย ExportFinancialsToParquet กลับเป็น []FinancialData
+// วันที่จะได้กลับมาในรูปแบบ YYYY-MM-DD และค่า Shift ของปีบัญชีคำนวณจากไตรมาสปฏิทินที่เก็บไว้
+func ReadFinancialsParquet(filename string) ([]FinancialData, error) {
+	rows, err := parquet.ReadFile[financialParquetRow](filename)
+	if err != nil {
+		return nil, errMsg("import.read_parquet", err)
+	}
+
+	data := make([]FinancialData, len(rows))
+	for i, row := range rows {
+		data[i] = fromFinancialParquetRow(row)
+	}
+	return data, nil
+}
+
+func fromFinancialParquetRow(row financialParquetRow) FinancialData {
+	item := FinancialData{
+		Symbol:                 row.Symbol,
+		Year:                   parquetIntString(row.Year),
+		Quarter:                parquetIntString(row.Quarter),
+		FinancialStatementType: row.FinancialStatementType,
+		DateAsof:               parquetDateString(row.DateAsof),
+		AccountPeriod:          row.AccountPeriod,
+		TotalAssets:            row.TotalAssets,
+		TotalLiabilities:       row.TotalLiabilities,
+		PaidupShareCapital:     row.PaidupShareCapital,
+		ShareholderEquity:      row.ShareholderEquity,
+		TotalEquity:            row.TotalEquity,
+		TotalRevenueQuarter:    row.TotalRevenueQuarter,
+		TotalRevenueAccum:      row.TotalRevenueAccum,
+		TotalExpensesQuarter:   row.TotalExpensesQuarter,
+		TotalExpensesAccum:     row.TotalExpensesAccum,
+		EbitQuarter:            row.EbitQuarter,
+		EbitAccum:              row.EbitAccum,
+		NetProfitQuarter:       row.NetProfitQuarter,
+		NetProfitAccum:         row.NetProfitAccum,
+		EpsQuarter:             row.EpsQuarter,
+		EpsAccum:               row.EpsAccum,
+		OperatingCashFlow:      row.OperatingCashFlow,
+		InvestingCashFlow:      row.InvestingCashFlow,
+		FinancingCashFlow:      row.FinancingCashFlow,
+		Roe:                    row.Roe,
+		Roa:                    row.Roa,
+		NetProfitMarginQuarter: row.NetProfitMarginQuarter,
+		NetProfitMarginAccum:   row.NetProfitMarginAccum,
+		De:                     row.De,
+		FixedAssetTurnover:     row.FixedAssetTurnover,
+		TotalAssetTurnover:     row.TotalAssetTurnover,
+	}
+	item.Fiscal.YearEndMonth = int(row.FiscalYearEndMonth)
+	item.Lineage = Lineage{
+		RunID:              row.LineageRunID,
+		Endpoint:           row.LineageEndpoint,
+		Params:             row.LineageParams,
+		FetchedAt:          row.LineageFetchedAt,
+		ResponseHash:       row.LineageResponseHash,
+		PriceEndpoint:      row.LineagePriceEndpoint,
+		PriceParams:        row.LineagePriceParams,
+		PriceFetchedAt:     row.LineagePriceFetchedAt,
+		PriceResponseHash:  row.LineagePriceHash,
+		PriceRequestedDate: row.LineagePriceRequested,
+		PriceDate:          row.LineagePriceDate,
+		PriceExactDate:     row.LineagePriceExactDate,
+	}
+
+	if row.CalendarYear != nil && row.CalendarQuarter != nil {
+		item.Calendar = Period{Year: int(*row.CalendarYear), Quarter: int(*row.CalendarQuarter)}
+		if fiscal, ok := fiscalPeriodOf(item); ok {
+			item.Fiscal.Shift = item.Calendar.Index() - fiscal.Index()
+		}
+	}
+
+	prices := map[string]*float64{
+		"prior":          row.PricePrior,
+		"open":           row.PriceOpen,
+		"high":           row.PriceHigh,
+		"low":            row.PriceLow,
+		"close":          row.PriceClose,
+		"average":        row.PriceAverage,
+		"totalVolume":    row.PriceTotalVolume,
+		"totalValue":     row.PriceTotalValue,
+		"pe":             row.PricePe,
+		"pbv":            row.PricePbv,
+		"bvps":           row.PriceBvps,
+		"dividendYield":  row.PriceDividendYield,
+		"marketCap":      row.PriceMarketCap,
+		"volumeTurnover": row.PriceVolumeTurnover,
+	}
+	for key, v := range prices {
+		if v == nil {
+			continue
+		}
+		if item.PriceData == nil {
+			item.PriceData = make(map[string]interface{})
+		}
+		item.PriceData["price_"+key] = *v
+	}
+	if row.PriceDate != 0 {
+		if item.PriceData == nil {
+			item.PriceData = make(map[string]interface{})
+		}
+		item.PriceData["price_date"] = parquetDateString(row.PriceDate)
+	}
+
+	return item
+}
+
+func parquetIntString(v *int32) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(int(*v))
+}
+
+// parquetDateString - แปลงจำนวนวันนับจาก 1970-01-01 กลับเป็น YYYY-MM-DD
+func parquetDateString(v int32) string {
+	if v == 0 {
+		return ""
+	}
+	return time.Unix(int64(v)*int64(24*time.Hour/time.Second), 0).UTC().Format("2006-01-02")
+}
+
+// ReadPricesParquet - อ่านไฟล์ Parquet ราคารายวันที่เขียนด้วย ExportPricesToParquet
+func ReadPricesParquet(filename string) ([]EODPriceBySymbol, error) {
+	rows, err := parquet.ReadFile[priceParquetRow](filename)
+	if err != nil {
+		return nil, errMsg("import.read_parquet", err)
+	}
+
+	history := make([]EODPriceBySymbol, len(rows))
+	for i, row := range rows {
+		history[i] = EODPriceBySymbol{
+			Date:              parquetDateString(row.Date),
+			Symbol:            row.Symbol,
+			SecurityType:      row.SecurityType,
+			AdjustedPriceFlag: row.AdjustedPriceFlag,
+			Prior:             row.Prior,
+			Open:              row.Open,
+			High:              row.High,
+			Low:               row.Low,
+			Close:             row.Close,
+			Average:           row.Average,
+			AomVolume:         row.AomVolume,
+			AomValue:          row.AomValue,
+			TrVolume:          row.TrVolume,
+			TrValue:           row.TrValue,
+			TotalVolume:       row.TotalVolume,
+			TotalValue:        row.TotalValue,
+			Pe:                row.Pe,
+			Pbv:               row.Pbv,
+			Bvps:              row.Bvps,
+			DividendYield:     row.DividendYield,
+			MarketCap:         row.MarketCap,
+			VolumeTurnover:    row.VolumeTurnover,
+		}
+	}
+	sortPriceHistory(history)
+	return history, nil
+}
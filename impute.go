@@ -0,0 +1,333 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// วิธีเติมค่าที่ขาดใน training matrix ใช้ได้หลายวิธีต่อกันตามลำดับ เช่น ffill,median
+const (
+	imputeForwardFill = "ffill"  // ใช้ค่าล่าสุดของหุ้นเดียวกันจากแถวก่อนหน้า
+	imputeMedian      = "median" // ใช้ค่ามัธยฐานของหุ้นทุกตัว (หรือกลุ่มอุตสาหกรรมเดียวกัน) ในไตรมาสเดียวกัน
+	imputeDrop        = "drop"   // ตัดแถวที่ยังมีค่าขาดทิ้ง (ต้องเป็นวิธีสุดท้าย)
+)
+
+// imputedColumnPrefix - prefix ของคอลัมน์ที่บอกว่าค่าของ feature ถูกเติม (1) หรือเป็นค่าจริง (0)
+const imputedColumnPrefix = "imputed_"
+
+// groupIndicator - กลุ่มของคอลัมน์ imputed_*
+const groupIndicator = "indicator"
+
+// ImputeConfig - การตั้งค่าการเติมค่าที่ขาด ทุกวิธีใช้เฉพาะข้อมูล ณ วันที่ของแถวหรือก่อนหน้า ไม่เติม label
+type ImputeConfig struct {
+	Strategies    []string          `json:"strategies"`
+	MaxStaleness  int               `json:"max_staleness_quarters"` // ffill ใช้ค่าที่เก่าไม่เกินกี่ไตรมาส
+	MedianBy      string            `json:"median_by"`              // period หรือ sector
+	Indicators    bool              `json:"indicators"`             // เพิ่มคอลัมน์ imputed_<ชื่อ>
+	ZeroAsMissing bool              `json:"zero_as_missing"`        // ตัวเลขงบการเงินที่เป็นศูนย์ถือว่าไม่มีค่า (API ส่ง 0 เมื่อไม่มีข้อมูล)
+	Sectors       map[string]string `json:"-"`
+}
+
+// ImputeStat - สรุปการเติมค่าของ feature หนึ่งคอลัมน์ (นับก่อนตัดแถวด้วย drop)
+type ImputeStat struct {
+	Column       string
+	Missing      int // ค่าที่ขาดก่อนเติม
+	ForwardFill  int
+	Median       int
+	StillMissing int // ค่าที่ยังขาดหลังเติม
+}
+
+// ImputeMatrix - เติมค่าที่ขาดของทุก feature ตามวิธีใน cfg.Strategies ตามลำดับ
+// คืนสรุปรายคอลัมน์ แถวที่ตัดด้วย drop นับใน m.Dropped["missing_features"]
+func ImputeMatrix(m *TrainingMatrix, cfg ImputeConfig) []ImputeStat {
+	features := make([]int, 0, len(m.Columns))
+	for i, col := range m.Columns {
+		if col.Group != groupLabel {
+			features = append(features, i)
+		}
+	}
+
+	stats := make([]ImputeStat, len(m.Columns))
+	imputed := make([][]bool, len(m.Rows))
+	for r := range m.Rows {
+		imputed[r] = make([]bool, len(m.Columns))
+		for _, c := range features {
+			v := &m.Rows[r].Values[c]
+			if cfg.ZeroAsMissing && *v == 0 && m.Columns[c].Group == groupFundamental && m.Columns[c].Name != "FundamentalAgeDays" {
+				*v = math.NaN()
+			}
+			if math.IsNaN(*v) {
+				stats[c].Missing++
+			}
+		}
+	}
+
+	drop := false
+	for _, strategy := range cfg.Strategies {
+		switch strategy {
+		case imputeForwardFill:
+			forwardFill(m, features, cfg.MaxStaleness, func(r, c int) {
+				imputed[r][c] = true
+				stats[c].ForwardFill++
+			})
+		case imputeMedian:
+			medianFill(m, features, cfg, func(r, c int) {
+				imputed[r][c] = true
+				stats[c].Median++
+			})
+		case imputeDrop:
+			drop = true
+		}
+	}
+
+	var summary []ImputeStat
+	for _, c := range features {
+		stats[c].Column = m.Columns[c].Name
+		stats[c].StillMissing = stats[c].Missing - stats[c].ForwardFill - stats[c].Median
+		summary = append(summary, stats[c])
+	}
+
+	if cfg.Indicators {
+		addImputedIndicators(m, features, imputed)
+	}
+	if drop {
+		kept := m.Rows[:0]
+		for _, row := range m.Rows {
+			complete := true
+			for _, c := range features {
+				if math.IsNaN(row.Values[c]) {
+					complete = false
+					break
+				}
+			}
+			if complete {
+				kept = append(kept, row)
+			} else {
+				m.Dropped["missing_features"]++
+			}
+		}
+		m.Rows = kept
+	}
+	m.countNonMissing()
+	return summary
+}
+
+// forwardFill - เติมค่าจากแถวก่อนหน้าของหุ้นและประเภทงบเดียวกัน ถ้าค่านั้นเก่าไม่เกิน maxStaleness ไตรมาส
+// แถวเรียงตามวันที่อยู่แล้ว จึงใช้เฉพาะค่าที่รู้ก่อนวันที่ของแถว
+func forwardFill(m *TrainingMatrix, features []int, maxStaleness int, filled func(r, c int)) {
+	type seriesKey struct{ Symbol, StatementType string }
+	type lastValue struct {
+		value  float64
+		period Period
+	}
+	last := make(map[seriesKey][]lastValue)
+
+	for r := range m.Rows {
+		row := &m.Rows[r]
+		key := seriesKey{row.Symbol, row.StatementType}
+		period := periodOfDate(row.SnapshotDate)
+		prev, ok := last[key]
+		if !ok {
+			prev = make([]lastValue, len(m.Columns))
+			last[key] = prev
+		}
+		for _, c := range features {
+			if v := row.Values[c]; !math.IsNaN(v) {
+				prev[c] = lastValue{v, period}
+				continue
+			}
+			if !prev[c].period.IsZero() && period.Index()-prev[c].period.Index() <= maxStaleness {
+				row.Values[c] = prev[c].value
+				filled(r, c)
+			}
+		}
+	}
+}
+
+// medianFill - เติมค่ามัธยฐานของ cross-section ในไตรมาสเดียวกัน (แยกตามกลุ่มอุตสาหกรรมเมื่อ MedianBy เป็น sector)
+func medianFill(m *TrainingMatrix, features []int, cfg ImputeConfig, filled func(r, c int)) {
+	type groupKey struct {
+		Period Period
+		Sector string
+	}
+	groups := make(map[groupKey][]int)
+	for r, row := range m.Rows {
+		key := groupKey{Period: periodOfDate(row.SnapshotDate)}
+		if cfg.MedianBy == neutralizeSector {
+			key.Sector = cfg.Sectors[row.Symbol]
+		}
+		groups[key] = append(groups[key], r)
+	}
+
+	for _, rows := range groups {
+		for _, c := range features {
+			var values []float64
+			var missing []int
+			for _, r := range rows {
+				if v := m.Rows[r].Values[c]; math.IsNaN(v) {
+					missing = append(missing, r)
+				} else {
+					values = append(values, v)
+				}
+			}
+			if len(values) == 0 || len(missing) == 0 {
+				continue
+			}
+			sort.Float64s(values)
+			median := quantile(values, 0.5)
+			for _, r := range missing {
+				m.Rows[r].Values[c] = median
+				filled(r, c)
+			}
+		}
+	}
+}
+
+// addImputedIndicators - เพิ่มคอลัมน์ imputed_<ชื่อ> ของทุก feature ไว้ก่อนคอลัมน์ label
+// เพิ่มทุกคอลัมน์แม้ไม่มีค่าที่เติม เพื่อให้ matrix ที่สร้างด้วยการตั้งค่าเดียวกันมีคอลัมน์ชุดเดียวกันเสมอ
+// (โมเดลที่ฝึกจาก matrix หนึ่งจึงใช้กับ matrix ของช่วงเวลาอื่นได้)
+func addImputedIndicators(m *TrainingMatrix, features []int, imputed [][]bool) {
+	insertAt := len(m.Columns)
+	for i, col := range m.Columns {
+		if col.Group == groupLabel {
+			insertAt = i
+			break
+		}
+	}
+
+	indicators := make([]MatrixColumn, len(features))
+	for i, c := range features {
+		name := imputedColumnPrefix + m.Columns[c].Name
+		indicators[i] = MatrixColumn{Name: name, Group: groupIndicator, Header: columnHeader(name)}
+	}
+	m.Columns = append(m.Columns[:insertAt], append(indicators, m.Columns[insertAt:]...)...)
+
+	for r := range m.Rows {
+		old := m.Rows[r].Values
+		values := make([]float64, 0, len(old)+len(features))
+		values = append(values, old[:insertAt]...)
+		for _, c := range features {
+			flag := 0.0
+			if imputed[r][c] {
+				flag = 1
+			}
+			values = append(values, flag)
+		}
+		m.Rows[r].Values = append(values, old[insertAt:]...)
+	}
+}
+
+// countNonMissing - นับค่าที่มีของแต่ละคอลัมน์ใหม่หลังเปลี่ยนแปลงแถว
+func (m *TrainingMatrix) countNonMissing() {
+	for i := range m.Columns {
+		m.Columns[i].NonMissing = 0
+	}
+	for _, row := range m.Rows {
+		for i, v := range row.Values {
+			if !math.IsNaN(v) {
+				m.Columns[i].NonMissing++
+			}
+		}
+	}
+}
+
+// imputedHeader - หัวคอลัมน์ของ imputed_* ตามภาษาปัจจุบัน
+func imputedHeader(col string) (string, bool) {
+	name, ok := strings.CutPrefix(col, imputedColumnPrefix)
+	if !ok {
+		return "", false
+	}
+	return T("impute.header", columnHeader(name)), true
+}
+
+// parseImputeConfig - อ่านการตั้งค่าจาก command line เช่น "ffill,median" หรือ "ffill,drop"
+func parseImputeConfig(spec string, maxStaleness int, medianBy string, indicators, zeroAsMissing bool, sectors map[string]string) (ImputeConfig, error) {
+	cfg := ImputeConfig{
+		MaxStaleness:  maxStaleness,
+		MedianBy:      strings.ToLower(medianBy),
+		Indicators:    indicators,
+		ZeroAsMissing: zeroAsMissing,
+		Sectors:       sectors,
+	}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		switch {
+		case part == "":
+			continue
+		case len(cfg.Strategies) > 0 && cfg.Strategies[len(cfg.Strategies)-1] == imputeDrop:
+			return cfg, errMsg("impute.drop_last", spec)
+		case part == imputeForwardFill || part == imputeMedian || part == imputeDrop:
+			cfg.Strategies = append(cfg.Strategies, part)
+		default:
+			return cfg, errMsg("impute.invalid_strategy", part)
+		}
+	}
+	if maxStaleness < 0 {
+		return cfg, errMsg("impute.invalid_staleness", strconv.Itoa(maxStaleness))
+	}
+	switch cfg.MedianBy {
+	case "period":
+	case neutralizeSector:
+		if sectors == nil {
+			return cfg, errMsg("transform.sectors_required")
+		}
+	default:
+		return cfg, errMsg("impute.invalid_median_by", medianBy)
+	}
+	return cfg, nil
+}
+
+// imputeKey - การเติมค่าในรูปที่เปรียบเทียบได้ (nil = ไม่ได้เติม)
+func imputeKey(cfg *ImputeConfig) []string {
+	if cfg == nil {
+		return nil
+	}
+	return []string{
+		strings.Join(cfg.Strategies, ","), strconv.Itoa(cfg.MaxStaleness), cfg.MedianBy,
+		strconv.FormatBool(cfg.Indicators), strconv.FormatBool(cfg.ZeroAsMissing),
+	}
+}
+
+// enabled - มีการเติมค่าหรือปรับค่าศูนย์หรือไม่
+func (c ImputeConfig) enabled() bool {
+	return len(c.Strategies) > 0 || c.ZeroAsMissing
+}
+
+// ExportImputeSummary - เขียนสรุปการเติมค่ารายคอลัมน์เป็น CSV
+func ExportImputeSummary(stats []ImputeStat, rows int, filename string) error {
+	columns := []string{"Column", "Missing", "MissingShare", "ForwardFilled", "MedianFilled", "StillMissing"}
+	records := make([][]string, 0, len(stats))
+	for _, s := range stats {
+		share := 0.0
+		if rows > 0 {
+			share = float64(s.Missing) / float64(rows)
+		}
+		records = append(records, []string{
+			s.Column, strconv.Itoa(s.Missing), strconv.FormatFloat(share, 'f', 4, 64),
+			strconv.Itoa(s.ForwardFill), strconv.Itoa(s.Median), strconv.Itoa(s.StillMissing),
+		})
+	}
+	return writeReportCSV(filename, columns, records)
+}
+
+// logImputeSummary - แสดงสรุปรวมและ feature ที่ขาดมากที่สุดไม่เกิน 10 คอลัมน์
+func logImputeSummary(stats []ImputeStat, rows int) {
+	var missing, ffill, median int
+	for _, s := range stats {
+		missing += s.Missing
+		ffill += s.ForwardFill
+		median += s.Median
+	}
+	logMsg("impute.summary", ffill+median, missing, ffill, median, missing-ffill-median)
+
+	sorted := append([]ImputeStat(nil), stats...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Missing > sorted[j].Missing })
+	for i, s := range sorted {
+		if i == 10 || s.Missing == 0 || rows == 0 {
+			break
+		}
+		logMsg("impute.column", s.Column, float64(s.Missing)*100/float64(rows), s.ForwardFill, s.Median)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestImputeMatrix(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       ImputeConfig
+		rows      []MatrixRow
+		want      []float64 // ค่าของ x หลังเติม
+		wantLabel []float64
+		wantStat  ImputeStat
+	}{
+		{
+			name:      "forward fill within staleness",
+			cfg:       ImputeConfig{Strategies: []string{imputeForwardFill}, MaxStaleness: 1},
+			rows:      []MatrixRow{testRow("A", "2024-03-29", 5, 1), testRow("A", "2024-06-28", nan, nan)},
+			want:      []float64{5, 5},
+			wantLabel: []float64{1, nan},
+			wantStat:  ImputeStat{Column: "x", Missing: 1, ForwardFill: 1},
+		},
+		{
+			name:      "forward fill stops at max staleness",
+			cfg:       ImputeConfig{Strategies: []string{imputeForwardFill}, MaxStaleness: 1},
+			rows:      []MatrixRow{testRow("A", "2024-03-29", 5, 1), testRow("A", "2024-09-30", nan, 1)},
+			want:      []float64{5, nan},
+			wantLabel: []float64{1, 1},
+			wantStat:  ImputeStat{Column: "x", Missing: 1, StillMissing: 1},
+		},
+		{
+			name:      "forward fill stays within a symbol",
+			cfg:       ImputeConfig{Strategies: []string{imputeForwardFill}, MaxStaleness: 4},
+			rows:      []MatrixRow{testRow("A", "2024-03-29", 5, 1), testRow("B", "2024-06-28", nan, 1)},
+			want:      []float64{5, nan},
+			wantLabel: []float64{1, 1},
+			wantStat:  ImputeStat{Column: "x", Missing: 1, StillMissing: 1},
+		},
+		{
+			// มัธยฐานของ 1, 3, 10 ในไตรมาสเดียวกัน ไตรมาสอื่นไม่นับ
+			name: "median of the same quarter",
+			cfg:  ImputeConfig{Strategies: []string{imputeMedian}},
+			rows: []MatrixRow{
+				testRow("A", "2024-03-29", 1, 1), testRow("B", "2024-03-29", 3, 1), testRow("C", "2024-03-29", 10, 1),
+				testRow("D", "2024-03-29", nan, 1), testRow("A", "2024-06-28", 1000, 1),
+			},
+			want:      []float64{1, 3, 10, 3, 1000},
+			wantLabel: []float64{1, 1, 1, 1, 1},
+			wantStat:  ImputeStat{Column: "x", Missing: 1, Median: 1},
+		},
+		{
+			name:      "forward fill before median",
+			cfg:       ImputeConfig{Strategies: []string{imputeForwardFill, imputeMedian}, MaxStaleness: 1},
+			rows:      []MatrixRow{testRow("A", "2024-03-29", 5, 1), testRow("A", "2024-06-28", nan, 1), testRow("B", "2024-06-28", 7, 1)},
+			want:      []float64{5, 5, 7},
+			wantLabel: []float64{1, 1, 1},
+			wantStat:  ImputeStat{Column: "x", Missing: 1, ForwardFill: 1},
+		},
+		{
+			name:      "drop rows still missing",
+			cfg:       ImputeConfig{Strategies: []string{imputeDrop}},
+			rows:      []MatrixRow{testRow("A", "2024-03-29", 5, 1), testRow("B", "2024-03-29", nan, 2)},
+			want:      []float64{5},
+			wantLabel: []float64{1},
+			wantStat:  ImputeStat{Column: "x", Missing: 1, StillMissing: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testMatrix([]string{"x"}, tt.rows...)
+			stats := ImputeMatrix(&m, tt.cfg)
+			if got := columnValues(m, 0); !seriesEqual(got, tt.want) {
+				t.Errorf("x = %v; want %v", got, tt.want)
+			}
+			if got := columnValues(m, 1); !seriesEqual(got, tt.wantLabel) {
+				t.Errorf("label = %v; want %v", got, tt.wantLabel)
+			}
+			if len(stats) != 1 || stats[0] != tt.wantStat {
+				t.Errorf("stats = %+v; want %+v", stats, tt.wantStat)
+			}
+			if dropped := len(tt.rows) - len(m.Rows); m.Dropped["missing_features"] != dropped {
+				t.Errorf("missing_features = %d; want %d", m.Dropped["missing_features"], dropped)
+			}
+		})
+	}
+}
+
+func TestImputeIndicators(t *testing.T) {
+	m := testMatrix([]string{"x", "z"},
+		testRow("A", "2024-03-29", 5, 1, 1),
+		testRow("A", "2024-06-28", nan, 2, 1),
+	)
+	ImputeMatrix(&m, ImputeConfig{Strategies: []string{imputeForwardFill}, MaxStaleness: 1, Indicators: true})
+
+	// เพิ่ม imputed_ ของทุก feature ไว้ก่อนคอลัมน์ label แม้ z จะไม่มีค่าที่เติม
+	names := make([]string, len(m.Columns))
+	for i, col := range m.Columns {
+		names[i] = col.Name
+	}
+	wantNames := []string{"x", "z", "imputed_x", "imputed_z", "label_y"}
+	if !slices.Equal(names, wantNames) {
+		t.Fatalf("columns = %v; want %v", names, wantNames)
+	}
+	tests := []struct {
+		name string
+		col  int
+		want []float64
+	}{
+		{"imputed_x", 2, []float64{0, 1}},
+		{"imputed_z", 3, []float64{0, 0}},
+		{"label_y", 4, []float64{1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := columnValues(m, tt.col); !seriesEqual(got, tt.want) {
+				t.Errorf("%s = %v; want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// tradingDaysPerYear - จำนวนวันทำการต่อปีโดยประมาณ ใช้ปรับความผันผวนเป็นรายปีและหาราคาสูงสุด/ต่ำสุด 52 สัปดาห์
+const tradingDaysPerYear = 250
+
+// priceSeries - ราคารายวันของหุ้นหนึ่งตัว เรียงจากเก่าไปใหม่ เฉพาะวันที่มีการซื้อขาย
+type priceSeries struct {
+	Symbol string
+	Dates  []time.Time
+	High   []float64
+	Low    []float64
+	Close  []float64
+	Volume []float64
+}
+
+// newPriceSeries - สร้าง series จากราคาปรับปรุงของหุ้นหนึ่งตัว
+// วันที่ไม่มีราคาปิด หรือไม่มีการซื้อขาย (หุ้นถูกพักการซื้อขาย) จะถูกตัดออก
+// ตัวชี้วัดจึงนับเป็นวันทำการที่ซื้อขายจริง ช่วงที่หายไปไม่ถูกเติมค่า
+func newPriceSeries(symbol string, prices []EODPriceBySymbol) priceSeries {
+	sorted := append([]EODPriceBySymbol(nil), prices...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date < sorted[j].Date })
+
+	s := priceSeries{Symbol: symbol}
+	for _, p := range sorted {
+		date, ok := parseAPIDate(p.Date)
+		if !ok || p.Close <= 0 || p.TotalVolume <= 0 {
+			continue
+		}
+		// วันที่ซ้ำใช้แถวล่าสุด
+		if n := len(s.Dates); n > 0 && s.Dates[n-1].Equal(date) {
+			s.Dates, s.High, s.Low, s.Close, s.Volume = s.Dates[:n-1], s.High[:n-1], s.Low[:n-1], s.Close[:n-1], s.Volume[:n-1]
+		}
+		high, low := p.High, p.Low
+		if high <= 0 || low <= 0 {
+			high, low = p.Close, p.Close
+		}
+		s.Dates = append(s.Dates, date)
+		s.High = append(s.High, high)
+		s.Low = append(s.Low, low)
+		s.Close = append(s.Close, p.Close)
+		s.Volume = append(s.Volume, p.TotalVolume)
+	}
+	return s
+}
+
+// indexAsOf - วันทำการสุดท้ายที่ไม่เกินวันที่กำหนด คืน -1 ถ้าไม่มี
+func (s priceSeries) indexAsOf(date time.Time) int {
+	return sort.Search(len(s.Dates), func(i int) bool { return s.Dates[i].After(date) }) - 1
+}
+
+// technicalIndicator - ตัวชี้วัดทางเทคนิคหนึ่งตัว Compute คืนค่าทุกวันของ series (NaN = ข้อมูลยังไม่พอ)
+type technicalIndicator struct {
+	Name    string
+	Compute func(s priceSeries) []float64
+}
+
+// technicalIndicators - ตัวชี้วัดทางเทคนิคทั้งหมด ส่งออกเป็นคอลัมน์ tech_<ชื่อ>
+// ค่าในแต่ละงวดคือค่า ณ วันที่ของราคาสิ้นไตรมาส (หรือวันทำการก่อนหน้าที่ใกล้ที่สุด)
+var technicalIndicators = []technicalIndicator{
+	{Name: "sma20", Compute: func(s priceSeries) []float64 { return sma(s.Close, 20) }},
+	{Name: "sma50", Compute: func(s priceSeries) []float64 { return sma(s.Close, 50) }},
+	{Name: "sma200", Compute: func(s priceSeries) []float64 { return sma(s.Close, 200) }},
+	{Name: "ema12", Compute: func(s priceSeries) []float64 { return ema(s.Close, 12) }},
+	{Name: "ema26", Compute: func(s priceSeries) []float64 { return ema(s.Close, 26) }},
+	{Name: "rsi14", Compute: func(s priceSeries) []float64 { return rsi(s.Close, 14) }},
+	// MACD (12, 26, 9): เส้น MACD, เส้นสัญญาณ และ histogram
+	{Name: "macd", Compute: func(s priceSeries) []float64 { line, _ := macd(s.Close, 12, 26, 9); return line }},
+	{Name: "macdSignal", Compute: func(s priceSeries) []float64 { _, signal := macd(s.Close, 12, 26, 9); return signal }},
+	{Name: "macdHist", Compute: func(s priceSeries) []float64 {
+		line, signal := macd(s.Close, 12, 26, 9)
+		return zipWith(line, signal, func(a, b float64) float64 { return a - b })
+	}},
+	// Bollinger Bands (20 วัน, 2 ส่วนเบี่ยงเบนมาตรฐาน) และตำแหน่งของราคาในแถบ (%B)
+	{Name: "bbUpper", Compute: func(s priceSeries) []float64 { upper, _ := bollinger(s.Close, 20, 2); return upper }},
+	{Name: "bbLower", Compute: func(s priceSeries) []float64 { _, lower := bollinger(s.Close, 20, 2); return lower }},
+	{Name: "bbPercentB", Compute: func(s priceSeries) []float64 {
+		upper, lower := bollinger(s.Close, 20, 2)
+		out := make([]float64, len(s.Close))
+		for i := range out {
+			out[i] = (s.Close[i] - lower[i]) / (upper[i] - lower[i])
+			if upper[i] == lower[i] {
+				out[i] = math.NaN()
+			}
+		}
+		return out
+	}},
+	{Name: "atr14", Compute: func(s priceSeries) []float64 { return atr(s, 14) }},
+	{Name: "roc20", Compute: func(s priceSeries) []float64 { return roc(s.Close, 20) }},
+	{Name: "roc60", Compute: func(s priceSeries) []float64 { return roc(s.Close, 60) }},
+	// ส่วนเบี่ยงเบนมาตรฐานของผลตอบแทนรายวัน (log) 20 วัน ปรับเป็นรายปี
+	{Name: "volatility20", Compute: func(s priceSeries) []float64 { return volatility(s.Close, 20) }},
+	// ปริมาณซื้อขายวันนี้ห่างจากค่าเฉลี่ย 20 วันกี่ส่วนเบี่ยงเบนมาตรฐาน
+	{Name: "volumeZ20", Compute: func(s priceSeries) []float64 { return zScore(s.Volume, 20) }},
+	// ราคาปิดเทียบราคาสูงสุด/ต่ำสุด 52 สัปดาห์ (0 = อยู่ที่จุดสูงสุด/ต่ำสุดพอดี)
+	{Name: "high52wDistance", Compute: func(s priceSeries) []float64 {
+		return zipWith(s.Close, rollingExtreme(s.High, tradingDaysPerYear, math.Max), func(c, h float64) float64 { return c/h - 1 })
+	}},
+	{Name: "low52wDistance", Compute: func(s priceSeries) []float64 {
+		return zipWith(s.Close, rollingExtreme(s.Low, tradingDaysPerYear, math.Min), func(c, l float64) float64 { return c/l - 1 })
+	}},
+	{Name: "obv", Compute: obv},
+}
+
+// maxSnapshotGap - วันซื้อขายล่าสุดต้องห่างจากวันที่ของงวดไม่เกินนี้ ถ้าเกิน (เช่น ถูกพักการซื้อขายนาน)
+// ถือว่าไม่มีค่าตัวชี้วัดของงวดนั้น เพื่อไม่ให้ใช้ค่าเก่าเกินไป
+const maxSnapshotGap = 10 * 24 * time.Hour
+
+// technicalColumnPrefix - prefix ของคอลัมน์ตัวชี้วัดทางเทคนิค เช่น tech_rsi14
+const technicalColumnPrefix = "tech_"
+
+// attachTechnicals - คำนวณตัวชี้วัดทางเทคนิคจากราคารายวันแล้วเก็บใน Technicals ของแต่ละงวด
+// ใช้ค่า ณ วันที่ของราคาสิ้นไตรมาส (ไม่ใช้ราคาหลังจากวันนั้น) งวดที่ไม่มีราคาใช้วันสิ้นไตรมาสปฏิทิน
+func attachTechnicals(data []FinancialData, history []EODPriceBySymbol) {
+	bySymbol := make(map[string][]EODPriceBySymbol)
+	for _, p := range history {
+		bySymbol[p.Symbol] = append(bySymbol[p.Symbol], p)
+	}
+
+	type computed struct {
+		series priceSeries
+		values map[string][]float64
+	}
+	cache := make(map[string]computed)
+
+	for i := range data {
+		symbol := data[i].Symbol
+		c, ok := cache[symbol]
+		if !ok {
+			c = computed{series: newPriceSeries(symbol, bySymbol[symbol]), values: make(map[string][]float64)}
+			for _, indicator := range technicalIndicators {
+				c.values[indicator.Name] = indicator.Compute(c.series)
+			}
+			cache[symbol] = c
+		}
+
+		data[i].Technicals = nil
+		date := snapshotDate(data[i])
+		idx := c.series.indexAsOf(date)
+		if idx < 0 || date.Sub(c.series.Dates[idx]) > maxSnapshotGap {
+			continue
+		}
+		technicals := make(map[string]float64)
+		for _, indicator := range technicalIndicators {
+			if v := c.values[indicator.Name][idx]; !math.IsNaN(v) && !math.IsInf(v, 0) {
+				technicals[indicator.Name] = v
+			}
+		}
+		data[i].Technicals = technicals
+	}
+}
+
+// snapshotDate - วันที่ของราคาสิ้นไตรมาสที่แนบกับงวด หรือวันสิ้นไตรมาสปฏิทินถ้าไม่มีราคา
+func snapshotDate(item FinancialData) time.Time {
+	if s, ok := item.PriceData["price_date"].(string); ok {
+		if t, ok := parseAPIDate(s); ok {
+			return t
+		}
+	}
+	return calendarPeriodOf(item).EndDate()
+}
+
+// nanSeries - slice ยาว n ที่ทุกค่าเป็น NaN
+func nanSeries(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
+
+func zipWith(a, b []float64, fn func(x, y float64) float64) []float64 {
+	out := make([]float64, len(a))
+	for i := range out {
+		out[i] = fn(a[i], b[i])
+	}
+	return out
+}
+
+// sma - ค่าเฉลี่ยเคลื่อนที่อย่างง่าย n วัน
+func sma(x []float64, n int) []float64 {
+	out := nanSeries(len(x))
+	var sum float64
+	for i, v := range x {
+		sum += v
+		if i >= n {
+			sum -= x[i-n]
+		}
+		if i >= n-1 {
+			out[i] = sum / float64(n)
+		}
+	}
+	return out
+}
+
+// ema - ค่าเฉลี่ยเคลื่อนที่แบบ exponential n วัน เริ่มจาก SMA ของ n ค่าแรกที่ไม่ใช่ NaN
+func ema(x []float64, n int) []float64 {
+	out := nanSeries(len(x))
+	start := 0
+	for start < len(x) && math.IsNaN(x[start]) {
+		start++
+	}
+	if len(x)-start < n {
+		return out
+	}
+	alpha := 2 / float64(n+1)
+	var seed float64
+	for _, v := range x[start : start+n] {
+		seed += v
+	}
+	prev := seed / float64(n)
+	out[start+n-1] = prev
+	for i := start + n; i < len(x); i++ {
+		prev = alpha*x[i] + (1-alpha)*prev
+		out[i] = prev
+	}
+	return out
+}
+
+// rsi - Relative Strength Index แบบ Wilder
+func rsi(x []float64, n int) []float64 {
+	out := nanSeries(len(x))
+	if len(x) <= n {
+		return out
+	}
+	var gain, loss float64
+	for i := 1; i <= n; i++ {
+		change := x[i] - x[i-1]
+		gain += math.Max(change, 0)
+		loss += math.Max(-change, 0)
+	}
+	gain /= float64(n)
+	loss /= float64(n)
+	out[n] = rsiValue(gain, loss)
+	for i := n + 1; i < len(x); i++ {
+		change := x[i] - x[i-1]
+		gain = (gain*float64(n-1) + math.Max(change, 0)) / float64(n)
+		loss = (loss*float64(n-1) + math.Max(-change, 0)) / float64(n)
+		out[i] = rsiValue(gain, loss)
+	}
+	return out
+}
+
+func rsiValue(gain, loss float64) float64 {
+	if loss == 0 {
+		return 100
+	}
+	return 100 - 100/(1+gain/loss)
+}
+
+// macd - เส้น MACD (EMA เร็ว - EMA ช้า) และเส้นสัญญาณ (EMA ของเส้น MACD)
+func macd(x []float64, fast, slow, signal int) (line, signalLine []float64) {
+	line = zipWith(ema(x, fast), ema(x, slow), func(a, b float64) float64 { return a - b })
+	return line, ema(line, signal)
+}
+
+// bollinger - แถบบนและล่าง = SMA ± k × ส่วนเบี่ยงเบนมาตรฐาน n วัน
+func bollinger(x []float64, n int, k float64) (upper, lower []float64) {
+	mid := sma(x, n)
+	sd := rollingStd(x, n)
+	upper = zipWith(mid, sd, func(m, s float64) float64 { return m + k*s })
+	lower = zipWith(mid, sd, func(m, s float64) float64 { return m - k*s })
+	return upper, lower
+}
+
+// rollingStd - ส่วนเบี่ยงเบนมาตรฐาน (population) ของ n ค่าล่าสุด
+func rollingStd(x []float64, n int) []float64 {
+	out := nanSeries(len(x))
+	for i := n - 1; i < len(x); i++ {
+		window := x[i-n+1 : i+1]
+		var mean float64
+		for _, v := range window {
+			mean += v
+		}
+		mean /= float64(n)
+		var ss float64
+		for _, v := range window {
+			ss += (v - mean) * (v - mean)
+		}
+		out[i] = math.Sqrt(ss / float64(n))
+	}
+	return out
+}
+
+// atr - Average True Range แบบ Wilder
+func atr(s priceSeries, n int) []float64 {
+	out := nanSeries(len(s.Close))
+	if len(s.Close) < n {
+		return out
+	}
+	tr := make([]float64, len(s.Close))
+	for i := range tr {
+		tr[i] = s.High[i] - s.Low[i]
+		if i > 0 {
+			tr[i] = math.Max(tr[i], math.Max(math.Abs(s.High[i]-s.Close[i-1]), math.Abs(s.Low[i]-s.Close[i-1])))
+		}
+	}
+	var prev float64
+	for _, v := range tr[:n] {
+		prev += v
+	}
+	prev /= float64(n)
+	out[n-1] = prev
+	for i := n; i < len(tr); i++ {
+		prev = (prev*float64(n-1) + tr[i]) / float64(n)
+		out[i] = prev
+	}
+	return out
+}
+
+// roc - อัตราการเปลี่ยนแปลงของราคาเทียบกับ n วันทำการก่อน
+func roc(x []float64, n int) []float64 {
+	out := nanSeries(len(x))
+	for i := n; i < len(x); i++ {
+		out[i] = x[i]/x[i-n] - 1
+	}
+	return out
+}
+
+// volatility - ส่วนเบี่ยงเบนมาตรฐานของผลตอบแทนรายวันแบบ log n วัน คูณ √tradingDaysPerYear
+func volatility(x []float64, n int) []float64 {
+	returns := nanSeries(len(x))
+	for i := 1; i < len(x); i++ {
+		returns[i] = math.Log(x[i] / x[i-1])
+	}
+	out := nanSeries(len(x))
+	if len(x) <= n {
+		return out
+	}
+	sd := rollingStd(returns[1:], n)
+	for i, v := range sd {
+		out[i+1] = v * math.Sqrt(tradingDaysPerYear)
+	}
+	return out
+}
+
+// zScore - ค่าวันนี้ห่างจากค่าเฉลี่ย n วันล่าสุด (รวมวันนี้) กี่ส่วนเบี่ยงเบนมาตรฐาน
+func zScore(x []float64, n int) []float64 {
+	mean := sma(x, n)
+	sd := rollingStd(x, n)
+	out := nanSeries(len(x))
+	for i := range x {
+		if sd[i] > 0 {
+			out[i] = (x[i] - mean[i]) / sd[i]
+		}
+	}
+	return out
+}
+
+// rollingExtreme - ค่าสูงสุดหรือต่ำสุดของ n วันล่าสุด
+func rollingExtreme(x []float64, n int, pick func(a, b float64) float64) []float64 {
+	out := nanSeries(len(x))
+	for i := n - 1; i < len(x); i++ {
+		v := x[i]
+		for j := i - n + 1; j < i; j++ {
+			v = pick(v, x[j])
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// obv - On-Balance Volume สะสมปริมาณซื้อขายตามทิศทางของราคาปิด
+func obv(s priceSeries) []float64 {
+	out := make([]float64, len(s.Close))
+	for i := 1; i < len(out); i++ {
+		out[i] = out[i-1]
+		switch {
+		case s.Close[i] > s.Close[i-1]:
+			out[i] += s.Volume[i]
+		case s.Close[i] < s.Close[i-1]:
+			out[i] -= s.Volume[i]
+		}
+	}
+	return out
+}
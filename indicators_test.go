@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+var nan = math.NaN()
+
+// seriesEqual - เทียบ series ที่มี NaN (NaN ต้องอยู่ตำแหน่งเดียวกัน)
+func seriesEqual(got, want []float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.IsNaN(want[i]) != math.IsNaN(got[i]) || (!math.IsNaN(want[i]) && !approxEqual(got[i], want[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRSI(t *testing.T) {
+	tests := []struct {
+		name  string
+		close []float64
+		n     int
+		want  []float64
+	}{
+		// เฉลี่ยเริ่มต้น gain 0.5 loss 0.5 = 50 จากนั้น gain (0.5+2)/2 = 1.25 loss 0.5/2 = 0.25 -> RS 5
+		{"wilder smoothing", []float64{10, 11, 10, 12}, 2, []float64{nan, nan, 50, 100 - 100.0/6}},
+		{"no losses", []float64{1, 2, 3}, 2, []float64{nan, nan, 100}},
+		{"too short", []float64{1, 2}, 2, []float64{nan, nan}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rsi(tt.close, tt.n); !seriesEqual(got, tt.want) {
+				t.Errorf("rsi = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMACD(t *testing.T) {
+	// EMA2: 1.5, 2.5, 3.5, 6.5 EMA3: 2, 3, 5.5
+	// เส้น MACD 0.5, 0.5, 1 เส้นสัญญาณเริ่มจาก (0.5+0.5)/2 แล้ว 2/3*1 + 1/3*0.5
+	close := []float64{1, 2, 3, 4, 8}
+	line, signal := macd(close, 2, 3, 2)
+	tests := []struct {
+		name      string
+		got, want []float64
+	}{
+		{"line", line, []float64{nan, nan, 0.5, 0.5, 1}},
+		{"signal", signal, []float64{nan, nan, nan, 0.5, 5.0 / 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !seriesEqual(tt.got, tt.want) {
+				t.Errorf("macd %s = %v; want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestATR(t *testing.T) {
+	// true range 2, 2, 4 (gap ขึ้นจากราคาปิด 11 ไป high 15), 1.5 (low 13 ห่างจากราคาปิด 14.5)
+	s := priceSeries{
+		High:  []float64{11, 12, 15, 14},
+		Low:   []float64{9, 10, 14, 13},
+		Close: []float64{10, 11, 14.5, 13.5},
+	}
+	tests := []struct {
+		name string
+		n    int
+		want []float64
+	}{
+		{"wilder smoothing", 2, []float64{nan, 2, 3, 2.25}},
+		{"too short", 5, []float64{nan, nan, nan, nan}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := atr(s, tt.n); !seriesEqual(got, tt.want) {
+				t.Errorf("atr = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// labelColumnPrefix - prefix ของคอลัมน์เป้าหมาย (label) เช่น label_fwdReturn60
+const labelColumnPrefix = "label_"
+
+// defaultLabelHorizons - ระยะเวลาถือครองเริ่มต้น (วันทำการ) ประมาณ 1, 3, 6 และ 12 เดือน
+var defaultLabelHorizons = []int{20, 60, 120, 250}
+
+// labelHorizons - ระยะเวลาที่ใช้สร้างคอลัมน์ label_* ในปัจจุบัน เปลี่ยนด้วย setLabelHorizons
+var labelHorizons = defaultLabelHorizons
+
+// labelKinds - ชนิดของเป้าหมายต่อหนึ่งระยะเวลา ชื่อคอลัมน์คือ label_<ชนิด><วัน>
+//
+//	fwdReturn       ผลตอบแทนจากราคาปรับปรุง (สะท้อนเงินปันผลและการเพิ่มทุน) จากวันที่ราคาสิ้นไตรมาสไปอีก N วันทำการ
+//	excessReturn    fwdReturn - ผลตอบแทนของ benchmark ในช่วงเดียวกัน
+//	beatsBenchmark  1 ถ้า excessReturn > 0 มิฉะนั้น 0
+//	topQuintile     1 ถ้า fwdReturn อยู่ใน 20% บนสุดของหุ้นทั้งหมดที่วันที่ snapshot เดียวกัน มิฉะนั้น 0
+//	sign            เครื่องหมายของ fwdReturn (-1, 0, 1)
+var labelKinds = []string{"fwdReturn", "excessReturn", "beatsBenchmark", "topQuintile", "sign"}
+
+// LabelConfig - การตั้งค่าการสร้างเป้าหมาย
+// Benchmark ว่าง = ใช้ค่าเฉลี่ยแบบถ่วงน้ำหนักเท่ากันของหุ้นทุกตัวที่วันที่ snapshot เดียวกัน
+type LabelConfig struct {
+	Horizons  []int
+	Benchmark string
+}
+
+// minQuintileSize - จำนวนหุ้นขั้นต่ำในวันที่ snapshot เดียวกันที่จะจัดกลุ่ม quintile ได้
+const minQuintileSize = 5
+
+// labelName - ชื่อ label ของชนิดและระยะเวลา
+func labelName(kind string, horizon int) string {
+	return fmt.Sprintf("%s%d", kind, horizon)
+}
+
+// setLabelHorizons - เปลี่ยนระยะเวลาของ label แล้วสร้างรายการคอลัมน์ใหม่
+// ต้องเรียกก่อนตรวจ -columns เพราะชื่อคอลัมน์ label_* ขึ้นกับระยะเวลา
+func setLabelHorizons(horizons []int) {
+	labelHorizons = horizons
+	exportColumns, exportColumnsByName = buildExportColumns()
+}
+
+// parseLabelHorizons - อ่านรายการระยะเวลา เช่น "20,60,120,250"
+func parseLabelHorizons(spec string) ([]int, error) {
+	var horizons []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n <= 0 {
+			return nil, errMsg("label.invalid_horizon", part)
+		}
+		if !seen[n] {
+			seen[n] = true
+			horizons = append(horizons, n)
+		}
+	}
+	if len(horizons) == 0 {
+		return nil, errMsg("label.invalid_horizon", spec)
+	}
+	sort.Ints(horizons)
+	return horizons, nil
+}
+
+// labelHeader - หัวคอลัมน์ของ label_* ตามภาษาปัจจุบัน
+func labelHeader(col string) (string, bool) {
+	name, ok := strings.CutPrefix(col, labelColumnPrefix)
+	if !ok {
+		return "", false
+	}
+	for _, kind := range labelKinds {
+		if days, ok := strings.CutPrefix(name, kind); ok {
+			if n, err := strconv.Atoi(days); err == nil {
+				return T("label.header."+kind, n), true
+			}
+		}
+	}
+	return "", false
+}
+
+// tradingCalendar - วันทำการของตลาด (วันที่มีหุ้นอย่างน้อยหนึ่งตัวซื้อขาย) เรียงจากเก่าไปใหม่
+// ใช้นับระยะเวลาถือครองเพื่อไม่ให้ช่วงที่หุ้นตัวหนึ่งถูกพักการซื้อขายทำให้ระยะเวลายืดออก
+type tradingCalendar []time.Time
+
+func newTradingCalendar(series map[string]priceSeries) tradingCalendar {
+	seen := make(map[time.Time]bool)
+	var days tradingCalendar
+	for _, s := range series {
+		for _, d := range s.Dates {
+			if !seen[d] {
+				seen[d] = true
+				days = append(days, d)
+			}
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+// after - วันทำการที่ถัดจากวันที่ date ไป n วัน คืน false ถ้าเกินข้อมูลที่มี (ยังไม่รู้ผล)
+func (c tradingCalendar) after(date time.Time, n int) (time.Time, bool) {
+	idx := sort.Search(len(c), func(i int) bool { return c[i].After(date) }) - 1
+	if idx < 0 || idx+n >= len(c) {
+		return time.Time{}, false
+	}
+	return c[idx+n], true
+}
+
+// closeAsOf - ราคาปิดล่าสุดไม่เกินวันที่กำหนด และต้องไม่เก่ากว่า maxSnapshotGap
+func (s priceSeries) closeAsOf(date time.Time) (float64, bool) {
+	idx := s.indexAsOf(date)
+	if idx < 0 || date.Sub(s.Dates[idx]) > maxSnapshotGap {
+		return 0, false
+	}
+	return s.Close[idx], true
+}
+
+// forwardReturn - ผลตอบแทนจากราคาปิด ณ วันเริ่มถึงวันสิ้นสุด
+func (s priceSeries) forwardReturn(start, end time.Time) (float64, bool) {
+	from, ok := s.closeAsOf(start)
+	if !ok {
+		return 0, false
+	}
+	to, ok := s.closeAsOf(end)
+	if !ok {
+		return 0, false
+	}
+	return to/from - 1, true
+}
+
+// attachLabels - สร้างเป้าหมายสำหรับทุกงวดแล้วเก็บใน Labels
+// ทุกค่าใช้เฉพาะราคาจนถึงวันสิ้นสุดระยะเวลาถือครอง งวดที่ระยะเวลายังไม่ครบจะไม่มี label
+func attachLabels(data []FinancialData, history []EODPriceBySymbol, cfg LabelConfig) {
+	bySymbol := make(map[string][]EODPriceBySymbol)
+	for _, p := range history {
+		bySymbol[p.Symbol] = append(bySymbol[p.Symbol], p)
+	}
+	series := make(map[string]priceSeries, len(bySymbol))
+	for symbol, prices := range bySymbol {
+		series[symbol] = newPriceSeries(symbol, prices)
+	}
+	calendar := newTradingCalendar(series)
+	benchmark, hasBenchmark := series[cfg.Benchmark]
+
+	// กลุ่มของงวดที่มีวันที่ snapshot เดียวกัน (จึงมีช่วงถือครองเดียวกัน) สำหรับ benchmark แบบเฉลี่ยและการจัด quintile
+	type groupKey struct {
+		Date    time.Time
+		Horizon int
+	}
+	groups := make(map[groupKey][]int)
+
+	for i := range data {
+		data[i].Labels = nil
+		s, ok := series[data[i].Symbol]
+		if !ok {
+			continue
+		}
+		start := snapshotDate(data[i])
+		labels := make(map[string]float64)
+		for _, h := range cfg.Horizons {
+			end, ok := calendar.after(start, h)
+			if !ok {
+				continue
+			}
+			ret, ok := s.forwardReturn(start, end)
+			if !ok {
+				continue
+			}
+			labels[labelName("fwdReturn", h)] = ret
+			labels[labelName("sign", h)] = sign(ret)
+			if hasBenchmark {
+				if bench, ok := benchmark.forwardReturn(start, end); ok {
+					setExcessReturn(labels, h, ret-bench)
+				}
+			}
+			key := groupKey{start, h}
+			groups[key] = append(groups[key], i)
+		}
+		data[i].Labels = labels
+	}
+
+	for key, members := range groups {
+		// หุ้นที่มีงบหลายประเภทมีหลายแถวแต่ผลตอบแทนเดียวกัน นับหุ้นละหนึ่งค่า
+		rowsOf := make(map[string][]int)
+		var symbols []string
+		for _, idx := range members {
+			symbol := data[idx].Symbol
+			if _, ok := rowsOf[symbol]; !ok {
+				symbols = append(symbols, symbol)
+			}
+			rowsOf[symbol] = append(rowsOf[symbol], idx)
+		}
+		sort.Strings(symbols)
+		returns := make([]float64, len(symbols))
+		var mean float64
+		for j, symbol := range symbols {
+			returns[j] = data[rowsOf[symbol][0]].Labels[labelName("fwdReturn", key.Horizon)]
+			mean += returns[j]
+		}
+		mean /= float64(len(symbols))
+
+		if cfg.Benchmark == "" {
+			for j, symbol := range symbols {
+				for _, idx := range rowsOf[symbol] {
+					setExcessReturn(data[idx].Labels, key.Horizon, returns[j]-mean)
+				}
+			}
+		}
+
+		if len(symbols) < minQuintileSize {
+			continue
+		}
+		sorted := append([]float64(nil), returns...)
+		sort.Float64s(sorted)
+		// ค่าขั้นต่ำของกลุ่ม 20% บนสุด (เท่ากับเกณฑ์ถือว่าอยู่ในกลุ่ม)
+		cutoff := sorted[len(sorted)-int(math.Ceil(float64(len(sorted))/5))]
+		for j, symbol := range symbols {
+			top := 0.0
+			if returns[j] >= cutoff {
+				top = 1
+			}
+			for _, idx := range rowsOf[symbol] {
+				data[idx].Labels[labelName("topQuintile", key.Horizon)] = top
+			}
+		}
+	}
+}
+
+func setExcessReturn(labels map[string]float64, horizon int, excess float64) {
+	labels[labelName("excessReturn", horizon)] = excess
+	beats := 0.0
+	if excess > 0 {
+		beats = 1
+	}
+	labels[labelName("beatsBenchmark", horizon)] = beats
+}
+
+func sign(v float64) float64 {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestAttachLabels(t *testing.T) {
+	// ทุกหุ้นปิด 100 ในวันที่ 1-2 ม.ค. แล้วเปลี่ยนในวันที่ 3 ม.ค. (ผลตอบแทน 1 วันทำการจากวันที่ 2)
+	day3 := map[string]float64{"A": 110, "B": 105, "C": 100, "D": 95, "E": 120, "F": 50}
+	dates := []string{"2024-01-01", "2024-01-02", "2024-01-03", "2024-01-04"}
+	var history []EODPriceBySymbol
+	for symbol, close3 := range day3 {
+		closes := []float64{100, 100, close3, close3}
+		if symbol == "F" {
+			closes = []float64{50, 50, 50, 55}
+		}
+		for i, c := range closes {
+			history = append(history, EODPriceBySymbol{Date: dates[i], Symbol: symbol, High: c, Low: c, Close: c, TotalVolume: 1000})
+		}
+	}
+
+	snapshot := func(symbol, statementType, date string) FinancialData {
+		return FinancialData{Symbol: symbol, FinancialStatementType: statementType, PriceData: map[string]interface{}{"price_date": date}}
+	}
+	// A มีงบสองประเภท ต้องนับเป็นหุ้นเดียวในค่าเฉลี่ย: (0.10+0.05+0-0.05+0.20)/5 = 0.06
+	data := []FinancialData{
+		snapshot("A", "C", "2024-01-02"),
+		snapshot("A", "U", "2024-01-02"),
+		snapshot("B", "C", "2024-01-02"),
+		snapshot("C", "C", "2024-01-02"),
+		snapshot("D", "C", "2024-01-02"),
+		snapshot("E", "C", "2024-01-02"),
+		snapshot("F", "C", "2024-01-03"), // วันที่ snapshot ต่างกัน จึงอยู่คนละกลุ่ม
+		snapshot("B", "C", "2024-01-04"), // ระยะเวลาถือครองเกินข้อมูลที่มี
+	}
+
+	tests := []struct {
+		name      string
+		benchmark string
+		row       int
+		label     string
+		want      float64
+		wantOK    bool
+	}{
+		{"forward return from snapshot date", "", 0, "fwdReturn1", 0.10, true},
+		{"excess over equal-weight mean", "", 0, "excessReturn1", 0.04, true},
+		{"second statement type gets the same label", "", 1, "excessReturn1", 0.04, true},
+		{"below the mean", "", 2, "beatsBenchmark1", 0, true},
+		{"top quintile", "", 5, "topQuintile1", 1, true},
+		{"outside top quintile", "", 0, "topQuintile1", 0, true},
+		{"sign of a flat return", "", 3, "sign1", 0, true},
+		{"single-symbol date has zero excess", "", 6, "excessReturn1", 0, true},
+		{"single-symbol date has no quintile", "", 6, "topQuintile1", 0, false},
+		{"horizon beyond the data", "", 7, "fwdReturn1", 0, false},
+		{"explicit benchmark", "E", 0, "excessReturn1", -0.10, true},
+		{"explicit benchmark beaten by nobody", "E", 5, "beatsBenchmark1", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := append([]FinancialData(nil), data...)
+			attachLabels(rows, history, LabelConfig{Horizons: []int{1}, Benchmark: tt.benchmark})
+			got, ok := rows[tt.row].Labels[tt.label]
+			if ok != tt.wantOK || (ok && !approxEqual(got, tt.want)) {
+				t.Errorf("row %d %s = %v, %v; want %v, %v", tt.row, tt.label, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Lineage - ที่มาของงบการเงินหนึ่งงวดและราคา ณ สิ้นไตรมาสที่แนบมา
+// ส่งออกเป็นคอลัมน์ Lineage* ได้เมื่อเลือกด้วย -columns (ไม่อยู่ในคอลัมน์เริ่มต้น)
+type Lineage struct {
+	RunID        string `json:"runId"`
+	Endpoint     string `json:"endpoint"`
+	Params       string `json:"params"`       // query string ที่ส่งไป (api-key อยู่ใน header จึงไม่ถูกบันทึก)
+	FetchedAt    string `json:"fetchedAt"`    // เวลาที่ได้รับ response (RFC 3339, UTC)
+	ResponseHash string `json:"responseHash"` // SHA-256 ของ response body ทั้งก้อน
+
+	PriceEndpoint      string `json:"priceEndpoint,omitempty"`
+	PriceParams        string `json:"priceParams,omitempty"`
+	PriceFetchedAt     string `json:"priceFetchedAt,omitempty"`
+	PriceResponseHash  string `json:"priceResponseHash,omitempty"`
+	PriceRequestedDate string `json:"priceRequestedDate,omitempty"` // วันที่ที่ขอราคา
+	PriceDate          string `json:"priceDate,omitempty"`          // วันที่ของราคาที่ได้มาจริง
+	PriceExactDate     bool   `json:"priceExactDate"`               // ราคาเป็นของวันสิ้นไตรมาสปฏิทินพอดีหรือไม่
+}
+
+// currentRunID - รหัสการรันที่บันทึกใน lineage ตั้งค่าจาก command line
+var currentRunID string
+
+// newLineage - lineage ของ response หนึ่งครั้ง
+func newLineage(endpoint string, params url.Values, body []byte, fetchedAt time.Time) Lineage {
+	return Lineage{
+		RunID:        currentRunID,
+		Endpoint:     endpoint,
+		Params:       params.Encode(),
+		FetchedAt:    fetchedAt.UTC().Format(time.RFC3339),
+		ResponseHash: responseHash(body),
+	}
+}
+
+// withPrice - เพิ่มที่มาของราคา ณ สิ้นไตรมาส
+// priceDate ว่างหมายถึง API ไม่มีราคาให้ในช่วงที่ขอ
+func (l Lineage) withPrice(price Lineage, requestedDate, priceDate string, quarterEnd time.Time) Lineage {
+	l.PriceEndpoint = price.Endpoint
+	l.PriceParams = price.Params
+	l.PriceFetchedAt = price.FetchedAt
+	l.PriceResponseHash = price.ResponseHash
+	l.PriceRequestedDate = requestedDate
+	l.PriceDate = priceDate
+	l.PriceExactDate = false
+	if t, ok := parseAPIDate(priceDate); ok {
+		l.PriceExactDate = t.Format("2006-01-02") == quarterEnd.Format("2006-01-02")
+	}
+	return l
+}
+
+func responseHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// isLineageColumn - คอลัมน์ lineage เปลี่ยนทุกครั้งที่ดึงข้อมูล จึงไม่นำมาเปรียบเทียบใน diff
+func isLineageColumn(col string) bool {
+	return strings.HasPrefix(col, "Lineage")
+}
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// วิธีของโมเดลเชิงเส้น
+const (
+	linearOLS        = "ols"
+	linearRidge      = "ridge"
+	linearLasso      = "lasso"
+	linearElasticNet = "elasticnet"
+	linearLogistic   = "logistic"
+)
+
+// LinearConfig - การตั้งค่าการฝึกโมเดลเชิงเส้น
+// ค่าปรับ (penalty) คือ Lambda * (Alpha*|β|₁ + (1-Alpha)/2*|β|²) บนสัมประสิทธิ์ของ feature ที่ standardize แล้ว
+// ridge ใช้ Alpha = 0, lasso ใช้ Alpha = 1, logistic ใช้ Alpha ตามที่กำหนด
+type LinearConfig struct {
+	Method    string  `json:"method"`
+	Lambda    float64 `json:"lambda"`
+	Alpha     float64 `json:"alpha"`
+	MaxIter   int     `json:"max_iter"`
+	Tolerance float64 `json:"tolerance"`
+}
+
+// LinearModel - โมเดลเชิงเส้นที่ฝึกแล้ว
+// Coefficients เป็นของ feature ที่ standardize ด้วย Means/Stds ของชุด train (เทียบขนาดกันได้)
+// ค่าที่ขาด (NaN) ถือเป็นค่าเฉลี่ย คือ 0 หลัง standardize ส่วน feature ที่ไม่แปรผัน (Std = 0) ไม่ถูกใช้
+type LinearModel struct {
+	Config       LinearConfig `json:"config"`
+	Means        []float64    `json:"means"`
+	Stds         []float64    `json:"stds"`
+	Coefficients []float64    `json:"coefficients"`
+	Intercept    float64      `json:"intercept"`
+	Iterations   int          `json:"iterations"`
+}
+
+// standardized - ค่าของ feature j หลัง standardize
+func (m *LinearModel) standardized(j int, v float64) float64 {
+	if math.IsNaN(v) || m.Stds[j] == 0 {
+		return 0
+	}
+	return (v - m.Means[j]) / m.Stds[j]
+}
+
+// Predict - ค่าที่ทำนาย (logistic คืนความน่าจะเป็นของคลาส 1)
+func (m *LinearModel) Predict(x []float64) float64 {
+	score := m.Intercept
+	for j, b := range m.Coefficients {
+		if b != 0 {
+			score += b * m.standardized(j, x[j])
+		}
+	}
+	if m.Config.Method == linearLogistic {
+		return sigmoid(score)
+	}
+	return score
+}
+
+// RawCoefficients - สัมประสิทธิ์และจุดตัดในหน่วยเดิมของ feature
+func (m *LinearModel) RawCoefficients() ([]float64, float64) {
+	raw := make([]float64, len(m.Coefficients))
+	intercept := m.Intercept
+	for j, b := range m.Coefficients {
+		if m.Stds[j] == 0 {
+			continue
+		}
+		raw[j] = b / m.Stds[j]
+		intercept -= raw[j] * m.Means[j]
+	}
+	return raw, intercept
+}
+
+// FitLinear - ฝึกโมเดลเชิงเส้นจาก x (แถว × feature, NaN = ไม่มีค่า) และเป้าหมาย y
+// logistic ต้องการ y เป็น 0 หรือ 1
+func FitLinear(x [][]float64, y []float64, cfg LinearConfig) (*LinearModel, error) {
+	switch cfg.Method {
+	case linearOLS:
+		cfg.Lambda, cfg.Alpha = 0, 0
+	case linearRidge:
+		cfg.Alpha = 0
+	case linearLasso:
+		cfg.Alpha = 1
+	case linearElasticNet, linearLogistic:
+	default:
+		return nil, errMsg("model.unknown_method", cfg.Method)
+	}
+	if cfg.Lambda < 0 || cfg.Alpha < 0 || cfg.Alpha > 1 {
+		return nil, errMsg("model.invalid_penalty", cfg.Lambda, cfg.Alpha)
+	}
+	if len(y) == 0 {
+		return nil, errMsg("model.no_rows")
+	}
+	if cfg.Method == linearLogistic && !isBinary(y) {
+		return nil, errMsg("model.binary_target")
+	}
+
+	m := &LinearModel{Config: cfg}
+	z := m.standardize(x)
+	switch cfg.Method {
+	case linearOLS, linearRidge:
+		m.fitRidge(z, y)
+	case linearLasso, linearElasticNet:
+		m.fitCoordinateDescent(z, y)
+	case linearLogistic:
+		m.fitLogistic(z, y)
+	}
+	return m, nil
+}
+
+// standardize - หาค่าเฉลี่ยและส่วนเบี่ยงเบนมาตรฐานจากค่าที่มีของแต่ละ feature แล้วคืนค่าที่ standardize แล้ว
+// ค่าที่ขาดเป็น 0 ทำให้ทุกคอลัมน์มีค่าเฉลี่ยเป็นศูนย์พอดี
+func (m *LinearModel) standardize(x [][]float64) [][]float64 {
+	p := 0
+	if len(x) > 0 {
+		p = len(x[0])
+	}
+	m.Means = make([]float64, p)
+	m.Stds = make([]float64, p)
+	m.Coefficients = make([]float64, p)
+	counts := make([]float64, p)
+	for _, row := range x {
+		for j, v := range row {
+			if !math.IsNaN(v) {
+				m.Means[j] += v
+				counts[j]++
+			}
+		}
+	}
+	for j := range m.Means {
+		if counts[j] > 0 {
+			m.Means[j] /= counts[j]
+		}
+	}
+	for _, row := range x {
+		for j, v := range row {
+			if !math.IsNaN(v) {
+				m.Stds[j] += (v - m.Means[j]) * (v - m.Means[j])
+			}
+		}
+	}
+	for j := range m.Stds {
+		if counts[j] > 0 {
+			m.Stds[j] = math.Sqrt(m.Stds[j] / counts[j])
+		}
+		// ค่าคงที่ (รวมค่าที่ต่างกันแค่ความคลาดเคลื่อนของทศนิยม) ใช้อธิบายอะไรไม่ได้
+		if m.Stds[j] <= 1e-12*math.Max(1, math.Abs(m.Means[j])) {
+			m.Stds[j] = 0
+		}
+	}
+
+	z := make([][]float64, len(x))
+	for i, row := range x {
+		z[i] = make([]float64, p)
+		for j, v := range row {
+			z[i][j] = m.standardized(j, v)
+		}
+	}
+	return z
+}
+
+// fitRidge - แก้สมการ (ZᵀZ/n + λI)β = Zᵀ(y-ȳ)/n ด้วย Cholesky
+// OLS ใช้ λ เล็กมากเพื่อให้แก้ได้เมื่อ feature ซ้ำซ้อนกัน
+func (m *LinearModel) fitRidge(z [][]float64, y []float64) {
+	n, p := float64(len(y)), len(m.Coefficients)
+	mean := meanOf(y)
+	m.Intercept = mean
+
+	active := m.activeFeatures()
+	k := len(active)
+	a := make([][]float64, k)
+	b := make([]float64, k)
+	for i := range a {
+		a[i] = make([]float64, k)
+	}
+	for r, row := range z {
+		for i, ji := range active {
+			b[i] += row[ji] * (y[r] - mean)
+			for j := 0; j <= i; j++ {
+				a[i][j] += row[ji] * row[active[j]]
+			}
+		}
+	}
+	ridge := math.Max(m.Config.Lambda, 1e-8)
+	for i := range a {
+		b[i] /= n
+		for j := 0; j <= i; j++ {
+			a[i][j] /= n
+			a[j][i] = a[i][j]
+		}
+		a[i][i] += ridge
+	}
+
+	beta := solveSPD(a, b)
+	m.Coefficients = make([]float64, p)
+	for i, j := range active {
+		m.Coefficients[j] = beta[i]
+	}
+	m.Iterations = 1
+}
+
+// solveSPD - แก้ Ax = b เมื่อ A เป็นเมทริกซ์สมมาตรบวกแน่นอน (แยกตัวประกอบ Cholesky)
+func solveSPD(a [][]float64, b []float64) []float64 {
+	k := len(b)
+	l := make([][]float64, k)
+	for i := range l {
+		l[i] = make([]float64, k)
+		for j := 0; j <= i; j++ {
+			sum := a[i][j]
+			for t := 0; t < j; t++ {
+				sum -= l[i][t] * l[j][t]
+			}
+			if i == j {
+				l[i][i] = math.Sqrt(math.Max(sum, 1e-12))
+			} else {
+				l[i][j] = sum / l[j][j]
+			}
+		}
+	}
+	// Ly = b แล้ว Lᵀx = y
+	x := make([]float64, k)
+	for i := 0; i < k; i++ {
+		sum := b[i]
+		for t := 0; t < i; t++ {
+			sum -= l[i][t] * x[t]
+		}
+		x[i] = sum / l[i][i]
+	}
+	for i := k - 1; i >= 0; i-- {
+		sum := x[i]
+		for t := i + 1; t < k; t++ {
+			sum -= l[t][i] * x[t]
+		}
+		x[i] = sum / l[i][i]
+	}
+	return x
+}
+
+// fitCoordinateDescent - lasso/elastic-net ด้วย coordinate descent แบบ glmnet
+// β_j = S(ρ_j, λα) / (z_j + λ(1-α)) เมื่อ ρ_j = Z_jᵀr/n + z_jβ_j และ z_j = Z_jᵀZ_j/n
+func (m *LinearModel) fitCoordinateDescent(z [][]float64, y []float64) {
+	n := float64(len(y))
+	mean := meanOf(y)
+	m.Intercept = mean
+
+	residual := make([]float64, len(y))
+	for i := range y {
+		residual[i] = y[i] - mean
+	}
+	active := m.activeFeatures()
+	scale := make([]float64, len(m.Coefficients))
+	for _, row := range z {
+		for _, j := range active {
+			scale[j] += row[j] * row[j] / n
+		}
+	}
+
+	l1 := m.Config.Lambda * m.Config.Alpha
+	l2 := m.Config.Lambda * (1 - m.Config.Alpha)
+	for m.Iterations = 1; m.Iterations <= m.Config.MaxIter; m.Iterations++ {
+		var maxChange float64
+		for _, j := range active {
+			old := m.Coefficients[j]
+			var rho float64
+			for i, row := range z {
+				rho += row[j] * residual[i]
+			}
+			rho = rho/n + scale[j]*old
+			beta := softThreshold(rho, l1) / (scale[j] + l2)
+			if beta == old {
+				continue
+			}
+			for i, row := range z {
+				residual[i] -= row[j] * (beta - old)
+			}
+			m.Coefficients[j] = beta
+			maxChange = math.Max(maxChange, math.Abs(beta-old))
+		}
+		if maxChange < m.Config.Tolerance {
+			break
+		}
+	}
+	m.Iterations = min(m.Iterations, m.Config.MaxIter)
+}
+
+// fitLogistic - logistic regression ด้วย proximal gradient แบบเร่ง (FISTA)
+// ลด logloss เฉลี่ย + ค่าปรับ elastic-net โดยไม่ปรับจุดตัด ขนาดก้าวจากขอบบนของความโค้ง 0.25*(λmax(ZᵀZ/n)+1)
+func (m *LinearModel) fitLogistic(z [][]float64, y []float64) {
+	n, p := float64(len(y)), len(m.Coefficients)
+	active := m.activeFeatures()
+	l1 := m.Config.Lambda * m.Config.Alpha
+	l2 := m.Config.Lambda * (1 - m.Config.Alpha)
+	step := 1 / (0.25*(largestEigenvalue(z, active)+1) + l2)
+
+	// เริ่มจากจุดตัดที่ให้ความน่าจะเป็นเท่ากับสัดส่วนของคลาส 1
+	rate := math.Min(math.Max(meanOf(y), 1e-6), 1-1e-6)
+	m.Intercept = math.Log(rate / (1 - rate))
+
+	// (w, w0) คือจุดที่ใช้คำนวณ gradient หลังเร่งด้วยโมเมนตัม
+	w := append([]float64(nil), m.Coefficients...)
+	w0 := m.Intercept
+	t := 1.0
+	grad := make([]float64, p)
+	for m.Iterations = 1; m.Iterations <= m.Config.MaxIter; m.Iterations++ {
+		for j := range grad {
+			grad[j] = 0
+		}
+		var grad0 float64
+		for i, row := range z {
+			score := w0
+			for _, j := range active {
+				score += w[j] * row[j]
+			}
+			d := sigmoid(score) - y[i]
+			grad0 += d
+			for _, j := range active {
+				grad[j] += d * row[j]
+			}
+		}
+
+		prev := append([]float64(nil), m.Coefficients...)
+		prev0 := m.Intercept
+		m.Intercept = w0 - step*grad0/n
+		var maxChange float64
+		for _, j := range active {
+			v := w[j] - step*grad[j]/n
+			m.Coefficients[j] = softThreshold(v, step*l1) / (1 + step*l2)
+			maxChange = math.Max(maxChange, math.Abs(m.Coefficients[j]-prev[j]))
+		}
+		maxChange = math.Max(maxChange, math.Abs(m.Intercept-prev0))
+		if maxChange < m.Config.Tolerance {
+			break
+		}
+
+		next := (1 + math.Sqrt(1+4*t*t)) / 2
+		momentum := (t - 1) / next
+		t = next
+		for _, j := range active {
+			w[j] = m.Coefficients[j] + momentum*(m.Coefficients[j]-prev[j])
+		}
+		w0 = m.Intercept + momentum*(m.Intercept-prev0)
+	}
+	m.Iterations = min(m.Iterations, m.Config.MaxIter)
+}
+
+// largestEigenvalue - ค่าเฉพาะที่มากที่สุดของ ZᵀZ/n โดยประมาณด้วย power iteration
+func largestEigenvalue(z [][]float64, active []int) float64 {
+	if len(active) == 0 || len(z) == 0 {
+		return 0
+	}
+	p := len(z[0])
+	v := make([]float64, p)
+	for _, j := range active {
+		v[j] = 1 / math.Sqrt(float64(len(active)))
+	}
+	var eigen float64
+	for iter := 0; iter < 50; iter++ {
+		next := make([]float64, p)
+		for _, row := range z {
+			var dot float64
+			for _, j := range active {
+				dot += row[j] * v[j]
+			}
+			for _, j := range active {
+				next[j] += row[j] * dot / float64(len(z))
+			}
+		}
+		var norm float64
+		for _, x := range next {
+			norm += x * x
+		}
+		norm = math.Sqrt(norm)
+		if norm == 0 {
+			return 0
+		}
+		for j := range next {
+			next[j] /= norm
+		}
+		converged := math.Abs(norm-eigen) < 1e-6*norm
+		eigen, v = norm, next
+		if converged {
+			break
+		}
+	}
+	// เผื่อไว้เล็กน้อยเพราะ power iteration ประมาณค่าจากด้านล่าง
+	return eigen * 1.05
+}
+
+// activeFeatures - feature ที่แปรผันในชุด train
+func (m *LinearModel) activeFeatures() []int {
+	var active []int
+	for j, s := range m.Stds {
+		if s > 0 {
+			active = append(active, j)
+		}
+	}
+	return active
+}
+
+func softThreshold(v, threshold float64) float64 {
+	switch {
+	case v > threshold:
+		return v - threshold
+	case v < -threshold:
+		return v + threshold
+	}
+	return 0
+}
+
+func sigmoid(v float64) float64 {
+	if v >= 0 {
+		return 1 / (1 + math.Exp(-v))
+	}
+	e := math.Exp(v)
+	return e / (1 + e)
+}
+
+func meanOf(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// isBinary - ทุกค่าเป็น 0 หรือ 1
+func isBinary(values []float64) bool {
+	for _, v := range values {
+		if v != 0 && v != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// ExportCoefficients - เขียนสัมประสิทธิ์ของโมเดลเชิงเส้นเรียงตามขนาด (ค่าสัมบูรณ์) จากมากไปน้อย
+// Coefficient เทียบขนาดกันได้ (ต่อหนึ่งส่วนเบี่ยงเบนมาตรฐาน) RawCoefficient อยู่ในหน่วยเดิมของ feature
+func ExportCoefficients(m *LinearModel, features []string, filename string) error {
+	raw, intercept := m.RawCoefficients()
+	order := coefficientOrder(m)
+	columns := []string{"Feature", "Coefficient", "RawCoefficient", "Mean", "Std"}
+	records := [][]string{{"(intercept)", formatModelValue(m.Intercept), formatModelValue(intercept), "", ""}}
+	for _, j := range order {
+		records = append(records, []string{
+			features[j], formatModelValue(m.Coefficients[j]), formatModelValue(raw[j]), formatModelValue(m.Means[j]), formatModelValue(m.Stds[j]),
+		})
+	}
+	return writeReportCSV(filename, columns, records)
+}
+
+// coefficientOrder - ลำดับของ feature ตามขนาดสัมประสิทธิ์จากมากไปน้อย
+func coefficientOrder(m *LinearModel) []int {
+	order := make([]int, len(m.Coefficients))
+	for j := range order {
+		order[j] = j
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return math.Abs(m.Coefficients[order[a]]) > math.Abs(m.Coefficients[order[b]])
+	})
+	return order
+}
+
+// logCoefficients - แสดงจำนวนสัมประสิทธิ์ที่ไม่เป็นศูนย์และ feature ที่สำคัญที่สุดไม่เกิน 10 ตัว
+func logCoefficients(m *LinearModel, features []string) {
+	nonZero := 0
+	for _, b := range m.Coefficients {
+		if b != 0 {
+			nonZero++
+		}
+	}
+	logMsg("model.coefficients", nonZero, len(m.Coefficients), m.Iterations)
+	for i, j := range coefficientOrder(m) {
+		if i == 10 || m.Coefficients[j] == 0 {
+			break
+		}
+		logMsg("model.coefficient", features[j], m.Coefficients[j])
+	}
+}
+
+// formatModelValue - ตัวเลขของโมเดลแบบไม่ปัดเศษ (10 หลักที่มีนัยสำคัญ)
+func formatModelValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 10, 64)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSolveSPD(t *testing.T) {
+	tests := []struct {
+		name string
+		a    [][]float64
+		b    []float64
+		want []float64
+	}{
+		// inverse ของ [[4 2] [2 3]] คือ [[3 -2] [-2 4]]/8
+		{"2x2", [][]float64{{4, 2}, {2, 3}}, []float64{6, 5}, []float64{1, 1}},
+		{"2x2 with zero component", [][]float64{{4, 2}, {2, 3}}, []float64{2, 1}, []float64{0.5, 0}},
+		// L = [[2 0 0] [6 1 0] [-8 5 3]] และ b = A·(1,1,1)
+		{"3x3", [][]float64{{4, 12, -16}, {12, 37, -43}, {-16, -43, 98}}, []float64{0, 6, 39}, []float64{1, 1, 1}},
+		{"3x3 scaled", [][]float64{{4, 12, -16}, {12, 37, -43}, {-16, -43, 98}}, []float64{4, 12, -16}, []float64{1, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := solveSPD(tt.a, tt.b); !closeSlices(got, tt.want, 1e-9) {
+				t.Errorf("solveSPD = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func closeSlices(got, want []float64, tolerance float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFitLinear(t *testing.T) {
+	// y = 2x + 1 เมื่อ standardize แล้ว ZᵀZ/n = 1 และ Zᵀ(y-ȳ)/n = 2·sd(x) = √5
+	line := [][]float64{{1}, {2}, {3}, {4}}
+	lineY := []float64{3, 5, 7, 9}
+	// feature ตั้งฉากกันที่มีค่าเฉลี่ย 0 และ sd 1 อยู่แล้ว y = 3a + b จึงได้ ρ = (3, 1)
+	orthogonal := [][]float64{{1, 1}, {-1, 1}, {1, -1}, {-1, -1}}
+	orthogonalY := []float64{4, -2, 2, -4}
+
+	tests := []struct {
+		name          string
+		x             [][]float64
+		y             []float64
+		cfg           LinearConfig
+		wantRaw       []float64
+		wantIntercept float64
+	}{
+		{"ols recovers the line", line, lineY, LinearConfig{Method: linearOLS}, []float64{2}, 1},
+		// β = √5/(1+1) = sd(x) จึงได้ 1 ในหน่วยเดิม จุดตัด 6 - 2.5
+		{"ridge halves the slope", line, lineY, LinearConfig{Method: linearRidge, Lambda: 1}, []float64{1}, 3.5},
+		// β = S(√5, √1.25) = √1.25
+		{"lasso shrinks by lambda", line, lineY, LinearConfig{Method: linearLasso, Lambda: math.Sqrt(1.25), MaxIter: 100, Tolerance: 1e-12}, []float64{1}, 3.5},
+		{"lasso zeroes a weak fit", line, lineY, LinearConfig{Method: linearLasso, Lambda: 3, MaxIter: 100, Tolerance: 1e-12}, []float64{0}, 6},
+		// β = S(√5, 1)/(1+1) ในหน่วยเดิมหารด้วย √1.25
+		{"elastic net", line, lineY, LinearConfig{Method: linearElasticNet, Lambda: 2, Alpha: 0.5, MaxIter: 100, Tolerance: 1e-12}, []float64{1 - 1/math.Sqrt(5)}, 6 - 2.5*(1-1/math.Sqrt(5))},
+		{"lasso drops the weaker feature", orthogonal, orthogonalY, LinearConfig{Method: linearLasso, Lambda: 2, MaxIter: 100, Tolerance: 1e-12}, []float64{1, 0}, 0},
+		{"ridge on orthogonal features", orthogonal, orthogonalY, LinearConfig{Method: linearRidge, Lambda: 1}, []float64{1.5, 0.5}, 0},
+		{"constant feature is ignored", [][]float64{{1, 7}, {2, 7}, {3, 7}, {4, 7}}, lineY, LinearConfig{Method: linearOLS}, []float64{2, 0}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := FitLinear(tt.x, tt.y, tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw, intercept := m.RawCoefficients()
+			if !closeSlices(raw, tt.wantRaw, 1e-6) || math.Abs(intercept-tt.wantIntercept) > 1e-6 {
+				t.Errorf("coefficients = %v, %v; want %v, %v", raw, intercept, tt.wantRaw, tt.wantIntercept)
+			}
+		})
+	}
+}
+
+func TestFitLinearRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name string
+		y    []float64
+		cfg  LinearConfig
+	}{
+		{"unknown method", []float64{1}, LinearConfig{Method: "svm"}},
+		{"negative lambda", []float64{1}, LinearConfig{Method: linearRidge, Lambda: -1}},
+		{"alpha above one", []float64{1}, LinearConfig{Method: linearElasticNet, Alpha: 2}},
+		{"no rows", nil, LinearConfig{Method: linearOLS}},
+		{"logistic needs a binary target", []float64{0.5}, LinearConfig{Method: linearLogistic}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := make([][]float64, len(tt.y))
+			for i := range x {
+				x[i] = []float64{float64(i)}
+			}
+			if _, err := FitLinear(x, tt.y, tt.cfg); err == nil {
+				t.Error("FitLinear succeeded; want an error")
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name string
+		task string
+		y    []float64
+		pred []float64
+		want map[string]float64
+	}{
+		// ความคลาดเคลื่อน 1, -1, 0, 2: ssRes 6 ssTot 5
+		{"regression", taskRegression, []float64{1, 2, 3, 4}, []float64{0, 3, 3, 2},
+			map[string]float64{"rmse": math.Sqrt(1.5), "mae": 1, "r2": 1 - 6.0/5}},
+		{"constant target has no r2", taskRegression, []float64{2, 2}, []float64{1, 3},
+			map[string]float64{"rmse": 1, "mae": 1}},
+		{"classification", taskClassification, []float64{0, 1}, []float64{0.5, 0.5},
+			map[string]float64{"logloss": math.Ln2, "auc": 0.5, "accuracy": 0.5}},
+		{"single class has no auc", taskClassification, []float64{1, 1}, []float64{0.5, 0.5},
+			map[string]float64{"logloss": math.Ln2, "accuracy": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluate(tt.task, tt.y, tt.pred)
+			if len(got) != len(tt.want) {
+				t.Fatalf("evaluate = %v; want %v", got, tt.want)
+			}
+			for name, want := range tt.want {
+				if v, ok := got[name]; !ok || !approxEqual(v, want) {
+					t.Errorf("%s = %v; want %v", name, v, want)
+				}
+			}
+		})
+	}
+}
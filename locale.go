@@ -0,0 +1,377 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Locale - ภาษาที่ใช้แสดงผลข้อความและหัวคอลัมน์
+type Locale string
+
+const (
+	LocaleThai    Locale = "th"
+	LocaleEnglish Locale = "en"
+)
+
+// buddhistEraOffset - ผลต่างระหว่างปีพุทธศักราชกับปีคริสต์ศักราช
+const buddhistEraOffset = 543
+
+// currentLocale - ภาษาที่ใช้อยู่ (ค่าเริ่มต้นเป็นภาษาไทยตามพฤติกรรมเดิม)
+var currentLocale = LocaleThai
+
+// useBuddhistEra - แสดงปีเป็นพุทธศักราชในไฟล์ส่งออกและข้อความ
+var useBuddhistEra bool
+
+// SetLocale - เลือกภาษาและรูปแบบปีจาก command line
+func SetLocale(lang string, buddhistEra bool) error {
+	switch Locale(strings.ToLower(strings.TrimSpace(lang))) {
+	case LocaleThai, "":
+		currentLocale = LocaleThai
+	case LocaleEnglish:
+		currentLocale = LocaleEnglish
+	default:
+		return fmt.Errorf("unknown language / ไม่รู้จักภาษา: %s", lang)
+	}
+	useBuddhistEra = buddhistEra
+	return nil
+}
+
+// text - ข้อความหนึ่งข้อความในสองภาษา
+type text struct {
+	th string
+	en string
+}
+
+func (t text) String() string {
+	if currentLocale == LocaleEnglish && t.en != "" {
+		return t.en
+	}
+	return t.th
+}
+
+// T - ข้อความตาม key ในภาษาปัจจุบัน จัดรูปแบบด้วย fmt.Sprintf
+// ถ้าไม่พบ key จะคืน key เพื่อให้เห็นได้ชัดว่าขาดคำแปล
+func T(key string, args ...interface{}) string {
+	m, ok := messages[key]
+	if !ok {
+		if len(args) == 0 {
+			return key
+		}
+		return key + ": " + fmt.Sprint(args...)
+	}
+	if len(args) == 0 {
+		return m.String()
+	}
+	return fmt.Sprintf(m.String(), args...)
+}
+
+// logOutput - ปลายทางของข้อความ log เปลี่ยนเป็น stderr เมื่อส่งข้อมูลออกทาง stdout
+var logOutput io.Writer = os.Stdout
+
+// logMsg - พิมพ์ข้อความตาม key ออกทางหน้าจอ
+func logMsg(key string, args ...interface{}) {
+	fmt.Fprint(logOutput, T(key, args...))
+}
+
+// errMsg - สร้าง error จากข้อความตาม key
+func errMsg(key string, args ...interface{}) error {
+	return fmt.Errorf(T(key), args...)
+}
+
+// displayYear - ปีสำหรับแสดงผล (แปลงเป็น พ.ศ. ถ้าเลือกไว้)
+func displayYear(year int) int {
+	if useBuddhistEra && year != 0 {
+		return year + buddhistEraOffset
+	}
+	return year
+}
+
+// displayYearString - เหมือน displayYear แต่รับและคืนค่าเป็นสตริงตามที่ API ส่งมา
+func displayYearString(year string) string {
+	n, err := strconv.Atoi(strings.TrimSpace(year))
+	if err != nil {
+		return year
+	}
+	return strconv.Itoa(displayYear(n))
+}
+
+// parseDisplayYear - แปลงปีที่อ่านจากไฟล์กลับเป็นคริสต์ศักราช
+// ปีตั้งแต่ 2400 ขึ้นไปถือเป็นพุทธศักราช (ข้อมูลตลาดหุ้นไม่มีปี ค.ศ. ที่มากขนาดนั้น)
+func parseDisplayYear(year string) string {
+	n, err := strconv.Atoi(strings.TrimSpace(year))
+	if err != nil {
+		return year
+	}
+	if n >= 2400 {
+		n -= buddhistEraOffset
+	}
+	return strconv.Itoa(n)
+}
+
+// displayPeriod - ไตรมาสสำหรับแสดงผล เช่น 2024Q1 หรือ 2567Q1
+func displayPeriod(p Period) string {
+	if p.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%dQ%d", displayYear(p.Year), p.Quarter)
+}
+
+// yearColumns - คอลัมน์ที่เป็นปี ต้องแปลงเป็น พ.ศ. เมื่อเลือกไว้
+var yearColumns = map[string]bool{
+	"Year":         true,
+	"CalendarYear": true,
+}
+
+// localizeValue - ปรับค่าของคอลัมน์ตามการตั้งค่าภาษา (ปัจจุบันคือการแปลงปีเป็น พ.ศ.)
+func localizeValue(col string, val interface{}) interface{} {
+	if !useBuddhistEra || !yearColumns[col] {
+		return val
+	}
+	switch v := val.(type) {
+	case int:
+		return displayYear(v)
+	case string:
+		return displayYearString(v)
+	}
+	return val
+}
+
+// columnHeader - หัวคอลัมน์ตามภาษาปัจจุบัน ใช้ชื่อคอลัมน์เดิมถ้าไม่มีคำแปล
+func columnHeader(col string) string {
+	if name, ok := columnNames[col]; ok {
+		return name.String()
+	}
+	if name, ok := labelHeader(col); ok {
+		return name
+	}
+	if name, ok := imputedHeader(col); ok {
+		return name
+	}
+	return col
+}
+
+// messages - ข้อความทั้งหมดที่แสดงบนหน้าจอ บันทึกใน log และข้อความ error
+var messages = map[string]text{
+	// การดึงรายชื่อหุ้น
+	"symbols.sample":        {th: "ตัวอย่างข้อมูล (50 ตัวแรก): %s\n", en: "Response sample (first 50 bytes): %s\n"},
+	"symbols.decode_array":  {th: "แปลงข้อมูล JSON Array ไม่สำเร็จ: %v", en: "failed to decode JSON array: %v"},
+	"symbols.decode_object": {th: "แปลงข้อมูล JSON Object ไม่สำเร็จ: %v", en: "failed to decode JSON object: %v"},
+	"symbols.keys_header":   {th: "โครงสร้างข้อมูล (keys):\n", en: "Response structure (keys):\n"},
+	"symbols.key":           {th: "- %s (type: %T)\n", en: "- %s (type: %T)\n"},
+	"symbols.found_field":   {th: "พบข้อมูลใน field '%s' จำนวน %d รายการ\n", en: "Found %[2]d items in field '%[1]s'\n"},
+	"symbols.count":         {th: "จำนวนสัญลักษณ์หุ้นที่พบ: %d\n", en: "Symbols found: %d\n"},
+	"symbols.examples":      {th: "ตัวอย่างสัญลักษณ์: %v\n", en: "Example symbols: %v\n"},
+	"symbols.none":          {th: "ไม่พบข้อมูลสัญลักษณ์หุ้น ข้อมูลที่ได้รับ:\n", en: "No symbols found, raw response:\n"},
+
+	// การดึงงบการเงิน
+	"fetch.symbols_failed":    {th: "ไม่สามารถดึงรายชื่อหุ้นได้: %v", en: "cannot fetch symbol list: %v"},
+	"fetch.symbols_found":     {th: "พบหุ้นทั้งหมด %d ตัว\n", en: "Found %d symbols\n"},
+	"fetch.start_symbol":      {th: "กำลังดึงข้อมูลของ %s (%d/%d)\n", en: "Fetching %s (%d/%d)\n"},
+	"fetch.done_symbol":       {th: "เสร็จงาน %s (%d/%d)\n", en: "Queued %s (%d/%d)\n"},
+	"fetch.failed":            {th: "เกิดข้อผิดพลาดในการดึงข้อมูล: %v\n", en: "Error while fetching data: %v\n"},
+	"fetch.errors_log_header": {th: "--- ข้อผิดพลาดในการดึงข้อมูลวันที่ %s ---\n", en: "--- Fetch errors on %s ---\n"},
+	"fetch.errors_logged":     {th: "พบข้อผิดพลาด %d รายการ บันทึกไว้ที่ %s\n", en: "%d errors found, written to %s\n"},
+	"fetch.summary":           {th: "ดึงข้อมูลสำเร็จ: %d รายการ จาก %d บริษัท\n", en: "Fetched %d records from %d companies\n"},
+	"fetch.error_financial":   {th: "ข้อมูลงบการเงิน: %s", en: "financial data: %s"},
+	"fetch.error_price":       {th: "ข้อมูลราคา: %s", en: "price data: %s"},
+
+	// การเรียก API
+	"http.rate_limit":     {th: "rate limit error: %v", en: "rate limit error: %v"},
+	"http.create_request": {th: "สร้างคำขอไม่สำเร็จ: %v", en: "failed to create request: %v"},
+	"http.send_request":   {th: "ส่งคำขอไม่สำเร็จ: %v", en: "request failed: %v"},
+	"http.read_body":      {th: "อ่านข้อมูลไม่สำเร็จ: %v", en: "failed to read response: %v"},
+	"http.status":         {th: "API ตอบสถานะ: %d", en: "API returned status %d"},
+	"http.decode_json":    {th: "แปลงข้อมูล JSON ไม่สำเร็จ: %v", en: "failed to decode JSON: %v"},
+
+	// ราคา ณ สิ้นไตรมาส และราคาย้อนหลัง
+	"price.quarter_error":   {th: "%s Q%s/%s: ข้อมูลราคา: %v\n", en: "%s Q%s/%s: price data: %v\n"},
+	"price.no_period":       {th: "%s Q%s/%s: ไม่รู้ไตรมาสปฏิทินของงวด ข้ามการดึงราคา\n", en: "%s Q%s/%s: unknown calendar quarter, skipping the price lookup\n"},
+	"price.failed":          {th: "พบข้อผิดพลาดในการดึงข้อมูลราคา %v", en: "error while fetching prices: %v"},
+	"history.start":         {th: "กำลังดึงราคาย้อนหลังของ %s (%d/%d)\n", en: "Fetching price history for %s (%d/%d)\n"},
+	"history.symbol_failed": {th: "%s: ดึงราคาย้อนหลังไม่สำเร็จ: %v\n", en: "%s: price history failed: %v\n"},
+	"history.failed":        {th: "เกิดข้อผิดพลาดในการดึงราคาย้อนหลัง: %v", en: "error while fetching price history: %v"},
+	"history.done":          {th: "ดึงราคาย้อนหลังสำเร็จ: %d รายการ จาก %d บริษัท\n", en: "Fetched %d daily prices for %d companies\n"},
+
+	// การส่งออกไฟล์
+	"export.no_data":             {th: "ไม่มีข้อมูลสำหรับส่งออก", en: "no data to export"},
+	"export.no_price_data":       {th: "ไม่มีข้อมูลราคาสำหรับส่งออก", en: "no price data to export"},
+	"export.create_file":         {th: "ไม่สามารถสร้างไฟล์ %s: %v", en: "cannot create %s file: %v"},
+	"export.save_file":           {th: "ไม่สามารถบันทึกไฟล์ %s: %v", en: "cannot save %s file: %v"},
+	"export.write_header":        {th: "ไม่สามารถเขียนหัวคอลัมน์: %v", en: "cannot write header: %v"},
+	"export.write_row":           {th: "ไม่สามารถเขียนข้อมูลแถว: %v", en: "cannot write row: %v"},
+	"export.create_sheet":        {th: "ไม่สามารถสร้างชีต %s: %v", en: "cannot create sheet %s: %v"},
+	"export.parquet_write":       {th: "ไม่สามารถเขียนข้อมูล Parquet: %v", en: "cannot write Parquet data: %v"},
+	"export.parquet_close":       {th: "ไม่สามารถปิดไฟล์ Parquet: %v", en: "cannot close Parquet file: %v"},
+	"export.unknown_compression": {th: "ไม่รู้จักวิธีบีบอัด Parquet: %s", en: "unknown Parquet compression: %s"},
+	"export.done":                {th: "ส่งออกข้อมูลเรียบร้อยแล้วที่ %s จำนวน %d รายการ\n", en: "Exported %[2]d rows to %[1]s\n"},
+	"export.prices_done":         {th: "ส่งออกราคารายวันเรียบร้อยแล้วที่ %s จำนวน %d รายการ\n", en: "Exported %[2]d daily prices to %[1]s\n"},
+	"export.failed":              {th: "เกิดข้อผิดพลาดในการส่งออกไฟล์ %s: %v\n", en: "Failed to export %s file: %v\n"},
+	"import.read_parquet":        {th: "ไม่สามารถอ่านไฟล์ Parquet: %v", en: "cannot read Parquet file: %v"},
+	"export.unknown_format":      {th: "ไม่รู้จักรูปแบบไฟล์: %s", en: "unknown output format: %s"},
+
+	// การส่งออกระหว่างดึงข้อมูล (streaming)
+	"stream.mongo_connect":   {th: "ไม่สามารถเชื่อมต่อกับ MongoDB ได้: %v", en: "cannot connect to MongoDB: %v"},
+	"stream.mongo_connected": {th: "เชื่อมต่อกับ MongoDB สำเร็จ (%s.%s)\n", en: "Connected to MongoDB (%s.%s)\n"},
+	"stream.mongo_write":     {th: "ไม่สามารถบันทึกข้อมูลลง MongoDB: %v", en: "cannot write to MongoDB: %v"},
+	"stream.mongo_url":       {th: "ไม่ได้กำหนด MONGO_URL", en: "MONGO_URL is not set"},
+	"stream.unsupported":     {th: "-stream ใช้ร่วมกับ %s ไม่ได้ เพราะต้องโหลดข้อมูลทั้งชุด (รองรับ csv, jsonl, tidy-csv, tidy-jsonl)", en: "-stream cannot be combined with %s because it needs the whole dataset in memory (supported: csv, jsonl, tidy-csv, tidy-jsonl)"},
+
+	// การนำเข้าไฟล์ CSV
+	"import.open_file":      {th: "ไม่สามารถเปิดไฟล์ที่จะนำเข้า: %v", en: "cannot open input file: %v"},
+	"import.read_header":    {th: "ไม่สามารถอ่านหัวคอลัมน์: %v", en: "cannot read header: %v"},
+	"import.missing_column": {th: "ไม่พบคอลัมน์ที่จำเป็น: %s", en: "required column not found: %s"},
+	"import.field_count":    {th: "จำนวนคอลัมน์ไม่ตรงกับหัวคอลัมน์ (%d จาก %d)", en: "wrong number of fields (%d of %d)"},
+	"import.unknown_header": {th: "ไม่รู้จักหัวคอลัมน์ ข้ามคอลัมน์นี้", en: "unknown header, column skipped"},
+	"import.empty_symbol":   {th: "ไม่มีชื่อหุ้น", en: "missing symbol"},
+	"import.issue_line":     {th: "บรรทัด %d: %s", en: "line %d: %s"},
+	"import.issue_column":   {th: "บรรทัด %d คอลัมน์ %s (%q): %s", en: "line %d column %s (%q): %s"},
+	"import.done":           {th: "นำเข้าข้อมูลจาก %s จำนวน %d รายการ พบปัญหา %d รายการ\n", en: "Imported %[2]d rows from %[1]s with %[3]d issues\n"},
+
+	// dataset แบบแบ่งพาร์ทิชัน
+	"dataset.invalid_run_id": {th: "รหัสการรันไม่ถูกต้อง: %q", en: "invalid run id: %q"},
+	"dataset.invalid_as_of":  {th: "วันที่ของข้อมูลไม่ถูกต้อง (ใช้ YYYY-MM-DD): %s", en: "invalid as-of date (use YYYY-MM-DD): %s"},
+	"dataset.unknown_format": {th: "dataset รองรับเฉพาะ csv และ parquet: %s", en: "dataset supports only csv and parquet: %s"},
+	"dataset.mkdir":          {th: "ไม่สามารถสร้างโฟลเดอร์: %v", en: "cannot create directory: %v"},
+	"dataset.checksum":       {th: "ไม่สามารถคำนวณ checksum: %v", en: "cannot compute checksum: %v"},
+	"dataset.manifest":       {th: "ไม่สามารถเขียนหรืออ่าน manifest: %v", en: "cannot write or read manifest: %v"},
+	"dataset.done":           {th: "เขียน dataset ที่ %s (run %s) จำนวน %d ไฟล์\n", en: "Wrote dataset to %s (run %s), %d files\n"},
+
+	// การเปรียบเทียบข้อมูลสองชุด
+	"diff.usage":            {th: "วิธีใช้: stock-predict diff [flags] <ข้อมูลเดิม> <ข้อมูลใหม่> (ไฟล์ csv/jsonl/parquet หรือโฟลเดอร์ dataset[@run])", en: "usage: stock-predict diff [flags] <old> <new> (csv/jsonl/parquet file or dataset dir[@run])"},
+	"diff.header":           {th: "เปรียบเทียบ %s กับ %s\n", en: "Comparing %s with %s\n"},
+	"diff.new_symbols":      {th: "หุ้นใหม่: %d\n", en: "New symbols: %d\n"},
+	"diff.delisted_symbols": {th: "หุ้นที่หายไป: %d\n", en: "Delisted symbols: %d\n"},
+	"diff.new_quarters":     {th: "งวดใหม่: %d\n", en: "New quarters: %d\n"},
+	"diff.removed_quarters": {th: "งวดที่หายไป: %d\n", en: "Removed quarters: %d\n"},
+	"diff.changed_values":   {th: "ค่าที่เปลี่ยน: %d\n", en: "Changed values: %d\n"},
+	"diff.price_changes":    {th: "ราคาที่เปลี่ยน: %d\n", en: "Price snapshot changes: %d\n"},
+	"diff.more":             {th: "  ... และอีก %d รายการ\n", en: "  ... and %d more\n"},
+
+	// การตรวจสอบความถูกต้องของข้อมูล
+	"validate.summary":                   {th: "ตรวจสอบข้อมูล %d บริษัท: พบข้อผิดพลาด %d รายการ คำเตือน %d รายการ\n", en: "Validated %d companies: %d errors, %d warnings\n"},
+	"validate.worst":                     {th: "  %s คะแนน %s (ข้อผิดพลาด %d คำเตือน %d)\n", en: "  %s score %s (%d errors, %d warnings)\n"},
+	"validate.severity.error":            {th: "ข้อผิดพลาด", en: "error"},
+	"validate.severity.warning":          {th: "คำเตือน", en: "warning"},
+	"validate.rule.balance":              {th: "สินทรัพย์รวมไม่เท่ากับหนี้สินรวม + ส่วนของผู้ถือหุ้นรวม", en: "total assets differ from total liabilities + total equity"},
+	"validate.rule.parent_equity":        {th: "ส่วนของผู้ถือหุ้นบริษัทใหญ่มากกว่าส่วนของผู้ถือหุ้นรวม", en: "shareholders' equity exceeds total equity"},
+	"validate.rule.negative_assets":      {th: "สินทรัพย์รวมติดลบ", en: "negative total assets"},
+	"validate.rule.negative_liabilities": {th: "หนี้สินรวมติดลบ", en: "negative total liabilities"},
+	"validate.rule.negative_revenue":     {th: "รายได้รวมสะสมติดลบ", en: "negative accumulated revenue"},
+	"validate.rule.roe":                  {th: "ROE ไม่ตรงกับกำไรสุทธิ (ปรับเป็นรายปี) / ส่วนของผู้ถือหุ้น", en: "ROE does not match annualised net profit / shareholders' equity"},
+	"validate.rule.roa":                  {th: "ROA ไม่ตรงกับ EBIT (ปรับเป็นรายปี) / สินทรัพย์รวม", en: "ROA does not match annualised EBIT / total assets"},
+	"validate.rule.de":                   {th: "D/E ไม่ตรงกับหนี้สินรวม / ส่วนของผู้ถือหุ้น", en: "D/E does not match total liabilities / shareholders' equity"},
+	"validate.rule.net_margin":           {th: "อัตรากำไรสุทธิไม่ตรงกับกำไรสุทธิ / รายได้รวม", en: "net margin does not match net profit / total revenue"},
+	"validate.rule.operating_cash_flow":  {th: "กระแสเงินสดจากการดำเนินงานติดลบมากกว่ารายได้ทั้งปี", en: "operating cash outflow exceeds revenue"},
+	"validate.rule.investing_cash_flow":  {th: "กระแสเงินสดจากการลงทุนเป็นบวกมากกว่าสินทรัพย์รวม", en: "investing cash inflow exceeds total assets"},
+	"validate.rule.sum_revenue":          {th: "รายได้รายไตรมาสรวมกันไม่เท่ากับรายได้สะสม", en: "quarterly revenue does not add up to accumulated revenue"},
+	"validate.rule.sum_expenses":         {th: "ค่าใช้จ่ายรายไตรมาสรวมกันไม่เท่ากับค่าใช้จ่ายสะสม", en: "quarterly expenses do not add up to accumulated expenses"},
+	"validate.rule.sum_ebit":             {th: "EBIT รายไตรมาสรวมกันไม่เท่ากับ EBIT สะสม", en: "quarterly EBIT does not add up to accumulated EBIT"},
+	"validate.rule.sum_net_profit":       {th: "กำไรสุทธิรายไตรมาสรวมกันไม่เท่ากับกำไรสุทธิสะสม", en: "quarterly net profit does not add up to accumulated net profit"},
+
+	// รายงานความครบถ้วนของข้อมูล
+	"completeness.summary":           {th: "ข้อมูลครบ %d จาก %d บริษัท ช่วง %s ถึง %s\n", en: "%d of %d companies complete from %s to %s\n"},
+	"completeness.more":              {th: "  ... และอีก %d บริษัทที่ข้อมูลไม่ครบ\n", en: "  ... and %d more incomplete companies\n"},
+	"completeness.legend":            {th: "# ครบ  + ไม่มีราคา  ! ดึงราคาไม่สำเร็จ  . ไม่มีงบ  x ดึงงบไม่สำเร็จ  (% = สัดส่วนบริษัทที่ครบ 0-9, * = ทั้งหมด)\n", en: "# complete  + no price  ! price fetch failed  . no financials  x financial fetch failed  (% = share of complete companies 0-9, * = all)\n"},
+	"completeness.cell.complete":     {th: "ครบ", en: "complete"},
+	"completeness.cell.no_price":     {th: "ไม่มีราคา", en: "no price"},
+	"completeness.cell.price_failed": {th: "ดึงราคาไม่สำเร็จ", en: "price failed"},
+	"completeness.cell.missing":      {th: "ไม่มีงบ", en: "missing"},
+	"completeness.cell.failed":       {th: "ดึงงบไม่สำเร็จ", en: "failed"},
+
+	// เป้าหมายสำหรับการพยากรณ์ (label)
+	"label.invalid_horizon":       {th: "ระยะเวลาของ label ไม่ถูกต้อง: %s (ใช้จำนวนวันทำการ คั่นด้วยจุลภาค เช่น 20,60,120,250)", en: "invalid label horizon: %s (use trading days separated by commas, e.g. 20,60,120,250)"},
+	"label.benchmark_missing":     {th: "ไม่พบราคาย้อนหลังของ benchmark %s จะไม่มี excessReturn และ beatsBenchmark\n", en: "No price history for benchmark %s; excessReturn and beatsBenchmark will be empty\n"},
+	"label.header.fwdReturn":      {th: "ผลตอบแทนล่วงหน้า %d วันทำการ", en: "Forward Return (%d days)"},
+	"label.header.excessReturn":   {th: "ผลตอบแทนส่วนเกินล่วงหน้า %d วันทำการ", en: "Excess Return (%d days)"},
+	"label.header.beatsBenchmark": {th: "ชนะ benchmark ใน %d วันทำการ", en: "Beats Benchmark (%d days)"},
+	"label.header.topQuintile":    {th: "อยู่ใน 20%% บนสุดใน %d วันทำการ", en: "Top Quintile (%d days)"},
+	"label.header.sign":           {th: "ทิศทางผลตอบแทน %d วันทำการ", en: "Return Sign (%d days)"},
+
+	// training matrix
+	"matrix.usage":         {th: "วิธีใช้: stock-predict matrix [flags] <ข้อมูล>... (ไฟล์ csv/jsonl/parquet หรือโฟลเดอร์ dataset[@run] หลายชุดได้เพื่อเก็บงบฉบับก่อนแก้ไข)", en: "usage: stock-predict matrix [flags] <data>... (csv/jsonl/parquet file or dataset dir[@run]; pass several to keep pre-restatement versions)"},
+	"matrix.invalid_split": {th: "วันที่แบ่งชุดข้อมูลไม่ถูกต้อง: %s (ใช้ YYYY-MM-DD ไม่เกินสองวันที่ เรียงจากเก่าไปใหม่)", en: "invalid split dates: %s (use up to two YYYY-MM-DD dates in ascending order)"},
+	"matrix.metadata":      {th: "เขียนไฟล์คำอธิบาย feature ไม่สำเร็จ: %v", en: "failed to write feature metadata: %v"},
+	"matrix.summary":       {th: "training matrix %d แถว %d คอลัมน์ (train %d, valid %d, test %d) ตัดทิ้ง: embargo %d, ไม่มีงบที่เผยแพร่แล้ว %d, ค่าขาด %d\n", en: "Training matrix: %d rows, %d columns (train %d, valid %d, test %d); dropped: embargo %d, no published financials %d, missing features %d\n"},
+
+	// การปรับ feature แบบ cross-section
+	"transform.invalid_winsor":     {th: "เปอร์เซ็นไทล์ของ -winsorize ไม่ถูกต้อง: %s (ใช้ ล่าง,บน ระหว่าง 0 ถึง 1 เช่น 0.01,0.99)", en: "invalid -winsorize percentiles: %s (use lower,upper between 0 and 1, e.g. 0.01,0.99)"},
+	"transform.invalid_method":     {th: "ไม่รู้จักวิธีปรับ feature: %s (ใช้ none, rank, zscore)", en: "unknown normalization: %s (use none, rank, zscore)"},
+	"transform.invalid_neutralize": {th: "ไม่รู้จักวิธี neutralize: %s (ใช้ sector หรือ size)", en: "unknown neutralization: %s (use sector or size)"},
+	"transform.invalid_buckets":    {th: "จำนวนกลุ่มมูลค่าตลาดต้องมากกว่าศูนย์: %d", en: "size bucket count must be positive: %d"},
+	"transform.sectors_required":   {th: "-neutralize sector ต้องระบุไฟล์กลุ่มอุตสาหกรรมด้วย -sectors", en: "-neutralize sector requires a sector file (-sectors)"},
+	"transform.read_sectors":       {th: "อ่านไฟล์กลุ่มอุตสาหกรรมไม่สำเร็จที่บรรทัด %d (ต้องมีสองคอลัมน์ หุ้น,กลุ่ม)", en: "cannot read sector file at line %d (expected two columns: symbol,sector)"},
+	"transform.write":              {th: "เขียนไฟล์การปรับ feature ไม่สำเร็จ: %v", en: "failed to write transform file: %v"},
+	"transform.read":               {th: "อ่านไฟล์การปรับ feature ไม่สำเร็จ: %v", en: "failed to read transform file: %v"},
+
+	// การเติมค่าที่ขาด
+	"impute.invalid_strategy":  {th: "ไม่รู้จักวิธีเติมค่า: %s (ใช้ ffill, median, drop)", en: "unknown imputation strategy: %s (use ffill, median, drop)"},
+	"impute.drop_last":         {th: "drop ต้องเป็นวิธีสุดท้ายใน -impute: %s", en: "drop must be the last strategy in -impute: %s"},
+	"impute.invalid_staleness": {th: "-impute-max-age ต้องไม่ติดลบ: %s", en: "-impute-max-age must not be negative: %s"},
+	"impute.invalid_median_by": {th: "ไม่รู้จักกลุ่มของ median: %s (ใช้ period หรือ sector)", en: "unknown median grouping: %s (use period or sector)"},
+	"impute.summary":           {th: "เติมค่าที่ขาด %d จาก %d ค่า (ffill %d, median %d) ยังขาดอยู่ %d\n", en: "Imputed %d of %d missing values (ffill %d, median %d); %d still missing\n"},
+	"impute.column":            {th: "  %-28s ขาด %5.1f%%  ffill %d  median %d\n", en: "  %-28s missing %5.1f%%  ffill %d  median %d\n"},
+	"impute.header":            {th: "%s (ค่าที่เติม)", en: "%s (Imputed)"},
+
+	// การอ่าน training matrix
+	"matrix.read_row":       {th: "อ่าน matrix ไม่สำเร็จที่แถว %d: %v", en: "cannot read matrix row %d: %v"},
+	"matrix.invalid_period": {th: "รูปแบบไตรมาสไม่ถูกต้อง: %s (ใช้ เช่น 2023Q4)", en: "invalid period: %s (expected e.g. 2023Q4)"},
+
+	// การฝึกและใช้โมเดล
+	"train.usage":                {th: "วิธีใช้: stock-predict train [flags] <matrix> (ไฟล์ csv/parquet จากคำสั่ง matrix)", en: "usage: stock-predict train [flags] <matrix> (csv/parquet file from the matrix command)"},
+	"predict.usage":              {th: "วิธีใช้: stock-predict predict [flags] <โมเดล.json> <matrix>", en: "usage: stock-predict predict [flags] <model.json> <matrix>"},
+	"predict.impute_mismatch":    {th: "คำเตือน: การเติมค่าที่ขาดของ matrix ไม่ตรงกับที่ใช้ฝึกโมเดล\n", en: "Warning: the matrix imputation differs from the one the model was trained with\n"},
+	"predict.stale_excluded":     {th: "ข้ามหุ้น %d รายการที่ไม่มีข้อมูลในไตรมาสล่าสุด (%s)\n", en: "Skipped %d symbols with no row in the latest quarter (%s)\n"},
+	"predict.transform_applied":  {th: "ปรับ feature ของ matrix ด้วยการตั้งค่าเดียวกับที่ใช้ฝึกโมเดล\n", en: "Applied the model's training feature transform to the matrix\n"},
+	"predict.transform_mismatch": {th: "คำเตือน: การปรับ feature ของ matrix ไม่ตรงกับที่ใช้ฝึกโมเดล\n", en: "Warning: the matrix feature transform differs from the one the model was trained with\n"},
+	"train.holdout":              {th: "ใช้แถว train ช่วงท้าย %d แถวเป็น validation ของ early stopping ฝึกด้วย %d แถว\n", en: "Using the latest %d training rows for early stopping; training on %d rows\n"},
+	"cv.usage":                   {th: "วิธีใช้: stock-predict cv [flags] <matrix> (ไฟล์ csv/parquet จากคำสั่ง matrix)", en: "usage: stock-predict cv [flags] <matrix> (csv/parquet file from the matrix command)"},
+	"cv.not_enough_periods":      {th: "มีข้อมูล %d วันที่ ไม่พอสำหรับ %d fold หลังข้อมูล train ขั้นต่ำ %d วันที่", en: "%d snapshot dates are not enough for %d folds after %d minimum training periods"},
+	"cv.fold_skipped":            {th: "ข้าม fold %d (%s) เพราะไม่มีข้อมูล train หลังตัดช่วง purge/embargo\n", en: "Skipping fold %d (%s): no training rows left after purge/embargo\n"},
+	"cv.fold":                    {th: "fold %d: train %s ถึง %s (%d แถว) ทดสอบ %s ถึง %s (%d แถว)\n", en: "Fold %d: train %s to %s (%d rows), test %s to %s (%d rows)\n"},
+	"cv.invalid_param":           {th: "ค่าใน -grid ไม่ถูกต้อง: %s (ใช้ ชื่อflag=ค่า,ค่า;... เช่น learning-rate=0.03,0.1)", en: "invalid -grid entry: %s (use flag=value,value;... e.g. learning-rate=0.03,0.1)"},
+	"cv.invalid_search":          {th: "ไม่รู้จักวิธีค้นหา: %s (ใช้ grid หรือ random)", en: "unknown search: %s (use grid or random)"},
+	"cv.invalid_trials":          {th: "-trials ต้องมากกว่าศูนย์: %d", en: "-trials must be positive: %d"},
+	"cv.invalid_metric":          {th: "ไม่รู้จักตัวชี้วัด: %s (ใช้ rank_ic, top_decile_return, rmse, auc, logloss)", en: "unknown metric: %s (use rank_ic, top_decile_return, rmse, auc, logloss)"},
+	"cv.candidate":               {th: "[%d/%d] %s  %s=%s\n", en: "[%d/%d] %s  %s=%s\n"},
+	"cv.no_score":                {th: "ไม่มีการตั้งค่าใดคำนวณ %s ได้", en: "no configuration produced a %s score"},
+	"cv.best":                    {th: "การตั้งค่าที่ดีที่สุด #%d %s (%s=%s)\nฝึกด้วย: %s\n", en: "Best configuration #%d %s (%s=%s)\nTrain with: %s\n"},
+	"cv.write":                   {th: "เขียนผลการค้นหาไม่สำเร็จ: %v", en: "failed to write search result: %v"},
+	"model.unknown_method":       {th: "ไม่รู้จักชนิดของโมเดล: %s (ใช้ ols, ridge, lasso, elasticnet, logistic, gbdt)", en: "unknown model: %s (use ols, ridge, lasso, elasticnet, logistic, gbdt)"},
+	"model.unknown_task":         {th: "ไม่รู้จักประเภทของปัญหา: %s (ใช้ regression หรือ classification)", en: "unknown task: %s (use regression or classification)"},
+	"model.task_mismatch":        {th: "โมเดล %s ใช้กับ %s ไม่ได้ (classification ใช้ logistic หรือ gbdt)", en: "model %s cannot be used for %s (use logistic or gbdt for classification)"},
+	"model.unknown_objective":    {th: "ไม่รู้จัก objective ของ gbdt: %s", en: "unknown gbdt objective: %s"},
+	"model.invalid_gbdt":         {th: "การตั้งค่า gbdt ไม่ถูกต้อง (-trees, -max-depth, -min-leaf, -learning-rate ต้องมากกว่าศูนย์, -subsample และ -colsample อยู่ในช่วง (0, 1], -bins อย่างน้อย 2)", en: "invalid gbdt settings (-trees, -max-depth, -min-leaf, -learning-rate must be positive; -subsample and -colsample in (0, 1]; -bins at least 2)"},
+	"model.trees":                {th: "ใช้ต้นไม้ %d จาก %d ต้น\n", en: "Kept %d of %d trees\n"},
+	"model.importance":           {th: "  %-28s gain %5.1f%%  แบ่ง %d ครั้ง\n", en: "  %-28s gain %5.1f%%  %d splits\n"},
+	"model.invalid_penalty":      {th: "ค่า regularization ไม่ถูกต้อง: lambda %g alpha %g (lambda ต้องไม่ติดลบ alpha อยู่ระหว่าง 0 ถึง 1)", en: "invalid regularization: lambda %g alpha %g (lambda must not be negative, alpha must be between 0 and 1)"},
+	"model.no_rows":              {th: "ไม่มีแถว train ที่มีค่าเป้าหมาย", en: "no training rows with a target value"},
+	"model.binary_target":        {th: "classification ต้องใช้เป้าหมายที่เป็น 0 หรือ 1 (เช่น label_beatsBenchmark60, label_topQuintile60)", en: "classification requires a 0/1 target (e.g. label_beatsBenchmark60, label_topQuintile60)"},
+	"model.unknown_target":       {th: "ไม่พบคอลัมน์เป้าหมาย: %s (ต้องเป็นคอลัมน์ label_*)", en: "target column not found: %s (must be a label_* column)"},
+	"model.unknown_feature":      {th: "ไม่พบ feature หรือกลุ่ม: %s", en: "unknown feature or group: %s"},
+	"model.label_feature":        {th: "ใช้คอลัมน์ label เป็น feature ไม่ได้: %s", en: "label columns cannot be features: %s"},
+	"model.no_features":          {th: "ไม่มี feature ที่จะใช้ฝึก", en: "no features selected"},
+	"model.missing_feature":      {th: "matrix ไม่มี feature ที่โมเดลใช้: %s", en: "matrix is missing a model feature: %s"},
+	"model.unknown_kind":         {th: "ไม่รู้จักชนิดของโมเดลในไฟล์: %s", en: "unknown model kind in file: %s"},
+	"model.write":                {th: "เขียนไฟล์โมเดลไม่สำเร็จ: %v", en: "failed to write model file: %v"},
+	"model.read":                 {th: "อ่านไฟล์โมเดลไม่สำเร็จ: %v", en: "failed to read model file: %v"},
+	"model.saved":                {th: "บันทึกโมเดลที่ %s\n", en: "Model saved to %s\n"},
+	"model.metrics":              {th: "  %-5s %6d แถว  %s\n", en: "  %-5s %6d rows  %s\n"},
+	"model.coefficients":         {th: "สัมประสิทธิ์ที่ไม่เป็นศูนย์ %d จาก %d (%d รอบ)\n", en: "%d of %d coefficients non-zero (%d iterations)\n"},
+	"model.coefficient":          {th: "  %-28s %+.4f\n", en: "  %-28s %+.4f\n"},
+
+	// ปลายทางของไฟล์ส่งออก
+	"sink.compress_binary": {th: "รูปแบบ %s บีบอัดในไฟล์อยู่แล้ว ไม่รองรับ .gz หรือ .zst", en: "%s is already compressed internally, .gz and .zst are not supported"},
+	"sink.stdout_single":   {th: "ส่งออกทาง stdout (-out -) เลือกได้เพียงรูปแบบเดียว", en: "only one format can be written to stdout (-out -)"},
+
+	// การกำหนดความละเอียดของตัวเลข
+	"precision.invalid": {th: "รูปแบบความละเอียดตัวเลขไม่ถูกต้อง: %s (ใช้ raw, auto, fixed:N, thousands[:N], millions[:N], percent[:N])", en: "invalid precision rule: %s (use raw, auto, fixed:N, thousands[:N], millions[:N], percent[:N])"},
+
+	// การกำหนดคอลัมน์
+	"columns.read_file":  {th: "ไม่สามารถอ่านไฟล์กำหนดคอลัมน์: %v", en: "cannot read column layout file: %v"},
+	"columns.parse_file": {th: "แปลงไฟล์กำหนดคอลัมน์ไม่สำเร็จ: %v", en: "cannot parse column layout file: %v"},
+	"columns.empty":      {th: "ไม่ได้เลือกคอลัมน์สำหรับส่งออก", en: "no columns selected for export"},
+	"columns.unknown":    {th: "ไม่รู้จักคอลัมน์: %s", en: "unknown columns: %s"},
+}
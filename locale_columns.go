@@ -0,0 +1,221 @@
+package main
+
+// columnNames - ชื่อคอลัมน์ภาษาไทยและภาษาอังกฤษ ครอบคลุมทุกคอลัมน์ที่ส่งออกได้
+var columnNames = map[string]text{
+	// ข้อมูลงวด
+	"Symbol":                 {th: "หุ้น", en: "Symbol"},
+	"Year":                   {th: "ปี", en: "Year"},
+	"Quarter":                {th: "ไตรมาส", en: "Quarter"},
+	"FinancialStatementType": {th: "ประเภทงบการเงิน", en: "Statement Type"},
+	"DateAsof":               {th: "ณ วันที่", en: "Date As Of"},
+	"AccountPeriod":          {th: "รอบบัญชี", en: "Account Period"},
+	"CalendarYear":           {th: "ปีปฏิทิน", en: "Calendar Year"},
+	"CalendarQuarter":        {th: "ไตรมาสปฏิทิน", en: "Calendar Quarter"},
+	"FiscalYearEndMonth":     {th: "เดือนปิดงบ", en: "Fiscal Year End Month"},
+	"FiscalShift":            {th: "ส่วนต่างไตรมาสปีบัญชี", en: "Fiscal Quarter Shift"},
+
+	// งบแสดงฐานะการเงิน
+	"TotalAssets":        {th: "สินทรัพย์รวม", en: "Total Assets"},
+	"TotalLiabilities":   {th: "หนี้สินรวม", en: "Total Liabilities"},
+	"PaidupShareCapital": {th: "ทุนชำระแล้ว", en: "Paid-up Share Capital"},
+	"ShareholderEquity":  {th: "ส่วนของผู้ถือหุ้น", en: "Shareholders' Equity"},
+	"TotalEquity":        {th: "ส่วนของผู้ถือหุ้นรวม", en: "Total Equity"},
+
+	// งบกำไรขาดทุน
+	"TotalRevenueQuarter":  {th: "รายได้รวม (ไตรมาส)", en: "Total Revenue (Quarter)"},
+	"TotalRevenueAccum":    {th: "รายได้รวม (สะสม)", en: "Total Revenue (YTD)"},
+	"TotalExpensesQuarter": {th: "ค่าใช้จ่ายรวม (ไตรมาส)", en: "Total Expenses (Quarter)"},
+	"TotalExpensesAccum":   {th: "ค่าใช้จ่ายรวม (สะสม)", en: "Total Expenses (YTD)"},
+	"EbitQuarter":          {th: "EBIT (ไตรมาส)", en: "EBIT (Quarter)"},
+	"EbitAccum":            {th: "EBIT (สะสม)", en: "EBIT (YTD)"},
+	"NetProfitQuarter":     {th: "กำไรสุทธิ (ไตรมาส)", en: "Net Profit (Quarter)"},
+	"NetProfitAccum":       {th: "กำไรสุทธิ (สะสม)", en: "Net Profit (YTD)"},
+	"EpsQuarter":           {th: "กำไรต่อหุ้น (ไตรมาส)", en: "EPS (Quarter)"},
+	"EpsAccum":             {th: "กำไรต่อหุ้น (สะสม)", en: "EPS (YTD)"},
+
+	// งบกระแสเงินสด
+	"OperatingCashFlow": {th: "กระแสเงินสดจากการดำเนินงาน", en: "Operating Cash Flow"},
+	"InvestingCashFlow": {th: "กระแสเงินสดจากการลงทุน", en: "Investing Cash Flow"},
+	"FinancingCashFlow": {th: "กระแสเงินสดจากการจัดหาเงิน", en: "Financing Cash Flow"},
+
+	// อัตราส่วนทางการเงิน
+	"ROE":                    {th: "อัตราผลตอบแทนส่วนของผู้ถือหุ้น", en: "ROE (%)"},
+	"ROA":                    {th: "อัตราผลตอบแทนจากสินทรัพย์", en: "ROA (%)"},
+	"NetProfitMarginQuarter": {th: "อัตรากำไรสุทธิ (ไตรมาส)", en: "Net Profit Margin (Quarter, %)"},
+	"NetProfitMarginAccum":   {th: "อัตรากำไรสุทธิ (สะสม)", en: "Net Profit Margin (YTD, %)"},
+	"DE":                     {th: "อัตราส่วนหนี้สินต่อส่วนของผู้ถือหุ้น", en: "Debt to Equity"},
+	"FixedAssetTurnover":     {th: "อัตราการหมุนของสินทรัพย์ถาวร", en: "Fixed Asset Turnover"},
+	"TotalAssetTurnover":     {th: "อัตราการหมุนของสินทรัพย์รวม", en: "Total Asset Turnover"},
+
+	// ที่มาของข้อมูล (lineage)
+	"LineageRunID":              {th: "รหัสการรัน", en: "Run ID"},
+	"LineageEndpoint":           {th: "API งบการเงิน", en: "Financial Endpoint"},
+	"LineageParams":             {th: "พารามิเตอร์งบการเงิน", en: "Financial Request Params"},
+	"LineageFetchedAt":          {th: "เวลาที่ดึงงบการเงิน", en: "Financial Fetched At"},
+	"LineageResponseHash":       {th: "Hash ของงบการเงิน", en: "Financial Response Hash"},
+	"LineagePriceEndpoint":      {th: "API ราคา", en: "Price Endpoint"},
+	"LineagePriceParams":        {th: "พารามิเตอร์ราคา", en: "Price Request Params"},
+	"LineagePriceFetchedAt":     {th: "เวลาที่ดึงราคา", en: "Price Fetched At"},
+	"LineagePriceResponseHash":  {th: "Hash ของราคา", en: "Price Response Hash"},
+	"LineagePriceRequestedDate": {th: "วันที่ขอราคา", en: "Price Requested Date"},
+	"LineagePriceDate":          {th: "วันที่ของราคาที่ใช้", en: "Price Date Used"},
+	"LineagePriceExactDate":     {th: "ราคาตรงวันสิ้นไตรมาส", en: "Price On Quarter End"},
+
+	// ราคา ณ สิ้นไตรมาส
+	"price_date":              {th: "วันที่ราคา", en: "Price Date"},
+	"price_symbol":            {th: "หุ้น (ราคา)", en: "Price Symbol"},
+	"price_securityType":      {th: "ประเภทหลักทรัพย์", en: "Security Type"},
+	"price_adjustedPriceFlag": {th: "ราคาปรับปรุง", en: "Adjusted Price Flag"},
+	"price_prior":             {th: "ราคาปิดก่อนหน้า", en: "Prior Close"},
+	"price_open":              {th: "ราคาเปิด", en: "Open"},
+	"price_high":              {th: "ราคาสูงสุด", en: "High"},
+	"price_low":               {th: "ราคาต่ำสุด", en: "Low"},
+	"price_close":             {th: "ราคาปิด", en: "Close"},
+	"price_average":           {th: "ราคาเฉลี่ย", en: "Average Price"},
+	"price_aomVolume":         {th: "ปริมาณซื้อขาย (AOM)", en: "AOM Volume"},
+	"price_aomValue":          {th: "มูลค่าซื้อขาย (AOM)", en: "AOM Value"},
+	"price_trVolume":          {th: "ปริมาณซื้อขาย (Trade Report)", en: "Trade Report Volume"},
+	"price_trValue":           {th: "มูลค่าซื้อขาย (Trade Report)", en: "Trade Report Value"},
+	"price_totalVolume":       {th: "ปริมาณซื้อขายรวม", en: "Total Volume"},
+	"price_totalValue":        {th: "มูลค่าซื้อขายรวม", en: "Total Value"},
+	"price_pe":                {th: "P/E", en: "P/E"},
+	"price_pbv":               {th: "P/BV", en: "P/BV"},
+	"price_bvps":              {th: "มูลค่าหุ้นตามบัญชีต่อหุ้น", en: "Book Value per Share"},
+	"price_dividendYield":     {th: "อัตราเงินปันผลตอบแทน", en: "Dividend Yield (%)"},
+	"price_marketCap":         {th: "มูลค่าตลาด", en: "Market Cap"},
+	"price_volumeTurnover":    {th: "อัตราการหมุนเวียนการซื้อขาย", en: "Volume Turnover"},
+
+	// ตัวชี้วัดที่คำนวณเพิ่ม (derivedMetrics)
+	"derived_equityRatio":         {th: "สัดส่วนส่วนของผู้ถือหุ้นต่อสินทรัพย์", en: "Equity Ratio"},
+	"derived_expenseRatioQuarter": {th: "สัดส่วนค่าใช้จ่ายต่อรายได้ (ไตรมาส)", en: "Expense Ratio (Quarter)"},
+	"derived_epsTTM":              {th: "กำไรต่อหุ้นย้อนหลัง 12 เดือน", en: "EPS (TTM)"},
+	"derived_peTTM":               {th: "P/E (EPS ย้อนหลัง 12 เดือน)", en: "P/E (TTM)"},
+	"derived_earningsYield":       {th: "อัตราผลตอบแทนจากกำไร", en: "Earnings Yield"},
+	"derived_pbvFromEquity":       {th: "P/BV (มูลค่าตลาด / ส่วนของผู้ถือหุ้น)", en: "P/BV (Market Cap / Equity)"},
+	"derived_freeCashFlow":        {th: "กระแสเงินสดอิสระ", en: "Free Cash Flow"},
+	"derived_fcfYield":            {th: "อัตราผลตอบแทนกระแสเงินสดอิสระ", en: "FCF Yield"},
+	"derived_accrualsRatio":       {th: "สัดส่วนรายการคงค้าง", en: "Accruals Ratio"},
+	"derived_sharesOutstanding":   {th: "จำนวนหุ้นโดยประมาณ", en: "Implied Shares Outstanding"},
+	"derived_bookValuePerShare":   {th: "มูลค่าตามบัญชีต่อหุ้น (คำนวณ)", en: "Book Value per Share (Derived)"},
+
+	// การเติบโต (growthMetrics)
+	"derived_revenueQoQ":              {th: "การเติบโตรายได้ (เทียบไตรมาสก่อน)", en: "Revenue Growth (QoQ)"},
+	"derived_revenueYoY":              {th: "การเติบโตรายได้ (เทียบไตรมาสเดียวกันปีก่อน)", en: "Revenue Growth (YoY)"},
+	"derived_revenueTTMYoY":           {th: "การเติบโตรายได้ (12 เดือนล่าสุดเทียบปีก่อน)", en: "Revenue Growth (TTM YoY)"},
+	"derived_revenueCAGR3Y":           {th: "การเติบโตรายได้ (เฉลี่ยต่อปี 3 ปี)", en: "Revenue Growth (3Y CAGR)"},
+	"derived_ebitQoQ":                 {th: "การเติบโต EBIT (เทียบไตรมาสก่อน)", en: "EBIT Growth (QoQ)"},
+	"derived_ebitYoY":                 {th: "การเติบโต EBIT (เทียบไตรมาสเดียวกันปีก่อน)", en: "EBIT Growth (YoY)"},
+	"derived_ebitTTMYoY":              {th: "การเติบโต EBIT (12 เดือนล่าสุดเทียบปีก่อน)", en: "EBIT Growth (TTM YoY)"},
+	"derived_ebitCAGR3Y":              {th: "การเติบโต EBIT (เฉลี่ยต่อปี 3 ปี)", en: "EBIT Growth (3Y CAGR)"},
+	"derived_netProfitQoQ":            {th: "การเติบโตกำไรสุทธิ (เทียบไตรมาสก่อน)", en: "Net Profit Growth (QoQ)"},
+	"derived_netProfitYoY":            {th: "การเติบโตกำไรสุทธิ (เทียบไตรมาสเดียวกันปีก่อน)", en: "Net Profit Growth (YoY)"},
+	"derived_netProfitTTMYoY":         {th: "การเติบโตกำไรสุทธิ (12 เดือนล่าสุดเทียบปีก่อน)", en: "Net Profit Growth (TTM YoY)"},
+	"derived_netProfitCAGR3Y":         {th: "การเติบโตกำไรสุทธิ (เฉลี่ยต่อปี 3 ปี)", en: "Net Profit Growth (3Y CAGR)"},
+	"derived_epsQoQ":                  {th: "การเติบโต EPS (เทียบไตรมาสก่อน)", en: "EPS Growth (QoQ)"},
+	"derived_epsYoY":                  {th: "การเติบโต EPS (เทียบไตรมาสเดียวกันปีก่อน)", en: "EPS Growth (YoY)"},
+	"derived_epsTTMYoY":               {th: "การเติบโต EPS (12 เดือนล่าสุดเทียบปีก่อน)", en: "EPS Growth (TTM YoY)"},
+	"derived_epsCAGR3Y":               {th: "การเติบโต EPS (เฉลี่ยต่อปี 3 ปี)", en: "EPS Growth (3Y CAGR)"},
+	"derived_equityQoQ":               {th: "การเติบโตส่วนของผู้ถือหุ้น (เทียบไตรมาสก่อน)", en: "Equity Growth (QoQ)"},
+	"derived_equityYoY":               {th: "การเติบโตส่วนของผู้ถือหุ้น (เทียบไตรมาสเดียวกันปีก่อน)", en: "Equity Growth (YoY)"},
+	"derived_equityCAGR3Y":            {th: "การเติบโตส่วนของผู้ถือหุ้น (เฉลี่ยต่อปี 3 ปี)", en: "Equity Growth (3Y CAGR)"},
+	"derived_operatingCashFlowQoQ":    {th: "การเติบโตกระแสเงินสดจากการดำเนินงาน (เทียบไตรมาสก่อน)", en: "Operating Cash Flow Growth (QoQ)"},
+	"derived_operatingCashFlowYoY":    {th: "การเติบโตกระแสเงินสดจากการดำเนินงาน (เทียบไตรมาสเดียวกันปีก่อน)", en: "Operating Cash Flow Growth (YoY)"},
+	"derived_operatingCashFlowTTMYoY": {th: "การเติบโตกระแสเงินสดจากการดำเนินงาน (12 เดือนล่าสุดเทียบปีก่อน)", en: "Operating Cash Flow Growth (TTM YoY)"},
+	"derived_operatingCashFlowCAGR3Y": {th: "การเติบโตกระแสเงินสดจากการดำเนินงาน (เฉลี่ยต่อปี 3 ปี)", en: "Operating Cash Flow Growth (3Y CAGR)"},
+
+	// ตัวชี้วัดทางเทคนิค (technicalIndicators)
+	"tech_sma20":           {th: "ค่าเฉลี่ยเคลื่อนที่ 20 วัน", en: "SMA 20"},
+	"tech_sma50":           {th: "ค่าเฉลี่ยเคลื่อนที่ 50 วัน", en: "SMA 50"},
+	"tech_sma200":          {th: "ค่าเฉลี่ยเคลื่อนที่ 200 วัน", en: "SMA 200"},
+	"tech_ema12":           {th: "ค่าเฉลี่ยเคลื่อนที่แบบ EMA 12 วัน", en: "EMA 12"},
+	"tech_ema26":           {th: "ค่าเฉลี่ยเคลื่อนที่แบบ EMA 26 วัน", en: "EMA 26"},
+	"tech_rsi14":           {th: "RSI 14 วัน", en: "RSI 14"},
+	"tech_macd":            {th: "MACD", en: "MACD"},
+	"tech_macdSignal":      {th: "เส้นสัญญาณ MACD", en: "MACD Signal"},
+	"tech_macdHist":        {th: "MACD Histogram", en: "MACD Histogram"},
+	"tech_bbUpper":         {th: "Bollinger Band บน", en: "Bollinger Upper"},
+	"tech_bbLower":         {th: "Bollinger Band ล่าง", en: "Bollinger Lower"},
+	"tech_bbPercentB":      {th: "ตำแหน่งใน Bollinger Band (%B)", en: "Bollinger %B"},
+	"tech_atr14":           {th: "ATR 14 วัน", en: "ATR 14"},
+	"tech_roc20":           {th: "อัตราการเปลี่ยนแปลงราคา 20 วัน", en: "Rate of Change 20"},
+	"tech_roc60":           {th: "อัตราการเปลี่ยนแปลงราคา 60 วัน", en: "Rate of Change 60"},
+	"tech_volatility20":    {th: "ความผันผวน 20 วัน (ต่อปี)", en: "Volatility 20 (Annualised)"},
+	"tech_volumeZ20":       {th: "Z-score ปริมาณซื้อขาย 20 วัน", en: "Volume Z-score 20"},
+	"tech_high52wDistance": {th: "ระยะห่างจากราคาสูงสุด 52 สัปดาห์", en: "Distance from 52-week High"},
+	"tech_low52wDistance":  {th: "ระยะห่างจากราคาต่ำสุด 52 สัปดาห์", en: "Distance from 52-week Low"},
+	"tech_obv":             {th: "On-Balance Volume", en: "On-Balance Volume"},
+
+	// คอลัมน์ของชีตสรุปและชีตข้อผิดพลาด
+	"Quarters":      {th: "จำนวนไตรมาส", en: "Quarters"},
+	"FirstPeriod":   {th: "งวดแรก", en: "First Period"},
+	"LastPeriod":    {th: "งวดล่าสุด", en: "Last Period"},
+	"MissingPrices": {th: "ไตรมาสที่ไม่มีราคา", en: "Missing Prices"},
+	"Stage":         {th: "ขั้นตอน", en: "Stage"},
+	"Message":       {th: "ข้อความ", en: "Message"},
+
+	// คอลัมน์ของรายงานการตรวจสอบข้อมูล
+	"Rule":         {th: "กฎ", en: "Rule"},
+	"Severity":     {th: "ความรุนแรง", en: "Severity"},
+	"Expected":     {th: "ค่าที่ควรเป็น", en: "Expected"},
+	"Actual":       {th: "ค่าจริง", en: "Actual"},
+	"Detail":       {th: "รายละเอียด", en: "Detail"},
+	"Checks":       {th: "จำนวนการตรวจ", en: "Checks"},
+	"Errors":       {th: "ข้อผิดพลาด", en: "Errors"},
+	"Warnings":     {th: "คำเตือน", en: "Warnings"},
+	"QualityScore": {th: "คะแนนคุณภาพ", en: "Quality Score"},
+
+	// คอลัมน์ของ training matrix
+	"SnapshotDate":             {th: "วันที่ของข้อมูล", en: "Snapshot Date"},
+	"FundamentalPeriod":        {th: "งวดของงบที่ใช้", en: "Fundamental Period"},
+	"FundamentalAvailableDate": {th: "วันที่งบเผยแพร่", en: "Fundamental Available Date"},
+	"FundamentalAgeDays":       {th: "อายุของงบ (วัน)", en: "Fundamental Age (days)"},
+	"Split":                    {th: "ชุดข้อมูล", en: "Split"},
+
+	// คอลัมน์ของสรุปการเติมค่า
+	"Column":        {th: "คอลัมน์", en: "Column"},
+	"Missing":       {th: "ค่าที่ขาด", en: "Missing"},
+	"MissingShare":  {th: "สัดส่วนที่ขาด", en: "Missing Share"},
+	"ForwardFilled": {th: "เติมจากงวดก่อน", en: "Forward Filled"},
+	"MedianFilled":  {th: "เติมด้วยมัธยฐาน", en: "Median Filled"},
+	"StillMissing":  {th: "ยังขาดอยู่", en: "Still Missing"},
+
+	// คอลัมน์ของรายงานโมเดล
+	"Feature":        {th: "feature", en: "Feature"},
+	"Coefficient":    {th: "สัมประสิทธิ์ (standardize)", en: "Coefficient (Standardized)"},
+	"RawCoefficient": {th: "สัมประสิทธิ์ (หน่วยเดิม)", en: "Coefficient (Raw Units)"},
+	"Mean":           {th: "ค่าเฉลี่ย", en: "Mean"},
+	"Std":            {th: "ส่วนเบี่ยงเบนมาตรฐาน", en: "Std. Dev."},
+	"Gain":           {th: "gain รวม", en: "Total Gain"},
+	"GainShare":      {th: "สัดส่วน gain", en: "Gain Share"},
+	"Splits":         {th: "จำนวนครั้งที่ใช้แบ่ง", en: "Splits"},
+	"Score":          {th: "คะแนนที่ทำนาย", en: "Predicted Score"},
+	"Rank":           {th: "อันดับ", en: "Rank"},
+
+	// คอลัมน์ของรายงาน cross-validation
+	"Candidate":       {th: "ชุดการตั้งค่า", en: "Candidate"},
+	"Params":          {th: "ค่าที่ลอง", en: "Parameters"},
+	"Fold":            {th: "fold", en: "Fold"},
+	"TrainStart":      {th: "train เริ่ม", en: "Train Start"},
+	"TrainEnd":        {th: "train สิ้นสุด", en: "Train End"},
+	"TestStart":       {th: "ทดสอบเริ่ม", en: "Test Start"},
+	"TestEnd":         {th: "ทดสอบสิ้นสุด", en: "Test End"},
+	"TrainRows":       {th: "แถว train", en: "Train Rows"},
+	"TestRows":        {th: "แถวทดสอบ", en: "Test Rows"},
+	"RMSE":            {th: "RMSE", en: "RMSE"},
+	"AUC":             {th: "AUC", en: "AUC"},
+	"LogLoss":         {th: "log loss", en: "Log Loss"},
+	"RankIC":          {th: "rank IC", en: "Rank IC"},
+	"TopDecileReturn": {th: "ผลตอบแทน decile บนสุด", en: "Top Decile Return"},
+
+	// คอลัมน์ของรายงานความครบถ้วน
+	"MissingFinancials": {th: "ไตรมาสที่ไม่มีงบ", en: "Missing Financials"},
+	"MissingPeriods":    {th: "งวดที่ขาด", en: "Missing Periods"},
+}
+
+// sheetNames - ชื่อชีตในไฟล์ Excel
+var sheetNames = map[string]text{
+	sheetFinancials: {th: "งบการเงิน", en: "Financials"},
+	sheetPrices:     {th: "ราคาสิ้นไตรมาส", en: "Price Snapshots"},
+	sheetSummary:    {th: "สรุปรายหุ้น", en: "Summary"},
+	sheetErrors:     {th: "ข้อผิดพลาด", en: "Fetch Errors"},
+}
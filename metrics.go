@@ -0,0 +1,165 @@
+package main
+
+import "sort"
+
+// derivedMetric - ตัวชี้วัดที่คำนวณจากข้อมูลงบการเงินและราคา
+// history คืองวดก่อนหน้าของหุ้นและประเภทงบเดียวกัน เรียงจากใหม่ไปเก่า (ไม่รวม item)
+// Compute คืน false เมื่อคำนวณไม่ได้ (เช่น ตัวหารเป็นศูนย์หรือไม่มีราคา) ค่านั้นจะไม่ถูกส่งออก
+type derivedMetric struct {
+	Name    string
+	Compute func(item FinancialData, history []FinancialData) (float64, bool)
+}
+
+// derivedMetrics - รายการตัวชี้วัดที่คำนวณเพิ่ม เพิ่มรายการที่นี่แล้วจะปรากฏในไฟล์ long
+// และเป็นคอลัมน์ derived_<ชื่อ> ที่เลือกได้ด้วย -columns โดยอัตโนมัติ
+// ตัวชี้วัดการเติบโตต่อท้ายรายการจาก growthMetrics (growth.go)
+//
+// จำนวนเงินในงบการเงินและมูลค่าตลาดเป็นหน่วยบาททั้งคู่ จึงหารกันได้โดยตรง
+// กระแสเงินสดเป็นยอดสะสมตั้งแต่ต้นปีบัญชี จึงปรับเป็นรายปีก่อนเทียบกับมูลค่าตลาด
+var derivedMetrics = append([]derivedMetric{
+	// ส่วนของผู้ถือหุ้นรวม / สินทรัพย์รวม
+	{Name: "equityRatio", Compute: func(item FinancialData, _ []FinancialData) (float64, bool) {
+		return safeDiv(item.TotalEquity, item.TotalAssets)
+	}},
+	// ค่าใช้จ่ายรวม / รายได้รวม ของไตรมาส
+	{Name: "expenseRatioQuarter", Compute: func(item FinancialData, _ []FinancialData) (float64, bool) {
+		return safeDiv(item.TotalExpensesQuarter, item.TotalRevenueQuarter)
+	}},
+	// กำไรต่อหุ้นย้อนหลัง 12 เดือน: ผลรวม EpsQuarter ของ 4 ไตรมาสปฏิทินติดกันล่าสุด
+	{Name: "epsTTM", Compute: func(item FinancialData, history []FinancialData) (float64, bool) {
+		return trailingSum(item, history, func(d FinancialData) float64 { return d.EpsQuarter })
+	}},
+	// P/E จาก EPS ย้อนหลัง 12 เดือน: ราคาปิด / epsTTM (ไม่คำนวณเมื่อขาดทุน)
+	{Name: "peTTM", Compute: func(item FinancialData, history []FinancialData) (float64, bool) {
+		eps, ok := trailingSum(item, history, func(d FinancialData) float64 { return d.EpsQuarter })
+		closePrice, hasPrice := nonZeroPrice(item, "close")
+		if !ok || !hasPrice || eps <= 0 {
+			return 0, false
+		}
+		return closePrice / eps, true
+	}},
+	// ผลตอบแทนจากกำไร: epsTTM / ราคาปิด (ส่วนกลับของ P/E แต่ใช้ได้แม้ขาดทุน)
+	{Name: "earningsYield", Compute: func(item FinancialData, history []FinancialData) (float64, bool) {
+		eps, ok := trailingSum(item, history, func(d FinancialData) float64 { return d.EpsQuarter })
+		closePrice, hasPrice := nonZeroPrice(item, "close")
+		if !ok || !hasPrice {
+			return 0, false
+		}
+		return safeDiv(eps, closePrice)
+	}},
+	// P/BV จากมูลค่าตลาด / ส่วนของผู้ถือหุ้นบริษัทใหญ่ (ไม่คำนวณเมื่อส่วนของผู้ถือหุ้นติดลบ)
+	{Name: "pbvFromEquity", Compute: func(item FinancialData, _ []FinancialData) (float64, bool) {
+		marketCap, ok := nonZeroPrice(item, "marketCap")
+		if !ok || item.ShareholderEquity <= 0 {
+			return 0, false
+		}
+		return marketCap / item.ShareholderEquity, true
+	}},
+	// กระแสเงินสดอิสระ: กระแสเงินสดจากการดำเนินงาน + กระแสเงินสดจากการลงทุน (ยอดสะสมของงวด)
+	{Name: "freeCashFlow", Compute: func(item FinancialData, _ []FinancialData) (float64, bool) {
+		return freeCashFlow(item), true
+	}},
+	// FCF yield: กระแสเงินสดอิสระที่ปรับเป็นรายปี / มูลค่าตลาด
+	{Name: "fcfYield", Compute: func(item FinancialData, _ []FinancialData) (float64, bool) {
+		marketCap, ok := nonZeroPrice(item, "marketCap")
+		fcf, annual := annualized(item, freeCashFlow(item))
+		if !ok || !annual {
+			return 0, false
+		}
+		return safeDiv(fcf, marketCap)
+	}},
+	// accruals ratio: (กำไรสุทธิสะสม - กระแสเงินสดจากการดำเนินงาน) / สินทรัพย์รวม
+	// ค่าบวกมากหมายถึงกำไรที่ยังไม่เป็นเงินสด
+	{Name: "accrualsRatio", Compute: func(item FinancialData, _ []FinancialData) (float64, bool) {
+		return safeDiv(item.NetProfitAccum-item.OperatingCashFlow, item.TotalAssets)
+	}},
+	// จำนวนหุ้นโดยประมาณ: มูลค่าตลาด / ราคาปิด
+	{Name: "sharesOutstanding", Compute: func(item FinancialData, _ []FinancialData) (float64, bool) {
+		return impliedShares(item)
+	}},
+	// มูลค่าหุ้นตามบัญชีต่อหุ้น: ส่วนของผู้ถือหุ้นบริษัทใหญ่ / จำนวนหุ้นโดยประมาณ
+	{Name: "bookValuePerShare", Compute: func(item FinancialData, _ []FinancialData) (float64, bool) {
+		shares, ok := impliedShares(item)
+		if !ok {
+			return 0, false
+		}
+		return safeDiv(item.ShareholderEquity, shares)
+	}},
+}, growthMetrics()...)
+
+// derivedColumnPrefix - prefix ของคอลัมน์ตัวชี้วัดที่คำนวณเพิ่ม เช่น derived_peTTM
+const derivedColumnPrefix = "derived_"
+
+// computeDerivedMetrics - คำนวณทุกตัวชี้วัดใน derivedMetrics แล้วเก็บไว้ใน Metrics ของแต่ละงวด
+// ข้อมูลไม่ต้องเรียงมาก่อน งวดของหุ้นเดียวกันจะถูกจัดกลุ่มและเรียงตามไตรมาสปฏิทินเพื่อหาค่าย้อนหลัง
+func computeDerivedMetrics(data []FinancialData) {
+	type seriesKey struct{ Symbol, StatementType string }
+	series := make(map[seriesKey][]int)
+	for i, item := range data {
+		key := seriesKey{item.Symbol, item.FinancialStatementType}
+		series[key] = append(series[key], i)
+	}
+
+	for _, indexes := range series {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			return calendarPeriodOf(data[indexes[b]]).Before(calendarPeriodOf(data[indexes[a]]))
+		})
+		history := make([]FinancialData, len(indexes))
+		for i, idx := range indexes {
+			history[i] = data[idx]
+		}
+
+		for i, idx := range indexes {
+			data[idx].Metrics = derivedMetricsOf(history[i], history[i+1:])
+		}
+	}
+}
+
+// derivedMetricsOf - ค่าของทุกตัวชี้วัดใน derivedMetrics ของงวดเดียว history เรียงจากใหม่ไปเก่า
+func derivedMetricsOf(item FinancialData, history []FinancialData) map[string]float64 {
+	metrics := make(map[string]float64)
+	for _, metric := range derivedMetrics {
+		if value, ok := metric.Compute(item, history); ok {
+			metrics[metric.Name] = value
+		}
+	}
+	return metrics
+}
+
+// trailingSum - ผลรวมของ 4 ไตรมาสล่าสุด คืน false ถ้าไตรมาสก่อนหน้าไม่ครบหรือไม่ต่อเนื่อง
+func trailingSum(item FinancialData, history []FinancialData, value func(FinancialData) float64) (float64, bool) {
+	if len(history) < 3 {
+		return 0, false
+	}
+	period := calendarPeriodOf(item)
+	sum := value(item)
+	for i := 0; i < 3; i++ {
+		if calendarPeriodOf(history[i]) != period.Add(-(i + 1)) {
+			return 0, false
+		}
+		sum += value(history[i])
+	}
+	return sum, true
+}
+
+// nonZeroPrice - ค่าจากราคา ณ สิ้นไตรมาสที่ใช้เป็นตัวหารได้ คืน false ถ้าไม่มีราคาหรือเป็นศูนย์
+func nonZeroPrice(item FinancialData, key string) (float64, bool) {
+	v := priceValue(item, key)
+	if v == nil || *v == 0 {
+		return 0, false
+	}
+	return *v, true
+}
+
+func freeCashFlow(item FinancialData) float64 {
+	return item.OperatingCashFlow + item.InvestingCashFlow
+}
+
+func impliedShares(item FinancialData) (float64, bool) {
+	marketCap, ok := nonZeroPrice(item, "marketCap")
+	closePrice, hasPrice := nonZeroPrice(item, "close")
+	if !ok || !hasPrice {
+		return 0, false
+	}
+	return marketCap / closePrice, true
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+// quarterItem - งวดของหุ้นทดสอบในไตรมาสปฏิทิน year/quarter (ปีบัญชีตรงกับปีปฏิทิน)
+func quarterItem(year, quarter int) FinancialData {
+	return FinancialData{
+		Symbol:                 "AAA",
+		Year:                   strconv.Itoa(year),
+		Quarter:                strconv.Itoa(quarter),
+		FinancialStatementType: "C",
+		Calendar:               Period{Year: year, Quarter: quarter},
+	}
+}
+
+func withEps(item FinancialData, eps float64) FinancialData {
+	item.EpsQuarter = eps
+	return item
+}
+
+func withPrice(item FinancialData, closePrice, marketCap float64) FinancialData {
+	item.PriceData = map[string]interface{}{"price_close": closePrice, "price_marketCap": marketCap}
+	return item
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
+
+func TestDerivedMetrics(t *testing.T) {
+	// EPS 4 ไตรมาสติดกัน 3+2+1+4 = 10 ราคาปิด 50 มูลค่าตลาด 1000 (20 หุ้น)
+	history := []FinancialData{withEps(quarterItem(2023, 4), 1), withEps(quarterItem(2023, 3), 2), withEps(quarterItem(2023, 2), 3)}
+	item := withPrice(withEps(quarterItem(2024, 1), 4), 50, 1000)
+	item.ShareholderEquity = 500
+
+	gap := []FinancialData{history[0], history[2]}
+	loss := []FinancialData{withEps(quarterItem(2023, 4), -10), history[1], history[2]}
+	negativeEquity := item
+	negativeEquity.ShareholderEquity = -5
+
+	cashFlow := withPrice(quarterItem(2024, 2), 50, 1000)
+	cashFlow.OperatingCashFlow, cashFlow.InvestingCashFlow = 30, -10
+
+	tests := []struct {
+		name    string
+		item    FinancialData
+		history []FinancialData
+		metric  string
+		want    float64
+		wantOK  bool
+	}{
+		{"epsTTM sums four consecutive quarters", item, history, "epsTTM", 10, true},
+		{"epsTTM needs consecutive quarters", item, gap, "epsTTM", 0, false},
+		{"peTTM", item, history, "peTTM", 5, true},
+		{"peTTM skips losses", item, loss, "peTTM", 0, false},
+		{"earningsYield keeps losses", item, loss, "earningsYield", -0.02, true},
+		{"pbvFromEquity", item, nil, "pbvFromEquity", 2, true},
+		{"pbvFromEquity skips negative equity", negativeEquity, nil, "pbvFromEquity", 0, false},
+		{"sharesOutstanding", item, nil, "sharesOutstanding", 20, true},
+		{"bookValuePerShare", item, nil, "bookValuePerShare", 25, true},
+		{"freeCashFlow", cashFlow, nil, "freeCashFlow", 20, true},
+		// FCF สะสมครึ่งปี 20 ปรับเป็นรายปี 40 / 1000
+		{"fcfYield annualizes year-to-date cash flow", cashFlow, nil, "fcfYield", 0.04, true},
+		{"fcfYield needs a price", quarterItem(2024, 2), nil, "fcfYield", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := derivedMetricsOf(tt.item, tt.history)[tt.metric]
+			if ok != tt.wantOK || (ok && !approxEqual(got, tt.want)) {
+				t.Errorf("%s = %v, %v; want %v, %v", tt.metric, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
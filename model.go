@@ -0,0 +1,553 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Model - โมเดลที่ฝึกแล้ว x เรียงตาม ModelFile.Features (NaN = ไม่มีค่า)
+type Model interface {
+	Predict(x []float64) float64
+}
+
+// ModelFile - ไฟล์ JSON ของโมเดลที่ฝึกแล้ว พร้อมข้อมูลที่ต้องใช้ทำนายซ้ำ
+type ModelFile struct {
+	Kind      string                        `json:"kind"`
+	Task      string                        `json:"task"`
+	Target    string                        `json:"target"`
+	Features  []string                      `json:"features"`
+	TrainedAt time.Time                     `json:"trained_at"`
+	Source    string                        `json:"source"`
+	Rows      map[string]int                `json:"rows"`    // จำนวนแถวที่มีเป้าหมายในแต่ละชุดข้อมูล
+	Metrics   map[string]map[string]float64 `json:"metrics"` // ชุดข้อมูล -> ตัวชี้วัด -> ค่า
+
+	Impute    *ImputeConfig    `json:"impute,omitempty"`    // การเติมค่าของ matrix ที่ใช้ฝึก
+	Transform *TransformConfig `json:"transform,omitempty"` // การปรับ feature ของ matrix ที่ใช้ฝึก
+	Linear    *LinearModel     `json:"linear,omitempty"`
+	GBDT      *GBDTModel       `json:"gbdt,omitempty"`
+}
+
+// ชนิดของโมเดลใน ModelFile.Kind
+const (
+	modelLinear = "linear"
+	modelGBDT   = "gbdt"
+)
+
+// model - โมเดลตามชนิดในไฟล์
+func (f *ModelFile) model() (Model, error) {
+	switch {
+	case f.Kind == modelLinear && f.Linear != nil:
+		return f.Linear, nil
+	case f.Kind == modelGBDT && f.GBDT != nil:
+		return f.GBDT, nil
+	}
+	return nil, errMsg("model.unknown_kind", f.Kind)
+}
+
+// setModel - เก็บโมเดลในไฟล์ตามชนิด
+func (f *ModelFile) setModel(model Model) {
+	switch v := model.(type) {
+	case *LinearModel:
+		f.Kind, f.Linear = modelLinear, v
+	case *GBDTModel:
+		f.Kind, f.GBDT = modelGBDT, v
+	}
+}
+
+// TrainConfig - ชนิดของโมเดลและการตั้งค่าของแต่ละชนิด
+// Model เป็นวิธีของโมเดลเชิงเส้น (ols, ridge, lasso, elasticnet, logistic) หรือ gbdt
+type TrainConfig struct {
+	Model  string       `json:"model"`
+	Task   string       `json:"task"`
+	Linear LinearConfig `json:"linear"`
+	GBDT   GBDTConfig   `json:"gbdt"`
+}
+
+// fitModel - ฝึกโมเดลด้วยแถว train (ตำแหน่งใน m.Rows) valid ใช้เฉพาะ early stopping ของ gbdt
+func fitModel(m TrainingMatrix, features []int, target int, train, valid []int, cfg TrainConfig) (Model, error) {
+	x, y := designRows(m, features, target, train)
+	if cfg.Model != modelGBDT {
+		return FitLinear(x, y, cfg.Linear)
+	}
+	validX, validY := designRows(m, features, target, valid)
+	return FitGBDT(x, y, validX, validY, cfg.GBDT)
+}
+
+// timeOrderedHoldout - แยกแถวที่ใหม่ที่สุดประมาณ fraction ของแถว train เป็นชุด validation
+// แถวของวันที่เดียวกันอยู่ชุดเดียวกัน และตัดแถว train ที่ห่างจากวันแรกของ validation ไม่เกิน embargoDays ทิ้ง
+// (เหมือน embargo ของคำสั่ง matrix) rows ต้องเรียงตามวันที่
+func timeOrderedHoldout(m TrainingMatrix, rows []int, fraction float64, embargoDays int) (train, valid []int) {
+	if fraction <= 0 || len(rows) < 2 {
+		return rows, nil
+	}
+	cut := len(rows) - int(math.Ceil(float64(len(rows))*fraction))
+	start := m.Rows[rows[max(cut, 0)]].SnapshotDate
+	embargo := start.AddDate(0, 0, -embargoDays)
+	for _, r := range rows {
+		date := m.Rows[r].SnapshotDate
+		switch {
+		case !date.Before(start):
+			valid = append(valid, r)
+		case date.Before(embargo):
+			train = append(train, r)
+		}
+	}
+	return train, valid
+}
+
+// SaveModel - เขียนโมเดลเป็น JSON
+func SaveModel(f *ModelFile, filename string) error {
+	body, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return errMsg("model.write", err)
+	}
+	out, err := createOutput(filename)
+	if err != nil {
+		return errMsg("export.create_file", "JSON", err)
+	}
+	defer out.Close()
+	if _, err := out.Write(append(body, '\n')); err != nil {
+		return errMsg("model.write", err)
+	}
+	if err := out.Close(); err != nil {
+		return errMsg("export.save_file", "JSON", err)
+	}
+	logMsg("model.saved", displayPath(filename))
+	return nil
+}
+
+// LoadModel - อ่านโมเดลจากไฟล์ JSON
+func LoadModel(filename string) (*ModelFile, error) {
+	body, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errMsg("model.read", err)
+	}
+	var f ModelFile
+	if err := json.Unmarshal(body, &f); err != nil {
+		return nil, errMsg("model.read", err)
+	}
+	if _, err := f.model(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// selectFeatures - ตำแหน่งของคอลัมน์ feature ตาม spec คั่นด้วยจุลภาค (ชื่อคอลัมน์หรือชื่อกลุ่ม)
+// spec ว่าง = ทุกคอลัมน์ที่ไม่ใช่ label คอลัมน์ label ใช้เป็น feature ไม่ได้
+func selectFeatures(m TrainingMatrix, spec string) ([]int, error) {
+	selected := make(map[int]bool)
+	if strings.TrimSpace(spec) == "" {
+		for i, col := range m.Columns {
+			selected[i] = col.Group != groupLabel
+		}
+	}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		found := false
+		for i, col := range m.Columns {
+			if col.Name == part || col.Group == part {
+				if col.Group == groupLabel {
+					return nil, errMsg("model.label_feature", col.Name)
+				}
+				selected[i] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, errMsg("model.unknown_feature", part)
+		}
+	}
+
+	var features []int
+	for i := range m.Columns {
+		if selected[i] {
+			features = append(features, i)
+		}
+	}
+	if len(features) == 0 {
+		return nil, errMsg("model.no_features")
+	}
+	return features, nil
+}
+
+// featureIndex - ตำแหน่งใน matrix ของ feature ตามชื่อ สำหรับโมเดลที่ฝึกกับ matrix อื่น
+func featureIndex(m TrainingMatrix, names []string) ([]int, error) {
+	index := make([]int, len(names))
+	for i, name := range names {
+		if index[i] = m.columnIndex(name); index[i] < 0 {
+			return nil, errMsg("model.missing_feature", name)
+		}
+	}
+	return index, nil
+}
+
+// splitRows - ตำแหน่งของแถวในชุดข้อมูล split ที่มีค่าเป้าหมาย เรียงตามวันที่
+func splitRows(m TrainingMatrix, target int, split string) []int {
+	var rows []int
+	for i, row := range m.Rows {
+		if row.Split == split && !math.IsNaN(row.Values[target]) {
+			rows = append(rows, i)
+		}
+	}
+	return rows
+}
+
+// designRows - feature และเป้าหมายของแถว rows
+func designRows(m TrainingMatrix, features []int, target int, rows []int) ([][]float64, []float64) {
+	x := make([][]float64, len(rows))
+	y := make([]float64, len(rows))
+	for i, r := range rows {
+		x[i] = featureValues(m.Rows[r], features)
+		y[i] = m.Rows[r].Values[target]
+	}
+	return x, y
+}
+
+func featureValues(row MatrixRow, features []int) []float64 {
+	x := make([]float64, len(features))
+	for j, c := range features {
+		x[j] = row.Values[c]
+	}
+	return x
+}
+
+// evaluateModel - ตัวชี้วัดของโมเดลในทุกชุดข้อมูลที่มีแถว
+func evaluateModel(f *ModelFile, model Model, m TrainingMatrix, features []int, target int) {
+	f.Rows = make(map[string]int)
+	f.Metrics = make(map[string]map[string]float64)
+	for _, split := range splitNames[2] {
+		x, y := designRows(m, features, target, splitRows(m, target, split))
+		if len(y) == 0 {
+			continue
+		}
+		pred := make([]float64, len(x))
+		for i := range x {
+			pred[i] = model.Predict(x[i])
+		}
+		f.Rows[split] = len(y)
+		f.Metrics[split] = evaluate(f.Task, y, pred)
+	}
+}
+
+// logMetrics - แสดงตัวชี้วัดของแต่ละชุดข้อมูล
+func logMetrics(f *ModelFile) {
+	for _, split := range splitNames[2] {
+		metrics, ok := f.Metrics[split]
+		if !ok {
+			continue
+		}
+		var parts []string
+		for _, name := range sortedKeys(metrics) {
+			parts = append(parts, name+"="+strconv.FormatFloat(metrics[name], 'f', 4, 64))
+		}
+		logMsg("model.metrics", split, f.Rows[split], strings.Join(parts, " "))
+	}
+}
+
+// trainFlags - flag ของชนิดโมเดลและการตั้งค่าที่ใช้ร่วมกันระหว่างคำสั่ง train และ cv
+// ฟังก์ชันที่คืนมาอ่านค่าหลัง Parse แล้วตรวจความถูกต้อง
+func trainFlags(fs *flag.FlagSet) func() (TrainConfig, error) {
+	method := fs.String("model", linearRidge, "ชนิดของโมเดล (ols, ridge, lasso, elasticnet, logistic, gbdt)")
+	task := fs.String("task", "", "regression หรือ classification (เป้าหมาย 0/1) ค่าเริ่มต้นคือ classification สำหรับ logistic และ regression สำหรับโมเดลอื่น")
+	lambda := fs.Float64("lambda", 0.01, "ความแรงของ regularization ของโมเดลเชิงเส้น (ไม่ใช้กับ ols)")
+	alpha := fs.Float64("alpha", 0.5, "สัดส่วน L1 ของ elasticnet และ logistic (0 = ridge, 1 = lasso)")
+	maxIter := fs.Int("max-iter", 1000, "จำนวนรอบสูงสุดของ lasso, elasticnet และ logistic")
+	tolerance := fs.Float64("tol", 1e-6, "หยุดเมื่อสัมประสิทธิ์เปลี่ยนน้อยกว่านี้")
+	trees := fs.Int("trees", 500, "จำนวนต้นไม้สูงสุดของ gbdt")
+	learningRate := fs.Float64("learning-rate", 0.05, "learning rate ของ gbdt")
+	maxDepth := fs.Int("max-depth", 4, "ความลึกสูงสุดของต้นไม้")
+	minLeaf := fs.Int("min-leaf", 20, "จำนวนแถวขั้นต่ำในแต่ละใบ")
+	leafL2 := fs.Float64("leaf-l2", 1, "L2 regularization ของค่าในใบ")
+	subsample := fs.Float64("subsample", 0.8, "สัดส่วนแถวที่สุ่มใช้ต่อต้น")
+	colsample := fs.Float64("colsample", 1, "สัดส่วน feature ที่สุ่มใช้ต่อต้น")
+	bins := fs.Int("bins", 64, "จำนวนช่วงสูงสุดของ histogram ต่อ feature")
+	earlyStopping := fs.Int("early-stopping", 50, "หยุดเมื่อ validation ไม่ดีขึ้นกี่ต้นติดกัน (0 = ไม่หยุด)")
+	seed := fs.Int64("seed", 1, "seed ของการสุ่ม (ผลลัพธ์เหมือนเดิมทุกครั้งเมื่อใช้ seed เดียวกัน)")
+
+	return func() (TrainConfig, error) {
+		cfg := TrainConfig{
+			Model:  strings.ToLower(*method),
+			Task:   strings.ToLower(*task),
+			Linear: LinearConfig{Lambda: *lambda, Alpha: *alpha, MaxIter: *maxIter, Tolerance: *tolerance},
+			GBDT: GBDTConfig{
+				Trees: *trees, LearningRate: *learningRate, MaxDepth: *maxDepth, MinLeaf: *minLeaf, L2: *leafL2,
+				Subsample: *subsample, ColSample: *colsample, Bins: *bins, EarlyStopping: *earlyStopping, Seed: *seed,
+			},
+		}
+		return cfg, cfg.validate()
+	}
+}
+
+// validate - ตรวจชนิดของโมเดลกับประเภทของปัญหา กำหนดประเภทเริ่มต้น และตั้งวิธีหรือ objective ของโมเดลตามนั้น
+func (c *TrainConfig) validate() error {
+	switch c.Model {
+	case linearOLS, linearRidge, linearLasso, linearElasticNet, linearLogistic, modelGBDT:
+	default:
+		return errMsg("model.unknown_method", c.Model)
+	}
+	if c.Task == "" {
+		c.Task = taskRegression
+		if c.Model == linearLogistic {
+			c.Task = taskClassification
+		}
+	}
+	switch {
+	case c.Task != taskRegression && c.Task != taskClassification:
+		return errMsg("model.unknown_task", c.Task)
+	case (c.Model == linearLogistic) != (c.Task == taskClassification) && c.Model != modelGBDT:
+		return errMsg("model.task_mismatch", c.Model, c.Task)
+	}
+
+	c.Linear.Method, c.GBDT.Objective = "", ""
+	if c.Model == modelGBDT {
+		c.GBDT.Objective = objectiveRegression
+		if c.Task == taskClassification {
+			c.GBDT.Objective = objectiveBinary
+		}
+	} else {
+		c.Linear.Method = c.Model
+	}
+	return nil
+}
+
+// modelInputs - ตำแหน่งของคอลัมน์เป้าหมายและ feature พร้อมชื่อของ feature
+func modelInputs(m TrainingMatrix, target, featureSpec string) (int, []int, []string, error) {
+	targetIndex := m.columnIndex(target)
+	if targetIndex < 0 || m.Columns[targetIndex].Group != groupLabel {
+		return 0, nil, nil, errMsg("model.unknown_target", target)
+	}
+	features, err := selectFeatures(m, featureSpec)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	names := make([]string, len(features))
+	for j, c := range features {
+		names[j] = m.Columns[c].Name
+	}
+	return targetIndex, features, names, nil
+}
+
+// runTrain - คำสั่ง train: stock-predict train [flags] <matrix>
+// ฝึกด้วยแถว train ของ matrix (จากคำสั่ง matrix) แล้ววัดผลกับ valid และ test
+// gbdt ใช้แถว valid สำหรับ early stopping ถ้า matrix ไม่มีแถว valid จะแยกแถว train ช่วงท้ายตาม -valid-fraction
+func runTrain(args []string) error {
+	fs := flag.NewFlagSet("train", flag.ContinueOnError)
+	output := fs.String("out", "model", "ชื่อไฟล์โมเดล (ไม่ต้องใส่นามสกุล) เขียน <ชื่อ>.json และรายงาน")
+	target := fs.String("target", labelColumnPrefix+labelName("fwdReturn", 60), "คอลัมน์เป้าหมาย (label_*)")
+	featureSpec := fs.String("features", "", "feature ที่ใช้ คั่นด้วยจุลภาค เป็นชื่อคอลัมน์หรือกลุ่ม (fundamental, price, derived, technical, indicator) ค่าเริ่มต้นคือทุกคอลัมน์ที่ไม่ใช่ label")
+	validFraction := fs.Float64("valid-fraction", 0.2, "สัดส่วนแถว train ช่วงท้ายที่ใช้ early stopping ของ gbdt เมื่อ matrix ไม่มีแถว valid")
+	config := trainFlags(fs)
+	lang := fs.String("lang", "th", "ภาษาของข้อความ / language (th, en)")
+	buddhistEra := fs.Bool("buddhist-era", false, "แสดงปีเป็นพุทธศักราช / show years in the Buddhist Era")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := SetLocale(*lang, *buddhistEra); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errMsg("train.usage")
+	}
+	cfg, err := config()
+	if err != nil {
+		return err
+	}
+
+	m, err := ReadMatrix(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	targetIndex, features, names, err := modelInputs(m, *target, *featureSpec)
+	if err != nil {
+		return err
+	}
+
+	train, valid := splitRows(m, targetIndex, "train"), splitRows(m, targetIndex, "valid")
+	if cfg.Model == modelGBDT && len(valid) == 0 && cfg.GBDT.EarlyStopping > 0 {
+		train, valid = timeOrderedHoldout(m, train, *validFraction, m.Config.EmbargoDays)
+		logMsg("train.holdout", len(valid), len(train))
+	}
+	model, err := fitModel(m, features, targetIndex, train, valid, cfg)
+	if err != nil {
+		return err
+	}
+	f := &ModelFile{
+		Task:      cfg.Task,
+		Target:    *target,
+		Features:  names,
+		TrainedAt: time.Now().UTC(),
+		Source:    fs.Arg(0),
+		Impute:    m.Impute,
+		Transform: m.Transform,
+	}
+	f.setModel(model)
+	evaluateModel(f, model, m, features, targetIndex)
+
+	switch model := model.(type) {
+	case *LinearModel:
+		logCoefficients(model, names)
+		err = ExportCoefficients(model, names, *output+"_coefficients.csv")
+	case *GBDTModel:
+		logImportance(model, names)
+		err = ExportImportance(model, names, *output+"_importance.csv")
+	}
+	if err != nil {
+		return err
+	}
+	logMetrics(f)
+	return SaveModel(f, *output+".json")
+}
+
+// Prediction - ค่าที่ทำนายของหุ้นหนึ่งตัว
+type Prediction struct {
+	Row   MatrixRow
+	Score float64
+	Rank  int
+}
+
+// PredictLatest - ทำนายแถวล่าสุดของหุ้นแต่ละตัวและประเภทงบแต่ละประเภทที่อยู่ในไตรมาสล่าสุดของ matrix
+// หุ้นที่ไม่มีแถวในไตรมาสล่าสุด (เช่นหยุดซื้อขายหรือไม่มีราคา) ไม่ถูกจัดอันดับรวมกับหุ้นอื่นด้วยข้อมูลเก่า
+// เรียงจากคะแนนมากไปน้อย Rank เริ่มที่ 1
+func PredictLatest(f *ModelFile, m TrainingMatrix) ([]Prediction, error) {
+	model, err := f.model()
+	if err != nil {
+		return nil, err
+	}
+	features, err := featureIndex(m, f.Features)
+	if err != nil {
+		return nil, err
+	}
+
+	// ไตรมาสของวันที่ล่าสุด (วันที่ราคาสิ้นไตรมาสของแต่ละหุ้นอาจไม่ตรงกัน จึงเทียบเป็นไตรมาส)
+	var period Period
+	for _, row := range m.Rows {
+		if p := periodOfDate(row.SnapshotDate); period.Before(p) {
+			period = p
+		}
+	}
+
+	type latestKey struct{ Symbol, StatementType string }
+	latest := make(map[latestKey]int)
+	stale := make(map[latestKey]bool)
+	for i, row := range m.Rows {
+		key := latestKey{row.Symbol, row.StatementType}
+		if periodOfDate(row.SnapshotDate) != period {
+			stale[key] = true
+			continue
+		}
+		if j, ok := latest[key]; !ok || row.SnapshotDate.After(m.Rows[j].SnapshotDate) {
+			latest[key] = i
+		}
+	}
+	excluded := 0
+	for key := range stale {
+		if _, ok := latest[key]; !ok {
+			excluded++
+		}
+	}
+	if excluded > 0 {
+		logMsg("predict.stale_excluded", excluded, period.String())
+	}
+
+	keys := make([]latestKey, 0, len(latest))
+	for key := range latest {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Symbol != keys[j].Symbol {
+			return keys[i].Symbol < keys[j].Symbol
+		}
+		return keys[i].StatementType < keys[j].StatementType
+	})
+	predictions := make([]Prediction, 0, len(latest))
+	for _, key := range keys {
+		row := m.Rows[latest[key]]
+		predictions = append(predictions, Prediction{Row: row, Score: model.Predict(featureValues(row, features))})
+	}
+	sort.SliceStable(predictions, func(i, j int) bool { return predictions[i].Score > predictions[j].Score })
+	for i := range predictions {
+		predictions[i].Rank = i + 1
+	}
+	return predictions, nil
+}
+
+// ExportPredictions - เขียนค่าที่ทำนายเป็น CSV
+func ExportPredictions(predictions []Prediction, filename string) error {
+	columns := []string{"Rank", "Symbol", "FinancialStatementType", "SnapshotDate", "FundamentalPeriod", "Score"}
+	records := make([][]string, 0, len(predictions))
+	for _, p := range predictions {
+		records = append(records, []string{
+			strconv.Itoa(p.Rank), p.Row.Symbol, p.Row.StatementType,
+			p.Row.SnapshotDate.Format("2006-01-02"), p.Row.FundamentalPeriod.String(), formatModelValue(p.Score),
+		})
+	}
+	return writeReportCSV(filename, columns, records)
+}
+
+// runPredict - คำสั่ง predict: stock-predict predict [flags] <โมเดล.json> <matrix>
+// matrix ควรสร้างด้วยการตั้งค่าเดียวกับที่ใช้ฝึก (การเติมค่าและการปรับ feature)
+func runPredict(args []string) error {
+	fs := flag.NewFlagSet("predict", flag.ContinueOnError)
+	output := fs.String("out", "predictions", "ชื่อไฟล์ผลลัพธ์ (ไม่ต้องใส่นามสกุล)")
+	lang := fs.String("lang", "th", "ภาษาของหัวคอลัมน์และข้อความ / language for headers and messages (th, en)")
+	buddhistEra := fs.Bool("buddhist-era", false, "แสดงปีเป็นพุทธศักราช / show years in the Buddhist Era")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := SetLocale(*lang, *buddhistEra); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errMsg("predict.usage")
+	}
+
+	f, err := LoadModel(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	m, err := ReadMatrix(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(imputeKey(f.Impute), imputeKey(m.Impute)) {
+		logMsg("predict.impute_mismatch")
+	}
+	// matrix ที่ยังไม่ได้ปรับ feature ปรับด้วยการตั้งค่าเดียวกับตอนฝึกที่เก็บไว้ในโมเดล
+	if m.Transform == nil && f.Transform != nil && f.Transform.enabled() {
+		TransformMatrix(&m, *f.Transform)
+		m.Transform = f.Transform
+		logMsg("predict.transform_applied")
+	}
+	if !slices.Equal(transformKey(f.Transform), transformKey(m.Transform)) {
+		logMsg("predict.transform_mismatch")
+	}
+	predictions, err := PredictLatest(f, m)
+	if err != nil {
+		return err
+	}
+	if len(predictions) == 0 {
+		return errMsg("export.no_data")
+	}
+	return ExportPredictions(predictions, *output+".csv")
+}
+
+// transformKey - การปรับ feature ในรูปที่เปรียบเทียบได้ (nil = ไม่ได้ปรับ)
+func transformKey(cfg *TransformConfig) []string {
+	if cfg == nil {
+		return nil
+	}
+	return []string{
+		strconv.FormatFloat(cfg.WinsorLower, 'g', -1, 64), strconv.FormatFloat(cfg.WinsorUpper, 'g', -1, 64),
+		cfg.Method, cfg.Neutralize, strconv.Itoa(cfg.SizeBuckets),
+	}
+}
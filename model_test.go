@@ -0,0 +1,68 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPredictLatest(t *testing.T) {
+	// โมเดลที่ให้คะแนนเท่ากับค่าของ x
+	f := &ModelFile{
+		Kind:     modelLinear,
+		Features: []string{"x"},
+		Linear:   &LinearModel{Means: []float64{0}, Stds: []float64{1}, Coefficients: []float64{1}},
+	}
+	tests := []struct {
+		name        string
+		rows        []MatrixRow
+		wantSymbols []string
+		wantScores  []float64
+	}{
+		{
+			name: "latest row per symbol",
+			rows: []MatrixRow{
+				testRow("A", "2024-03-29", 1, nan), testRow("B", "2024-03-29", 2, nan),
+				testRow("A", "2024-06-28", 5, nan), testRow("B", "2024-06-28", 3, nan),
+			},
+			wantSymbols: []string{"A", "B"},
+			wantScores:  []float64{5, 3},
+		},
+		{
+			// วันที่ราคาสิ้นไตรมาสต่างกันแต่อยู่ในไตรมาสเดียวกัน
+			name:        "different dates in the same quarter",
+			rows:        []MatrixRow{testRow("A", "2024-06-28", 5, nan), testRow("B", "2024-06-30", 7, nan)},
+			wantSymbols: []string{"B", "A"},
+			wantScores:  []float64{7, 5},
+		},
+		{
+			// C ไม่มีแถวในไตรมาส 2 จึงไม่ถูกจัดอันดับด้วยข้อมูลไตรมาส 1
+			name: "symbols without the latest quarter are left out",
+			rows: []MatrixRow{
+				testRow("A", "2024-03-29", 1, nan), testRow("C", "2024-03-29", 9, nan),
+				testRow("A", "2024-06-28", 5, nan),
+			},
+			wantSymbols: []string{"A"},
+			wantScores:  []float64{5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predictions, err := PredictLatest(f, testMatrix([]string{"x"}, tt.rows...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var symbols []string
+			var scores []float64
+			for i, p := range predictions {
+				symbols = append(symbols, p.Row.Symbol)
+				scores = append(scores, p.Score)
+				if p.Rank != i+1 {
+					t.Errorf("%s rank = %d; want %d", p.Row.Symbol, p.Rank, i+1)
+				}
+			}
+			if !slices.Equal(symbols, tt.wantSymbols) || !slices.Equal(scores, tt.wantScores) {
+				t.Errorf("predictions = %v %v; want %v %v", symbols, scores, tt.wantSymbols, tt.wantScores)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// โหมดการแสดงตัวเลข
+const (
+	precisionRaw       = "raw"       // แสดงแบบสั้นที่สุดที่แปลงกลับได้ค่าเดิม (ไม่สูญเสียความละเอียด)
+	precisionFixed     = "fixed"     // ทศนิยมตามจำนวนที่กำหนด
+	precisionThousands = "thousands" // หารด้วย 1,000 (พันบาท)
+	precisionMillions  = "millions"  // หารด้วย 1,000,000 (ล้านบาท)
+	precisionPercent   = "percent"   // คูณ 100 สำหรับค่าที่เป็นสัดส่วน (0.12 -> 12)
+	precisionAuto      = "auto"      // รูปแบบเดิมของ formatFloat (ปัดตามขนาดของตัวเลข)
+)
+
+// PrecisionRule - วิธีแสดงตัวเลขของคอลัมน์หนึ่ง
+// Decimals < 0 หมายถึงไม่ปัดทศนิยม (ใช้ได้กับทุกโหมดยกเว้น auto)
+type PrecisionRule struct {
+	Mode     string `json:"mode"`
+	Decimals int    `json:"decimals"`
+}
+
+// PrecisionPolicy - กฎการแสดงตัวเลขแยกตามคอลัมน์
+// ชื่อคอลัมน์ไม่สนตัวพิมพ์เล็กใหญ่และเครื่องหมาย _ เพื่อให้ใช้ได้ทั้งชื่อคอลัมน์ (TotalAssets)
+// ชื่อ metric (totalAssets) และชื่อคอลัมน์ Parquet (total_assets)
+type PrecisionPolicy struct {
+	Default PrecisionRule
+	Columns map[string]PrecisionRule
+}
+
+// losslessRule - ค่าเริ่มต้นของทุกคอลัมน์
+var losslessRule = PrecisionRule{Mode: precisionRaw, Decimals: -1}
+
+// exportPrecision - นโยบายที่ exporter ทุกตัวใช้ ตั้งค่าจาก command line
+var exportPrecision = PrecisionPolicy{Default: losslessRule}
+
+// parsePrecisionRule - แปลงข้อความ เช่น "fixed:2", "millions:3", "raw" เป็น PrecisionRule
+func parsePrecisionRule(spec string) (PrecisionRule, error) {
+	mode, decimals, hasDecimals := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), ":")
+	rule := PrecisionRule{Mode: mode, Decimals: -1}
+
+	switch mode {
+	case precisionRaw, precisionAuto:
+	case precisionFixed, precisionThousands, precisionMillions, precisionPercent:
+		if mode == precisionFixed && !hasDecimals {
+			return PrecisionRule{}, errMsg("precision.invalid", spec)
+		}
+	default:
+		return PrecisionRule{}, errMsg("precision.invalid", spec)
+	}
+
+	if hasDecimals {
+		n, err := strconv.Atoi(decimals)
+		if err != nil || n < 0 {
+			return PrecisionRule{}, errMsg("precision.invalid", spec)
+		}
+		rule.Decimals = n
+	}
+	return rule, nil
+}
+
+// parsePrecisionPolicy - อ่านนโยบายจาก command line
+// รูปแบบ: "EpsQuarter=fixed:4,TotalAssets=millions:2,*=raw" (* คือค่าเริ่มต้นของคอลัมน์อื่น)
+func parsePrecisionPolicy(spec string) (PrecisionPolicy, error) {
+	policy := PrecisionPolicy{Default: losslessRule, Columns: make(map[string]PrecisionRule)}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		col, ruleSpec, ok := strings.Cut(part, "=")
+		if !ok {
+			return PrecisionPolicy{}, errMsg("precision.invalid", part)
+		}
+		rule, err := parsePrecisionRule(ruleSpec)
+		if err != nil {
+			return PrecisionPolicy{}, err
+		}
+		if col = strings.TrimSpace(col); col == "*" {
+			policy.Default = rule
+		} else {
+			policy.Columns[precisionKey(col)] = rule
+		}
+	}
+	return policy, nil
+}
+
+// With - คืนนโยบายใหม่ที่เพิ่มกฎของคอลัมน์ (ไม่แก้ของเดิม)
+func (p PrecisionPolicy) With(col string, rule PrecisionRule) PrecisionPolicy {
+	columns := make(map[string]PrecisionRule, len(p.Columns)+1)
+	for k, v := range p.Columns {
+		columns[k] = v
+	}
+	columns[precisionKey(col)] = rule
+	return PrecisionPolicy{Default: p.Default, Columns: columns}
+}
+
+// Rule - กฎของคอลัมน์ ถ้าไม่ได้กำหนดจะใช้ค่าเริ่มต้น
+func (p PrecisionPolicy) Rule(col string) PrecisionRule {
+	if rule, ok := p.Columns[precisionKey(col)]; ok {
+		return rule
+	}
+	if p.Default.Mode == "" {
+		return losslessRule
+	}
+	return p.Default
+}
+
+// Apply - ปรับขนาดและปัดเศษตามกฎ ใช้กับ exporter ที่เก็บเป็นตัวเลข (Parquet, JSON)
+func (p PrecisionPolicy) Apply(col string, v float64) float64 {
+	rule := p.Rule(col)
+	v = rule.scale(v)
+	if rule.Mode == precisionAuto {
+		v, _ = strconv.ParseFloat(formatFloat(v), 64)
+		return v
+	}
+	if rule.Decimals >= 0 {
+		pow := math.Pow(10, float64(rule.Decimals))
+		v = math.Round(v*pow) / pow
+	}
+	return v
+}
+
+// Format - แปลงตัวเลขเป็นสตริงตามกฎ ใช้กับ exporter ที่เป็นข้อความ (CSV)
+func (p PrecisionPolicy) Format(col string, v float64) string {
+	rule := p.Rule(col)
+	if rule.Mode == precisionAuto {
+		return formatFloat(v)
+	}
+	return strconv.FormatFloat(rule.scale(v), 'f', rule.Decimals, 64)
+}
+
+// NumFmt - รูปแบบตัวเลขของ Excel ตามกฎ (ค่าในเซลล์ยังเป็นค่าเต็ม ปรับเฉพาะการแสดงผล)
+// คืนค่าว่างถ้าไม่ได้กำหนดกฎให้คอลัมน์นี้ เพื่อให้ใช้รูปแบบเริ่มต้นของคอลัมน์
+func (p PrecisionPolicy) NumFmt(col string) string {
+	rule, ok := p.Columns[precisionKey(col)]
+	if !ok {
+		return ""
+	}
+	decimals := ""
+	if rule.Decimals > 0 {
+		decimals = "." + strings.Repeat("0", rule.Decimals)
+	} else if rule.Decimals < 0 {
+		decimals = ".##########"
+	}
+	switch rule.Mode {
+	case precisionFixed:
+		return "#,##0" + decimals
+	case precisionThousands:
+		return "#,##0" + decimals + ","
+	case precisionMillions:
+		return "#,##0" + decimals + ",,"
+	case precisionPercent:
+		return "0" + decimals + "%"
+	}
+	return "General"
+}
+
+func (r PrecisionRule) scale(v float64) float64 {
+	switch r.Mode {
+	case precisionThousands:
+		return v / 1e3
+	case precisionMillions:
+		return v / 1e6
+	case precisionPercent:
+		return v * 100
+	}
+	return v
+}
+
+// unscale - คืนค่าที่ถูก scale ไว้กลับเป็นหน่วยเดิม ใช้ตอนนำเข้าไฟล์ที่ส่งออกด้วย -precision
+func (r PrecisionRule) unscale(v float64) float64 {
+	switch r.Mode {
+	case precisionThousands:
+		return v * 1e3
+	case precisionMillions:
+		return v * 1e6
+	case precisionPercent:
+		return v / 100
+	}
+	return v
+}
+
+// scaled - กฎนี้เปลี่ยนหน่วยของค่าหรือไม่
+func (r PrecisionRule) scaled() bool {
+	return r.Mode == precisionThousands || r.Mode == precisionMillions || r.Mode == precisionPercent
+}
+
+func precisionKey(col string) string {
+	return strings.ReplaceAll(strings.ToLower(col), "_", "")
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/conc/pool"
+)
+
+// getAllPriceHistory - ดึงราคาปิดรายวันของหุ้นทุกตัวในช่วงวันที่ที่กำหนด
+func getAllPriceHistory(symbols []string, startDate, endDate string) ([]EODPriceBySymbol, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(30*len(symbols)+60)*time.Second)
+	defer cancel()
+
+	p := pool.New().WithContext(ctx).WithMaxGoroutines(20)
+
+	var mutex sync.Mutex
+	var history []EODPriceBySymbol
+
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	for i, symbol := range symbols {
+		symbol := symbol
+		idx := i
+
+		p.Go(func(ctx context.Context) error {
+			logMsg("history.start", symbol, idx+1, len(symbols))
+
+			prices, err := fetchPriceHistory(ctx, client, symbol, startDate, endDate)
+			if err != nil {
+				logMsg("history.symbol_failed", symbol, err)
+				return nil // ไม่ต้องการให้หยุดทั้งหมดเมื่อบริษัทเดียวล้มเหลว
+			}
+
+			mutex.Lock()
+			history = append(history, prices...)
+			mutex.Unlock()
+			return nil
+		})
+	}
+
+	if err := p.Wait(); err != nil {
+		return history, errMsg("history.failed", err)
+	}
+
+	sortPriceHistory(history)
+
+	logMsg("history.done", len(history), len(symbols))
+	return history, nil
+}
+
+// fetchPriceHistory - ดึงราคารายวันของหุ้นหนึ่งตัว
+func fetchPriceHistory(ctx context.Context, client *http.Client, symbol, startDate, endDate string) ([]EODPriceBySymbol, error) {
+	// จำกัดอัตราการเรียก API
+	if err := limiter.Wait(ctx); err != nil {
+		return nil, errMsg("http.rate_limit", err)
+	}
+
+	url := "https://www.setsmart.com/api/listed-company-api/eod-price-by-symbol"
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, errMsg("http.create_request", err)
+	}
+
+	req.Header.Add("api-key", APIKEY)
+
+	q := req.URL.Query()
+	q.Add("symbol", symbol)
+	q.Add("startDate", startDate)
+	q.Add("endDate", endDate)
+	q.Add("adjustedPriceFlag", "Y")
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errMsg("http.send_request", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errMsg("http.read_body", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, errMsg("http.status", resp.StatusCode)
+	}
+
+	var prices []EODPriceBySymbol
+	if err := json.Unmarshal(body, &prices); err != nil {
+		return nil, errMsg("http.decode_json", err)
+	}
+
+	return prices, nil
+}
+
+// sortPriceHistory - เรียงราคาตามชื่อหุ้น (A-Z) แล้วตามวันที่ (เก่าไปใหม่)
+func sortPriceHistory(history []EODPriceBySymbol) {
+	sort.SliceStable(history, func(i, j int) bool {
+		if history[i].Symbol != history[j].Symbol {
+			return history[i].Symbol < history[j].Symbol
+		}
+		return history[i].Date < history[j].Date
+	})
+}
+
+// uniqueSymbols - รายชื่อหุ้นที่ไม่ซ้ำกันจากข้อมูลงบการเงิน
+func uniqueSymbols(data []FinancialData) []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, item := range data {
+		if !seen[item.Symbol] {
+			seen[item.Symbol] = true
+			symbols = append(symbols, item.Symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
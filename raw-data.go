@@ -1,5 +1,7 @@
 package main
 
+// FinancialData - งบการเงินหนึ่งงวดของหนึ่งบริษัท
+// tag `col` ใช้กำหนดชื่อคอลัมน์ตอนส่งออก (ดู export_columns.go)
 type FinancialData struct {
 	Symbol                 string                 `json:"symbol"`
 	Year                   string                 `json:"year"`
@@ -25,38 +27,45 @@ type FinancialData struct {
 	OperatingCashFlow      float64                `json:"operatingCashFlow"`
 	InvestingCashFlow      float64                `json:"investingCashFlow"`
 	FinancingCashFlow      float64                `json:"financingCashFlow"`
-	Roe                    float64                `json:"roe"`
-	Roa                    float64                `json:"roa"`
+	Roe                    float64                `json:"roe" col:"ROE"`
+	Roa                    float64                `json:"roa" col:"ROA"`
 	NetProfitMarginQuarter float64                `json:"netProfitMarginQuarter"`
 	NetProfitMarginAccum   float64                `json:"netProfitMarginAccum"`
-	De                     float64                `json:"de"`
+	De                     float64                `json:"de" col:"DE"`
 	FixedAssetTurnover     float64                `json:"fixedAssetTurnover"`
 	TotalAssetTurnover     float64                `json:"totalAssetTurnover"`
-	PriceData              map[string]interface{} `json:"-"` // เก็บข้อมูลราคาที่เพิ่มเข้ามาภายหลัง
+	PriceData              map[string]interface{} `json:"-" col:"-"` // เก็บข้อมูลราคาที่เพิ่มเข้ามาภายหลัง
+	Fiscal                 FiscalCalendar         `json:"-"`         // ปฏิทินปีบัญชีของบริษัท
+	Calendar               Period                 `json:"-"`         // ไตรมาสปฏิทินที่ตรงกับงวดนี้
+	Lineage                Lineage                `json:"-"`         // ที่มาของข้อมูล (API, เวลา, hash ของ response)
+	Metrics                map[string]float64     `json:"-" col:"-"` // ตัวชี้วัดที่คำนวณเพิ่ม (ดู derivedMetrics)
+	Technicals             map[string]float64     `json:"-" col:"-"` // ตัวชี้วัดทางเทคนิค ณ วันที่ของราคาสิ้นไตรมาส (ดู technicalIndicators)
+	Labels                 map[string]float64     `json:"-" col:"-"` // เป้าหมายสำหรับการพยากรณ์ (ดู attachLabels)
 }
 
 // โครงสร้างสำหรับเก็บข้อมูลราคา
+// field ที่เป็น pointer คือค่าที่ API ส่งเป็น null ได้ เหมือน EODPriceBySymbol (nil = ไม่มีข้อมูล ไม่ใส่ลงใน FinancialData.PriceData)
 type PriceData struct {
-	Date              string  `json:"date"`
-	Symbol            string  `json:"symbol"`
-	SecurityType      string  `json:"securityType"`
-	AdjustedPriceFlag string  `json:"adjustedPriceFlag"`
-	Prior             float64 `json:"prior"`
-	Open              float64 `json:"open"`
-	High              float64 `json:"high"`
-	Low               float64 `json:"low"`
-	Close             float64 `json:"close"`
-	Average           float64 `json:"average"`
-	AomVolume         float64 `json:"aomVolume"`
-	AomValue          float64 `json:"aomValue"`
-	TrVolume          float64 `json:"trVolume"`
-	TrValue           float64 `json:"trValue"`
-	TotalVolume       float64 `json:"totalVolume"`
-	TotalValue        float64 `json:"totalValue"`
-	Pe                float64 `json:"pe"`
-	Pbv               float64 `json:"pbv"`
-	Bvps              float64 `json:"bvps"`
-	DividendYield     float64 `json:"dividendYield"`
-	MarketCap         float64 `json:"marketCap"`
-	VolumeTurnover    float64 `json:"volumeTurnover"`
+	Date              string   `json:"date"`
+	Symbol            string   `json:"symbol"`
+	SecurityType      string   `json:"securityType"`
+	AdjustedPriceFlag string   `json:"adjustedPriceFlag"`
+	Prior             float64  `json:"prior"`
+	Open              float64  `json:"open"`
+	High              float64  `json:"high"`
+	Low               float64  `json:"low"`
+	Close             float64  `json:"close"`
+	Average           float64  `json:"average"`
+	AomVolume         float64  `json:"aomVolume"`
+	AomValue          float64  `json:"aomValue"`
+	TrVolume          *float64 `json:"trVolume"`
+	TrValue           *float64 `json:"trValue"`
+	TotalVolume       float64  `json:"totalVolume"`
+	TotalValue        float64  `json:"totalValue"`
+	Pe                *float64 `json:"pe"`
+	Pbv               *float64 `json:"pbv"`
+	Bvps              *float64 `json:"bvps"`
+	DividendYield     *float64 `json:"dividendYield"`
+	MarketCap         *float64 `json:"marketCap"`
+	VolumeTurnover    *float64 `json:"volumeTurnover"`
 }
@@ -59,27 +59,29 @@ type FinancialDataAndRatioBySymbol struct {
 	TotalAssetTurnover     float64 `json:"totalAssetTurnover"`
 }
 
+// EODPriceBySymbol - ราคาปิดรายวันของหุ้นหนึ่งตัว
+// field ที่เป็น pointer คือค่าที่ API ส่งเป็น null ได้ (nil = ไม่มีข้อมูล ต่างจากค่าศูนย์)
 type EODPriceBySymbol struct {
-	Date              string  `json:"date"`
-	Symbol            string  `json:"symbol"`
-	SecurityType      string  `json:"securityType"`
-	AdjustedPriceFlag string  `json:"adjustedPriceFlag"`
-	Prior             float64 `json:"prior"`
-	Open              float64 `json:"open"`
-	High              float64 `json:"high"`
-	Low               float64 `json:"low"`
-	Close             float64 `json:"close"`
-	Average           float64 `json:"average"`
-	AomVolume         float64 `json:"aomVolume"`
-	AomValue          float64 `json:"aomValue"`
-	TrVolume          float64 `json:"trVolume"`
-	TrValue           float64 `json:"trValue"`
-	TotalVolume       float64 `json:"totalVolume"`
-	TotalValue        float64 `json:"totalValue"`
-	Pe                float64 `json:"pe"`
-	Pbv               float64 `json:"pbv"`
-	Bvps              float64 `json:"bvps"`
-	DividendYield     float64 `json:"dividendYield"`
-	MarketCap         float64 `json:"marketCap"`
-	VolumeTurnover    float64 `json:"volumeTurnover"`
+	Date              string   `json:"date"`
+	Symbol            string   `json:"symbol"`
+	SecurityType      string   `json:"securityType"`
+	AdjustedPriceFlag string   `json:"adjustedPriceFlag"`
+	Prior             float64  `json:"prior"`
+	Open              float64  `json:"open"`
+	High              float64  `json:"high"`
+	Low               float64  `json:"low"`
+	Close             float64  `json:"close"`
+	Average           float64  `json:"average"`
+	AomVolume         float64  `json:"aomVolume"`
+	AomValue          float64  `json:"aomValue"`
+	TrVolume          *float64 `json:"trVolume"` // ปริมาณซื้อขายผ่าน trade report null เมื่อไม่มีรายการ
+	TrValue           *float64 `json:"trValue"`  // มูลค่าซื้อขายผ่าน trade report null เมื่อไม่มีรายการ
+	TotalVolume       float64  `json:"totalVolume"`
+	TotalValue        float64  `json:"totalValue"`
+	Pe                *float64 `json:"pe"`             // P/E null เมื่อกำไรติดลบหรือไม่มีงบการเงิน
+	Pbv               *float64 `json:"pbv"`            // P/BV null เมื่อไม่มีมูลค่าตามบัญชี
+	Bvps              *float64 `json:"bvps"`           // มูลค่าตามบัญชีต่อหุ้น null เมื่อไม่มีงบการเงิน
+	DividendYield     *float64 `json:"dividendYield"`  // อัตราปันผลตอบแทน null เมื่อไม่จ่ายปันผล
+	MarketCap         *float64 `json:"marketCap"`      // มูลค่าตลาด null สำหรับหลักทรัพย์ที่ไม่มีจำนวนหุ้นจดทะเบียน
+	VolumeTurnover    *float64 `json:"volumeTurnover"` // อัตราการหมุนเวียน null สำหรับหลักทรัพย์ที่ไม่มีจำนวนหุ้นจดทะเบียน
 }
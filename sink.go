@@ -0,0 +1,193 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// stdoutPath - ชื่อไฟล์ที่หมายถึง stdout เพื่อส่งต่อให้ jq, DuckDB หรือเครื่องมืออื่นผ่าน pipe
+const stdoutPath = "-"
+
+// นามสกุลของไฟล์บีบอัดที่รองรับ
+const (
+	gzipExt = ".gz"
+	zstdExt = ".zst"
+)
+
+// OutputFormat - รูปแบบไฟล์ส่งออกจาก command line เช่น csv, jsonl.gz, tidy-csv.zst
+type OutputFormat struct {
+	Name        string // csv, jsonl, parquet, xlsx, tidy-csv, tidy-jsonl
+	Compression string // "", gz หรือ zst
+}
+
+// parseOutputFormat - แยกชื่อรูปแบบกับวิธีบีบอัด ("jsonl.gz" -> jsonl + gz)
+func parseOutputFormat(spec string) (OutputFormat, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	format := OutputFormat{Name: spec}
+	for _, ext := range []string{gzipExt, zstdExt} {
+		if name, ok := strings.CutSuffix(spec, ext); ok {
+			format = OutputFormat{Name: name, Compression: ext[1:]}
+			break
+		}
+	}
+
+	switch format.Name {
+	case "csv", "jsonl", "tidy-csv", "tidy-jsonl":
+	case "parquet", "xlsx":
+		// Parquet และ Excel บีบอัดภายในไฟล์อยู่แล้ว
+		if format.Compression != "" {
+			return OutputFormat{}, errMsg("sink.compress_binary", format.Name)
+		}
+	default:
+		return OutputFormat{}, errMsg("export.unknown_format", spec)
+	}
+	return format, nil
+}
+
+// parseOutputFormats - อ่านรายการรูปแบบไฟล์ที่คั่นด้วยจุลภาค
+// เมื่อส่งออกทาง stdout เลือกได้เพียงรูปแบบเดียว เพราะข้อมูลหลายรูปแบบจะปนกัน
+func parseOutputFormats(spec, output string) ([]OutputFormat, error) {
+	var formats []OutputFormat
+	for _, part := range strings.Split(spec, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		format, err := parseOutputFormat(part)
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, format)
+	}
+	if output == stdoutPath && len(formats) > 1 {
+		return nil, errMsg("sink.stdout_single")
+	}
+	return formats, nil
+}
+
+// Path - ชื่อไฟล์ผลลัพธ์ของรูปแบบนี้ suffix คือส่วนท้ายชื่อไฟล์ เช่น ".csv" หรือ "_long.jsonl"
+// ถ้า output เป็น "-" จะคืน "-" ตามด้วยนามสกุลบีบอัด (ถ้ามี) ซึ่ง createOutput เข้าใจว่าเป็น stdout
+func (f OutputFormat) Path(output, suffix string) string {
+	ext := ""
+	if f.Compression != "" {
+		ext = "." + f.Compression
+	}
+	if output == stdoutPath {
+		return stdoutPath + ext
+	}
+	return output + suffix + ext
+}
+
+// isStdout - ชื่อไฟล์นี้หมายถึง stdout หรือไม่ ("-", "-.gz", "-.zst")
+func isStdout(filename string) bool {
+	return strings.TrimSuffix(strings.TrimSuffix(filename, gzipExt), zstdExt) == stdoutPath
+}
+
+// createOutput - เปิดปลายทางสำหรับเขียนตามชื่อไฟล์
+// "-" คือ stdout, นามสกุล .gz และ .zst จะบีบอัดด้วย gzip และ zstd ตามลำดับ
+// ต้องเรียก Close เสมอเพื่อให้ข้อมูลที่บีบอัดถูกเขียนครบ
+func createOutput(filename string) (io.WriteCloser, error) {
+	var base io.WriteCloser
+	if isStdout(filename) {
+		base = nopWriteCloser{os.Stdout}
+	} else {
+		file, err := os.Create(filename)
+		if err != nil {
+			return nil, err
+		}
+		base = file
+	}
+
+	switch {
+	case strings.HasSuffix(filename, gzipExt):
+		return &compressedOutput{WriteCloser: gzip.NewWriter(base), base: base}, nil
+	case strings.HasSuffix(filename, zstdExt):
+		encoder, err := zstd.NewWriter(base)
+		if err != nil {
+			base.Close()
+			return nil, err
+		}
+		return &compressedOutput{WriteCloser: encoder, base: base}, nil
+	}
+	return base, nil
+}
+
+// openInput - เปิดไฟล์สำหรับอ่าน รองรับ "-" (stdin) และไฟล์ .gz, .zst เหมือน createOutput
+func openInput(filename string) (io.ReadCloser, error) {
+	var base io.ReadCloser
+	if isStdout(filename) {
+		base = io.NopCloser(os.Stdin)
+	} else {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		base = file
+	}
+
+	switch {
+	case strings.HasSuffix(filename, gzipExt):
+		reader, err := gzip.NewReader(base)
+		if err != nil {
+			base.Close()
+			return nil, err
+		}
+		return &compressedInput{Reader: reader, closers: []io.Closer{reader, base}}, nil
+	case strings.HasSuffix(filename, zstdExt):
+		decoder, err := zstd.NewReader(base)
+		if err != nil {
+			base.Close()
+			return nil, err
+		}
+		return &compressedInput{Reader: decoder, closers: []io.Closer{decoder.IOReadCloser(), base}}, nil
+	}
+	return base, nil
+}
+
+// compressedInput - ปิดตัวคลายการบีบอัดแล้วจึงปิดไฟล์
+type compressedInput struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (c *compressedInput) Close() error {
+	var err error
+	for _, closer := range c.closers {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// displayPath - ชื่อปลายทางสำหรับแสดงในข้อความ
+func displayPath(filename string) string {
+	if isStdout(filename) {
+		return "stdout"
+	}
+	return filename
+}
+
+// compressedOutput - ปิดตัวบีบอัดก่อนแล้วจึงปิดไฟล์
+type compressedOutput struct {
+	io.WriteCloser
+	base io.Closer
+}
+
+func (c *compressedOutput) Close() error {
+	err := c.WriteCloser.Close()
+	if cerr := c.base.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// nopWriteCloser - ไม่ปิด stdout เมื่อเขียนเสร็จ
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
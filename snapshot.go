@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadSnapshot - โหลดข้อมูลงบการเงินที่เก็บไว้จากไฟล์หรือ dataset
+//
+//	data.csv, data.csv.gz          ไฟล์ CSV จาก ExportToCSV
+//	data.jsonl, data.jsonl.zst     ไฟล์ JSON Lines จาก ExportToJSONL
+//	data.parquet                   ไฟล์ Parquet จาก ExportFinancialsToParquet
+//	dataset/ หรือ dataset@<run>    dataset จาก WriteDataset (ไม่ระบุ run = การรันล่าสุด)
+func loadSnapshot(spec string) ([]FinancialData, error) {
+	data, err := readSnapshot(spec)
+	if err != nil {
+		return nil, err
+	}
+	// Parquet และ CSV ที่เลือกคอลัมน์ไว้อาจไม่มีตัวชี้วัดที่คำนวณเพิ่ม จึงคำนวณใหม่ให้เทียบกันได้
+	computeDerivedMetrics(data)
+	return data, nil
+}
+
+// readSnapshot - อ่านข้อมูลตามชนิดของ spec
+func readSnapshot(spec string) ([]FinancialData, error) {
+	root, runID, _ := strings.Cut(spec, "@")
+	if info, err := os.Stat(root); err == nil && info.IsDir() {
+		return loadDatasetSnapshot(root, runID)
+	}
+
+	name := strings.TrimSuffix(strings.TrimSuffix(spec, gzipExt), zstdExt)
+	switch {
+	case strings.HasSuffix(name, ".jsonl"):
+		return ImportJSONL(spec)
+	case strings.HasSuffix(name, ".parquet"):
+		return ReadFinancialsParquet(spec)
+	}
+
+	data, issues, err := ImportCSV(spec)
+	logImportIssues(issues)
+	return data, err
+}
+
+// loadDatasetSnapshot - โหลดงบการเงินของการรันหนึ่งครั้งตาม manifest และตรวจ checksum ทุกไฟล์
+// ถ้าพาร์ทิชันเดียวกันมีทั้ง Parquet และ CSV จะอ่าน Parquet
+// อ่านไฟล์ตาม path ใน manifest จึงใช้ได้ทั้ง dataset เดิมที่แบ่งเป็น year=/quarter= และแบบ calendar_year=/calendar_quarter=
+func loadDatasetSnapshot(root, runID string) ([]FinancialData, error) {
+	manifest, err := readManifest(root, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	chosen := make(map[string]DatasetFile)
+	for _, file := range manifest.Files {
+		if file.Type != "financials" {
+			continue
+		}
+		if prev, ok := chosen[file.Partition]; !ok || (prev.Format != "parquet" && file.Format == "parquet") {
+			chosen[file.Partition] = file
+		}
+	}
+
+	var data []FinancialData
+	for _, partition := range sortedKeys(chosen) {
+		file := chosen[partition]
+		path := filepath.Join(root, filepath.FromSlash(file.Path))
+
+		sum, _, err := fileChecksum(path)
+		if err != nil {
+			return nil, err
+		}
+		if sum != file.SHA256 {
+			return nil, errMsg("dataset.checksum_mismatch", file.Path)
+		}
+
+		var part []FinancialData
+		if file.Format == "parquet" {
+			part, err = ReadFinancialsParquet(path)
+		} else {
+			var issues []ImportIssue
+			part, issues, err = readFinancialCSVFile(path)
+			logImportIssues(issues)
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, part...)
+	}
+
+	sortFinancialData(data)
+	return data, nil
+}
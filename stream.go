@@ -0,0 +1,558 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// RecordSink - ปลายทางที่รับข้อมูลของหุ้นแต่ละตัวทันทีที่ดึงเสร็จ
+// WriteSymbol จะถูกเรียกทีละครั้ง (ผู้เรียกล็อกให้แล้ว) ด้วยข้อมูลของหุ้นหนึ่งตัว
+type RecordSink interface {
+	WriteSymbol(records []FinancialData) error
+	Close() error
+}
+
+// storedRecord - รูปแบบ JSON ที่เก็บข้อมูลครบทุก field
+// FinancialData ไม่ส่ง PriceData, Fiscal, Calendar, Lineage, Metrics, Technicals และ Labels ออกเป็น JSON จึงต้องเพิ่ม field ที่นี่
+type storedRecord struct {
+	FinancialData
+	PriceData  map[string]interface{} `json:"priceData,omitempty"`
+	Fiscal     FiscalCalendar         `json:"fiscal"`
+	Calendar   Period                 `json:"calendar"`
+	Lineage    Lineage                `json:"lineage"`
+	Metrics    map[string]float64     `json:"metrics,omitempty"`
+	Technicals map[string]float64     `json:"technicals,omitempty"`
+	Labels     map[string]float64     `json:"labels,omitempty"`
+}
+
+func toStoredRecord(item FinancialData) storedRecord {
+	return storedRecord{
+		FinancialData: item,
+		PriceData:     item.PriceData,
+		Fiscal:        item.Fiscal,
+		Calendar:      item.Calendar,
+		Lineage:       item.Lineage,
+		Metrics:       item.Metrics,
+		Technicals:    item.Technicals,
+		Labels:        item.Labels,
+	}
+}
+
+// withPrecision - ปรับตัวเลขตามนโยบายความละเอียด โดยใช้ชื่อคอลัมน์เดียวกับไฟล์ CSV
+// (ชื่อ field, price_*, derived_*, tech_*, label_*) map ถูกคัดลอกใหม่เพื่อไม่ให้ข้อมูลต้นฉบับเปลี่ยน
+func (r storedRecord) withPrecision(p PrecisionPolicy) storedRecord {
+	v := reflect.ValueOf(&r.FinancialData).Elem()
+	for _, field := range financialMetricFields {
+		f := v.FieldByIndex(field.Index)
+		f.SetFloat(p.Apply(field.Name, f.Float()))
+	}
+	if r.PriceData != nil {
+		prices := make(map[string]interface{}, len(r.PriceData))
+		for key, value := range r.PriceData {
+			if f, ok := value.(float64); ok {
+				value = p.Apply(key, f)
+			}
+			prices[key] = value
+		}
+		r.PriceData = prices
+	}
+	r.Metrics = metricsWithPrecision(p, derivedColumnPrefix, r.Metrics)
+	r.Technicals = metricsWithPrecision(p, technicalColumnPrefix, r.Technicals)
+	r.Labels = metricsWithPrecision(p, labelColumnPrefix, r.Labels)
+	return r
+}
+
+// metricsWithPrecision - สำเนาของ values ที่ปรับตัวเลขแล้ว กฎของแต่ละค่าใช้ชื่อคอลัมน์ prefix+ชื่อ
+func metricsWithPrecision(p PrecisionPolicy, prefix string, values map[string]float64) map[string]float64 {
+	if values == nil {
+		return nil
+	}
+	adjusted := make(map[string]float64, len(values))
+	for name, v := range values {
+		adjusted[name] = p.Apply(prefix+name, v)
+	}
+	return adjusted
+}
+
+func (r storedRecord) financialData() FinancialData {
+	item := r.FinancialData
+	item.PriceData = r.PriceData
+	item.Fiscal = r.Fiscal
+	item.Calendar = r.Calendar
+	item.Lineage = r.Lineage
+	item.Metrics = r.Metrics
+	item.Technicals = r.Technicals
+	item.Labels = r.Labels
+	return item
+}
+
+// recordWriter - ตัวเขียนข้อมูลงบการเงินทีละชุด ใช้ได้ทั้งการส่งออกทั้งไฟล์และการเขียนทีละหุ้น
+type recordWriter interface {
+	Write(data []FinancialData) error
+	Flush() error
+}
+
+// streamFormats - รูปแบบที่เขียนทีละหุ้นได้ -> ส่วนท้ายชื่อไฟล์
+// parquet, xlsx, dataset, การตรวจสอบ, ตัวชี้วัดทางเทคนิคและ label ต้องใช้ข้อมูลทั้งชุดในหน่วยความจำ จึงใช้ร่วมกับ -stream ไม่ได้
+var streamFormats = map[string]string{
+	"csv":        ".csv",
+	"jsonl":      ".jsonl",
+	"tidy-csv":   "_long.csv",
+	"tidy-jsonl": "_long.jsonl",
+}
+
+// newRecordWriter - ตัวเขียนของรูปแบบใน streamFormats
+func newRecordWriter(format string, w io.Writer, layout ColumnLayout) (recordWriter, error) {
+	switch format {
+	case "csv":
+		writer, err := newCSVRecordWriter(w, layout)
+		if err != nil {
+			return nil, err
+		}
+		return writer, nil
+	case "jsonl":
+		return newJSONLRecordWriter(w), nil
+	case "tidy-csv":
+		writer, err := newTidyCSVRecordWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		return writer, nil
+	case "tidy-jsonl":
+		return newTidyJSONLRecordWriter(w), nil
+	}
+	return nil, errMsg("stream.unsupported", format)
+}
+
+// fileSink - เขียนไฟล์ตามลำดับที่ดึงเสร็จ (ยังไม่เรียง) เพื่อให้ใช้ข้อมูลได้บางส่วนถ้าโปรแกรมหยุดกลางทาง
+type fileSink struct {
+	file   *os.File
+	writer recordWriter
+}
+
+func newFileSink(filename, format string, layout ColumnLayout) (*fileSink, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, errMsg("export.create_file", filename, err)
+	}
+	writer, err := newRecordWriter(format, file, layout)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &fileSink{file: file, writer: writer}, nil
+}
+
+func (s *fileSink) WriteSymbol(records []FinancialData) error {
+	if err := s.writer.Write(records); err != nil {
+		return err
+	}
+	// flush ทุกครั้งเพื่อให้ข้อมูลถึงดิสก์ก่อนหุ้นตัวถัดไป
+	return s.writer.Flush()
+}
+
+func (s *fileSink) Close() error {
+	if err := s.writer.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
+
+// spoolChunk - ตำแหน่งข้อมูลของหุ้นหนึ่งตัวในไฟล์ spool
+type spoolChunk struct {
+	Symbol string
+	Offset int64
+	Length int64
+	Rows   int
+}
+
+// spoolSink - เก็บข้อมูลเป็น JSON Lines ทีละหุ้น พร้อมดัชนีตำแหน่งของแต่ละหุ้น
+// ข้อมูลในแต่ละ chunk เรียงแล้ว และแต่ละ chunk เป็นหุ้นคนละตัว
+// การ merge จึงทำได้โดยเรียงดัชนีแล้วอ่านทีละ chunk โดยไม่ต้องโหลดข้อมูลทั้งหมดเข้าหน่วยความจำ
+type spoolSink struct {
+	path   string
+	file   *os.File
+	offset int64
+	chunks []spoolChunk
+}
+
+func newSpoolSink(path string) (*spoolSink, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, errMsg("export.create_file", "JSON Lines", err)
+	}
+	return &spoolSink{path: path, file: file}, nil
+}
+
+func (s *spoolSink) WriteSymbol(records []FinancialData) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	sorted := append([]FinancialData(nil), records...)
+	sortFinancialData(sorted)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, item := range sorted {
+		if err := encoder.Encode(toStoredRecord(item)); err != nil {
+			return errMsg("export.write_row", err)
+		}
+	}
+
+	n, err := s.file.Write(buf.Bytes())
+	if err != nil {
+		return errMsg("export.write_row", err)
+	}
+
+	s.chunks = append(s.chunks, spoolChunk{
+		Symbol: sorted[0].Symbol,
+		Offset: s.offset,
+		Length: int64(n),
+		Rows:   len(sorted),
+	})
+	s.offset += int64(n)
+	return nil
+}
+
+func (s *spoolSink) Close() error {
+	return s.file.Close()
+}
+
+// Merge - อ่านข้อมูลจาก spool ทีละหุ้นตามลำดับชื่อหุ้น แล้วส่งให้ fn
+func (s *spoolSink) Merge(fn func(records []FinancialData) error) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	chunks := append([]spoolChunk(nil), s.chunks...)
+	sort.SliceStable(chunks, func(i, j int) bool {
+		return chunks[i].Symbol < chunks[j].Symbol
+	})
+
+	for _, chunk := range chunks {
+		records, err := readStoredRecords(io.NewSectionReader(file, chunk.Offset, chunk.Length))
+		if err != nil {
+			return err
+		}
+		if err := fn(records); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rows - จำนวนแถวทั้งหมดที่เขียนลง spool
+func (s *spoolSink) Rows() int {
+	total := 0
+	for _, chunk := range s.chunks {
+		total += chunk.Rows
+	}
+	return total
+}
+
+// readStoredRecords - อ่านข้อมูลจาก JSON Lines ที่เขียนด้วย storedRecord
+func readStoredRecords(r io.Reader) ([]FinancialData, error) {
+	var records []FinancialData
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var rec storedRecord
+		if err := decoder.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errMsg("http.decode_json", err)
+		}
+		records = append(records, rec.financialData())
+	}
+	return records, nil
+}
+
+// ImportJSONL - อ่านไฟล์ที่ส่งออกด้วย ExportToJSONL กลับเป็น []FinancialData (รองรับ .gz, .zst และ "-")
+func ImportJSONL(filename string) ([]FinancialData, error) {
+	file, err := openInput(filename)
+	if err != nil {
+		return nil, errMsg("import.open_file", err)
+	}
+	defer file.Close()
+	return readStoredRecords(file)
+}
+
+// jsonlRecordWriter - เขียนข้อมูลงบการเงินเป็น JSON Lines (หนึ่งบรรทัดต่อหนึ่งงวด) ตาม exportPrecision
+type jsonlRecordWriter struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newJSONLRecordWriter(w io.Writer) *jsonlRecordWriter {
+	buf := bufio.NewWriter(w)
+	return &jsonlRecordWriter{buf: buf, encoder: json.NewEncoder(buf)}
+}
+
+func (j *jsonlRecordWriter) Write(data []FinancialData) error {
+	for _, item := range data {
+		if err := j.encoder.Encode(toStoredRecord(item).withPrecision(exportPrecision)); err != nil {
+			return errMsg("export.write_row", err)
+		}
+	}
+	return nil
+}
+
+func (j *jsonlRecordWriter) Flush() error {
+	return j.buf.Flush()
+}
+
+// ExportToJSONL - ส่งออกข้อมูลงบการเงินเป็นไฟล์ JSON Lines
+func ExportToJSONL(data []FinancialData, filename string) error {
+	if len(data) == 0 {
+		return errMsg("export.no_data")
+	}
+
+	file, err := createOutput(filename)
+	if err != nil {
+		return errMsg("export.create_file", "JSON Lines", err)
+	}
+	defer file.Close()
+
+	writer := newJSONLRecordWriter(file)
+	if err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return errMsg("export.save_file", "JSON Lines", err)
+	}
+
+	logMsg("export.done", displayPath(filename), len(data))
+	return nil
+}
+
+// mongoSink - บันทึกข้อมูลลง MongoDB ทันทีที่ดึงเสร็จ
+// ใช้ upsert ตาม (หุ้น, ปี, ไตรมาส, ประเภทงบ) เพื่อให้รันซ้ำได้โดยไม่เกิดข้อมูลซ้ำ
+type mongoSink struct {
+	client     *mongo.Client
+	collection *mongo.Collection
+}
+
+func newMongoSink(uri, database, collection string) (*mongoSink, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, errMsg("stream.mongo_connect", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, errMsg("stream.mongo_connect", err)
+	}
+
+	logMsg("stream.mongo_connected", database, collection)
+	return &mongoSink{client: client, collection: client.Database(database).Collection(collection)}, nil
+}
+
+func (s *mongoSink) WriteSymbol(records []FinancialData) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(records))
+	for _, item := range records {
+		// แปลงผ่าน JSON เพื่อให้ชื่อ field ใน MongoDB ตรงกับไฟล์ JSON Lines
+		body, err := json.Marshal(toStoredRecord(item))
+		if err != nil {
+			return errMsg("export.write_row", err)
+		}
+		var doc bson.M
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return errMsg("export.write_row", err)
+		}
+
+		filter := bson.M{
+			"symbol":                 item.Symbol,
+			"year":                   item.Year,
+			"quarter":                item.Quarter,
+			"financialStatementType": item.FinancialStatementType,
+		}
+		models = append(models, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(doc).SetUpsert(true))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := s.collection.BulkWrite(ctx, models); err != nil {
+		return errMsg("stream.mongo_write", err)
+	}
+	return nil
+}
+
+func (s *mongoSink) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return s.client.Disconnect(ctx)
+}
+
+// StreamOptions - ตัวเลือกของการดึงข้อมูลแบบ streaming
+type StreamOptions struct {
+	Output   string         // ชื่อไฟล์ผลลัพธ์ (ไม่มีนามสกุล) หรือ "-" สำหรับ stdout
+	Formats  []OutputFormat // รูปแบบใน streamFormats (บีบอัดได้)
+	Layout   ColumnLayout
+	MongoURI string // ว่าง = ไม่บันทึกลง MongoDB
+}
+
+// streamFinancialData - ดึงข้อมูลและเขียนลงปลายทางทันทีที่หุ้นแต่ละตัวดึงเสร็จ
+// ระหว่างดึงจะเขียนไฟล์ .partial.* ตามลำดับที่ดึงเสร็จ เมื่อดึงครบจึง merge เป็นไฟล์ที่เรียงแล้ว
+// หน่วยความจำที่ใช้ขึ้นกับจำนวนหุ้น (ดัชนี) ไม่ใช่จำนวนข้อมูลทั้งหมด
+func streamFinancialData(opts StreamOptions) (*spoolSink, []FetchError, error) {
+	// เมื่อส่งออกทาง stdout ให้เก็บ spool ไว้ใน temp directory แทน
+	partialBase := opts.Output
+	if isStdout(opts.Output) {
+		partialBase = filepath.Join(os.TempDir(), fmt.Sprintf("stock_financial_data_%d", os.Getpid()))
+	}
+
+	spool, err := newSpoolSink(partialBase + ".spool.jsonl")
+	if err != nil {
+		return nil, nil, err
+	}
+	sinks := []RecordSink{spool}
+
+	closeAll := func() {
+		for _, sink := range sinks {
+			sink.Close()
+		}
+	}
+
+	var partialFiles []string
+	for _, format := range opts.Formats {
+		// stdout มีได้แค่ไฟล์ที่เรียงแล้ว จึงไม่ต้องเขียนไฟล์ระหว่างดึง
+		if isStdout(opts.Output) {
+			continue
+		}
+		name := opts.Output + ".partial" + streamFormats[format.Name]
+		sink, err := newFileSink(name, format.Name, opts.Layout)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		sinks = append(sinks, sink)
+		partialFiles = append(partialFiles, name)
+	}
+
+	if opts.MongoURI != "" {
+		sink, err := newMongoSink(opts.MongoURI, "stock_predict", "financial_data")
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	// เขียนลงทุกปลายทางตามลำดับ ล็อกไว้เพราะถูกเรียกจากหลาย goroutine
+	var mutex sync.Mutex
+	symbolCount, fetchErrors, err := fetchAllFinancialData(func(records []FinancialData) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, sink := range sinks {
+			if err := sink.WriteSymbol(records); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	for _, sink := range sinks {
+		if cerr := sink.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		// เก็บไฟล์ .partial.* ไว้ให้ใช้ข้อมูลที่ดึงได้แล้ว
+		return nil, fetchErrors, err
+	}
+
+	logMsg("fetch.summary", spool.Rows(), symbolCount)
+
+	// merge เป็นไฟล์ที่เรียงตามหุ้นและไตรมาส
+	if err := mergeSpool(spool, opts); err != nil {
+		return nil, fetchErrors, err
+	}
+
+	for _, path := range partialFiles {
+		os.Remove(path)
+	}
+	return spool, fetchErrors, nil
+}
+
+// mergeSpool - สร้างไฟล์ผลลัพธ์ที่เรียงแล้วจาก spool
+func mergeSpool(spool *spoolSink, opts StreamOptions) error {
+	type output struct {
+		name   string
+		file   io.WriteCloser
+		writer recordWriter
+	}
+	var outputs []output
+
+	defer func() {
+		for _, out := range outputs {
+			out.file.Close()
+		}
+	}()
+
+	for _, format := range opts.Formats {
+		name := format.Path(opts.Output, streamFormats[format.Name])
+		file, err := createOutput(name)
+		if err != nil {
+			return errMsg("export.create_file", name, err)
+		}
+		writer, err := newRecordWriter(format.Name, file, opts.Layout)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		outputs = append(outputs, output{name, file, writer})
+	}
+
+	if len(outputs) == 0 {
+		return nil
+	}
+
+	err := spool.Merge(func(records []FinancialData) error {
+		for _, out := range outputs {
+			if err := out.writer.Write(records); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, out := range outputs {
+		if err := out.writer.Flush(); err != nil {
+			return err
+		}
+		if err := out.file.Close(); err != nil {
+			return errMsg("export.save_file", out.name, err)
+		}
+		logMsg("export.done", displayPath(out.name), spool.Rows())
+	}
+	return nil
+}
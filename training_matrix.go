@@ -0,0 +1,714 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/parquet-go/parquet-go"
+)
+
+// กลุ่มของคอลัมน์ใน training matrix
+const (
+	groupFundamental = "fundamental"
+	groupPrice       = "price"
+	groupDerived     = "derived"
+	groupTechnical   = "technical"
+	groupLabel       = "label"
+)
+
+// matrixKeyColumns - คอลัมน์ระบุแถวของ matrix ไม่ใช่ feature
+//
+//	SnapshotDate              วันที่ที่สมมติว่าตัดสินใจ (วันที่ของราคาสิ้นไตรมาส)
+//	FundamentalPeriod         ไตรมาสปฏิทินของงบการเงินล่าสุดที่เผยแพร่แล้ว ณ SnapshotDate
+//	FundamentalAvailableDate  วันที่ถือว่างบนั้นเผยแพร่แล้ว (วันสิ้นงวด + ระยะเวลาส่งงบ)
+//	Split                     train, valid หรือ test ตามวันที่แบ่ง
+var matrixKeyColumns = []string{"Symbol", "FinancialStatementType", "SnapshotDate", "FundamentalPeriod", "FundamentalAvailableDate", "Split"}
+
+// ชื่อของชุดข้อมูลตามจำนวนวันที่แบ่ง
+var splitNames = map[int][]string{
+	0: {"train"},
+	1: {"train", "test"},
+	2: {"train", "valid", "test"},
+}
+
+// MatrixConfig - การตั้งค่าการสร้าง training matrix
+type MatrixConfig struct {
+	QuarterLagDays int         `json:"quarter_lag_days"` // ระยะเวลาส่งงบรายไตรมาสนับจากวันสิ้นงวด
+	AnnualLagDays  int         `json:"annual_lag_days"`  // ระยะเวลาส่งงบปี (ไตรมาส 4 ของปีบัญชี)
+	Horizons       []int       `json:"label_horizons"`
+	Benchmark      string      `json:"benchmark,omitempty"`
+	SplitDates     []time.Time `json:"split_dates,omitempty"` // แถวก่อนวันแรกเป็น train ก่อนวันที่สองเป็น valid ที่เหลือเป็น test
+	EmbargoDays    int         `json:"embargo_days"`          // ตัดแถวที่อยู่ห่างจากวันแบ่งไม่เกินนี้ทิ้ง
+}
+
+// MatrixColumn - คอลัมน์ feature หรือ label หนึ่งคอลัมน์ของ matrix
+type MatrixColumn struct {
+	Name       string `json:"name"`
+	Group      string `json:"group"`
+	Header     string `json:"header"`
+	NonMissing int    `json:"non_missing"`
+
+	value func(src matrixSource) (float64, bool)
+}
+
+// matrixSource - ข้อมูลที่รู้ ณ วันที่ของแถว
+// Item คืองบล่าสุดที่เผยแพร่แล้ว พร้อมราคา ตัวชี้วัดทางเทคนิค และ label ของวันที่นั้น
+type matrixSource struct {
+	Item      FinancialData
+	Snapshot  time.Time
+	PeriodEnd time.Time
+}
+
+// MatrixRow - หนึ่งแถวของ matrix คือหุ้นหนึ่งตัว ณ วันที่หนึ่ง Values เรียงตาม TrainingMatrix.Columns (NaN = ไม่มีค่า)
+type MatrixRow struct {
+	Symbol            string
+	StatementType     string
+	SnapshotDate      time.Time
+	FundamentalPeriod Period
+	AvailableDate     time.Time
+	Split             string
+	Values            []float64
+}
+
+// TrainingMatrix - ข้อมูลพร้อมใช้ฝึกโมเดล แถวเรียงตามวันที่แล้วตามหุ้น
+type TrainingMatrix struct {
+	Config  MatrixConfig
+	Columns []MatrixColumn
+	Rows    []MatrixRow
+	Dropped map[string]int // เหตุผล -> จำนวนแถวที่ตัดทิ้ง
+
+	Impute    *ImputeConfig    // การเติมค่าที่ใช้แล้ว (ดู ImputeMatrix)
+	Transform *TransformConfig // การปรับ feature ที่ใช้แล้ว (ดู TransformMatrix)
+}
+
+// MatrixMetadata - คำอธิบาย matrix ที่เขียนคู่กับไฟล์ข้อมูล (_features.json)
+type MatrixMetadata struct {
+	CreatedAt     time.Time        `json:"created_at"`
+	Sources       []string         `json:"sources"`
+	Config        MatrixConfig     `json:"config"`
+	KeyColumns    []string         `json:"key_columns"`
+	Columns       []MatrixColumn   `json:"columns"`
+	RowCounts     map[string]int   `json:"row_counts"`
+	Dropped       map[string]int   `json:"dropped"`
+	Impute        *ImputeConfig    `json:"impute,omitempty"`
+	Transform     *TransformConfig `json:"transform,omitempty"`
+	FirstSnapshot string           `json:"first_snapshot,omitempty"`
+	LastSnapshot  string           `json:"last_snapshot,omitempty"`
+}
+
+// matrixColumns - feature ทั้งหมดตามลำดับกลุ่ม ต่อด้วย label
+// ใช้ชื่อเดียวกับคอลัมน์ที่ส่งออกได้ (exportColumns) จึงอ่านค่าผ่านตัวเดียวกัน
+func matrixColumns() []MatrixColumn {
+	var columns []MatrixColumn
+	add := func(name, group string) {
+		col := exportColumnsByName[name]
+		columns = append(columns, MatrixColumn{Name: name, Group: group, value: func(src matrixSource) (float64, bool) {
+			v, ok := col.value(src.Item).(float64)
+			return v, ok
+		}})
+	}
+
+	// ตัวเลขจากงบการเงิน (ไม่รวมปี ไตรมาส และ lineage)
+	for _, col := range exportColumns {
+		if _, ok := col.value(FinancialData{}).(float64); ok {
+			add(col.Name, groupFundamental)
+		}
+	}
+	// อายุของงบ ณ วันที่ของแถว ช่วยให้โมเดลแยกงบที่เพิ่งออกกับงบที่เก่าแล้ว
+	columns = append(columns, MatrixColumn{Name: "FundamentalAgeDays", Group: groupFundamental, value: func(src matrixSource) (float64, bool) {
+		return math.Floor(src.Snapshot.Sub(src.PeriodEnd).Hours() / 24), true
+	}})
+
+	priceType := reflect.TypeOf(PriceData{})
+	for i := 0; i < priceType.NumField(); i++ {
+		if fieldKind(priceType.Field(i)) == reflect.Float64 {
+			add("price_"+jsonFieldName(priceType.Field(i)), groupPrice)
+		}
+	}
+	for _, metric := range derivedMetrics {
+		add(derivedColumnPrefix+metric.Name, groupDerived)
+	}
+	for _, indicator := range technicalIndicators {
+		add(technicalColumnPrefix+indicator.Name, groupTechnical)
+	}
+	for _, horizon := range labelHorizons {
+		for _, kind := range labelKinds {
+			add(labelColumnPrefix+labelName(kind, horizon), groupLabel)
+		}
+	}
+
+	for i := range columns {
+		columns[i].Header = columnHeader(columns[i].Name)
+	}
+	return columns
+}
+
+// availableDate - วันที่ถือว่างบเผยแพร่แล้ว = วันสิ้นงวด (DateAsof) + ระยะเวลาส่งงบ
+// API ไม่มีวันที่เผยแพร่จริง จึงใช้กำหนดส่งงบของตลาดหลักทรัพย์เป็นขอบบน (รายไตรมาส 45 วัน งบปี 60 วัน)
+func (c MatrixConfig) availableDate(item FinancialData) (periodEnd, available time.Time) {
+	periodEnd = calendarPeriodOf(item).EndDate()
+	if end, ok := periodEndDateOf(item); ok {
+		periodEnd = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	lag := c.QuarterLagDays
+	if fiscal, ok := fiscalPeriodOf(item); ok && fiscal.Quarter == 4 {
+		lag = c.AnnualLagDays
+	}
+	return periodEnd, periodEnd.AddDate(0, 0, lag)
+}
+
+// split - ชุดข้อมูลของแถวตามวันที่ คืน false เมื่อแถวอยู่ในช่วง embargo ก่อนวันแบ่ง
+// (ผลตอบแทนล่วงหน้าของแถวเหล่านี้คาบเกี่ยวกับช่วงของชุดถัดไป)
+func (c MatrixConfig) split(date time.Time) (string, bool) {
+	names := splitNames[len(c.SplitDates)]
+	for i, boundary := range c.SplitDates {
+		if date.Before(boundary) {
+			if !date.Before(boundary.AddDate(0, 0, -c.EmbargoDays)) {
+				return "", false
+			}
+			return names[i], true
+		}
+	}
+	return names[len(names)-1], true
+}
+
+// matrixSeries - งบของหุ้นและประเภทงบเดียวกันทุกงวดทุกฉบับ
+type matrixSeries struct {
+	periods  []Period                   // เรียงจากใหม่ไปเก่า
+	versions map[Period][]FinancialData // ฉบับของแต่ละงวด เรียงตามเวลาที่ดึงจากเก่าไปใหม่
+}
+
+// versionAsOf - ฉบับของงบที่รู้ ณ วันที่กำหนด
+// ใช้ฉบับล่าสุดที่ดึงมาก่อนหรือในวันนั้น ถ้าทุกฉบับดึงมาหลังจากนั้นใช้ฉบับแรกสุด
+// เพราะใกล้เคียงกับฉบับที่เผยแพร่ครั้งแรกมากที่สุด (API ให้เฉพาะงบฉบับล่าสุดที่แก้ไขแล้ว)
+func versionAsOf(versions []FinancialData, date time.Time) FinancialData {
+	chosen := versions[0]
+	for _, v := range versions[1:] {
+		if fetched, ok := parseAPIDate(v.Lineage.FetchedAt); ok && fetched.After(date) {
+			break
+		}
+		chosen = v
+	}
+	return chosen
+}
+
+// asOf - งบล่าสุดที่เผยแพร่แล้ว ณ วันที่กำหนด และงวดก่อนหน้าที่เผยแพร่แล้ว (เรียงจากใหม่ไปเก่า)
+func (s matrixSeries) asOf(date time.Time, cfg MatrixConfig) []FinancialData {
+	var known []FinancialData
+	for _, p := range s.periods {
+		item := versionAsOf(s.versions[p], date)
+		if _, available := cfg.availableDate(item); available.After(date) {
+			continue
+		}
+		known = append(known, item)
+	}
+	return known
+}
+
+// BuildTrainingMatrix - สร้าง matrix หนึ่งแถวต่อหุ้นต่อวันที่ของงวด (snapshots)
+//
+// snapshots คืองวดที่แนบราคา ตัวชี้วัดทางเทคนิค และ label แล้ว (ค่าเหล่านี้ใช้ราคาถึงวันที่ของงวดเท่านั้น)
+// versions คืองบทุกฉบับที่มี ใช้หางบที่เผยแพร่แล้ว ณ วันที่ของแต่ละแถว งวดของแถวเองมักยังไม่ถึงกำหนดส่งงบ
+// จึงใช้งบของงวดก่อนหน้า ตัวชี้วัดที่คำนวณเพิ่มจะคำนวณใหม่จากงบนั้นกับราคา ณ วันที่ของแถว
+// แถวที่ไม่มีราคา ณ วันที่ของแถวเลยถูกตัดทิ้งและนับใน Dropped["missing_features"]
+func BuildTrainingMatrix(snapshots, versions []FinancialData, cfg MatrixConfig) TrainingMatrix {
+	matrix := TrainingMatrix{
+		Config:  cfg,
+		Columns: matrixColumns(),
+		Dropped: map[string]int{"no_fundamentals": 0, "embargo": 0, "missing_features": 0},
+	}
+
+	type seriesKey struct{ Symbol, StatementType string }
+	series := make(map[seriesKey]*matrixSeries)
+	for _, item := range versions {
+		key := seriesKey{item.Symbol, item.FinancialStatementType}
+		s, ok := series[key]
+		if !ok {
+			s = &matrixSeries{versions: make(map[Period][]FinancialData)}
+			series[key] = s
+		}
+		p := calendarPeriodOf(item)
+		if _, ok := s.versions[p]; !ok {
+			s.periods = append(s.periods, p)
+		}
+		s.versions[p] = append(s.versions[p], item)
+	}
+	for _, s := range series {
+		sort.Slice(s.periods, func(i, j int) bool { return s.periods[j].Before(s.periods[i]) })
+		for _, v := range s.versions {
+			sort.SliceStable(v, func(i, j int) bool { return v[i].Lineage.FetchedAt < v[j].Lineage.FetchedAt })
+		}
+	}
+
+	for _, snap := range snapshots {
+		s, ok := series[seriesKey{snap.Symbol, snap.FinancialStatementType}]
+		if !ok {
+			continue
+		}
+		date := snapshotDate(snap)
+		known := s.asOf(date, cfg)
+		if len(known) == 0 {
+			matrix.Dropped["no_fundamentals"]++
+			continue
+		}
+		split, ok := cfg.split(date)
+		if !ok {
+			matrix.Dropped["embargo"]++
+			continue
+		}
+
+		item := known[0]
+		item.PriceData = snap.PriceData
+		item.Metrics = derivedMetricsOf(item, known[1:])
+		item.Technicals = snap.Technicals
+		item.Labels = snap.Labels
+		periodEnd, available := cfg.availableDate(known[0])
+		src := matrixSource{Item: item, Snapshot: date, PeriodEnd: periodEnd}
+
+		row := MatrixRow{
+			Symbol:            snap.Symbol,
+			StatementType:     snap.FinancialStatementType,
+			SnapshotDate:      date,
+			FundamentalPeriod: calendarPeriodOf(known[0]),
+			AvailableDate:     available,
+			Split:             split,
+			Values:            make([]float64, len(matrix.Columns)),
+		}
+		hasPrice := false
+		for i, col := range matrix.Columns {
+			row.Values[i] = math.NaN()
+			if v, ok := col.value(src); ok && !math.IsNaN(v) && !math.IsInf(v, 0) {
+				row.Values[i] = v
+				hasPrice = hasPrice || col.Group == groupPrice
+			}
+		}
+		if !hasPrice {
+			matrix.Dropped["missing_features"]++
+			continue
+		}
+		for i, v := range row.Values {
+			if !math.IsNaN(v) {
+				matrix.Columns[i].NonMissing++
+			}
+		}
+		matrix.Rows = append(matrix.Rows, row)
+	}
+
+	sort.SliceStable(matrix.Rows, func(i, j int) bool {
+		a, b := matrix.Rows[i], matrix.Rows[j]
+		if !a.SnapshotDate.Equal(b.SnapshotDate) {
+			return a.SnapshotDate.Before(b.SnapshotDate)
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.StatementType < b.StatementType
+	})
+	return matrix
+}
+
+// latestVersions - ฉบับล่าสุดของแต่ละงวด (ตามเวลาที่ดึง) ใช้เป็นงวดของแถวใน matrix
+// ฉบับที่เวลาเท่ากันใช้ฉบับที่มาทีหลังในรายการ
+func latestVersions(versions []FinancialData) []FinancialData {
+	latest := make(map[RecordKey]FinancialData)
+	for _, item := range versions {
+		key := recordKeyOf(item)
+		if prev, ok := latest[key]; !ok || prev.Lineage.FetchedAt <= item.Lineage.FetchedAt {
+			latest[key] = item
+		}
+	}
+	data := make([]FinancialData, 0, len(latest))
+	for _, key := range sortedRecordKeys(latest) {
+		data = append(data, latest[key])
+	}
+	return data
+}
+
+// Metadata - คำอธิบาย matrix สำหรับเขียนเป็น JSON
+func (m TrainingMatrix) Metadata(sources []string, createdAt time.Time) MatrixMetadata {
+	meta := MatrixMetadata{
+		CreatedAt:  createdAt.UTC(),
+		Sources:    sources,
+		Config:     m.Config,
+		KeyColumns: matrixKeyColumns,
+		Columns:    m.Columns,
+		RowCounts:  make(map[string]int),
+		Dropped:    m.Dropped,
+		Impute:     m.Impute,
+		Transform:  m.Transform,
+	}
+	for _, name := range splitNames[len(m.Config.SplitDates)] {
+		meta.RowCounts[name] = 0
+	}
+	for _, row := range m.Rows {
+		meta.RowCounts[row.Split]++
+	}
+	if len(m.Rows) > 0 {
+		meta.FirstSnapshot = m.Rows[0].SnapshotDate.Format("2006-01-02")
+		meta.LastSnapshot = m.Rows[len(m.Rows)-1].SnapshotDate.Format("2006-01-02")
+	}
+	return meta
+}
+
+// keyValues - ค่าของคอลัมน์ระบุแถวตามลำดับ matrixKeyColumns
+func (r MatrixRow) keyValues() []string {
+	return []string{
+		r.Symbol,
+		r.StatementType,
+		r.SnapshotDate.Format("2006-01-02"),
+		r.FundamentalPeriod.String(),
+		r.AvailableDate.Format("2006-01-02"),
+		r.Split,
+	}
+}
+
+// WriteMatrixCSV - เขียน matrix เป็น CSV หัวคอลัมน์เป็นชื่อคอลัมน์ (ไม่แปลภาษา) เพื่อให้โปรแกรมอ่านต่อได้
+// หัวคอลัมน์ตามภาษาอยู่ในไฟล์ metadata ช่องว่างคือไม่มีค่า
+func WriteMatrixCSV(m TrainingMatrix, filename string) error {
+	out, err := createOutput(filename)
+	if err != nil {
+		return errMsg("export.create_file", "CSV", err)
+	}
+	defer out.Close()
+
+	writer := csv.NewWriter(out)
+	header := append([]string{}, matrixKeyColumns...)
+	for _, col := range m.Columns {
+		header = append(header, col.Name)
+	}
+	if err := writer.Write(header); err != nil {
+		return errMsg("export.write_header", err)
+	}
+
+	record := make([]string, len(header))
+	for _, row := range m.Rows {
+		copy(record, row.keyValues())
+		for i, v := range row.Values {
+			record[len(matrixKeyColumns)+i] = ""
+			if !math.IsNaN(v) {
+				record[len(matrixKeyColumns)+i] = exportPrecision.Format(m.Columns[i].Name, v)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return errMsg("export.write_row", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return errMsg("export.write_row", err)
+	}
+	if err := out.Close(); err != nil {
+		return errMsg("export.save_file", "CSV", err)
+	}
+
+	logMsg("export.done", displayPath(filename), len(m.Rows))
+	return nil
+}
+
+// WriteMatrixParquet - เขียน matrix เป็น Parquet ทุก feature เป็น double ที่เป็น null ได้
+// schema สร้างจากรายการคอลัมน์ขณะเขียน จึงเรียงคอลัมน์ตามชื่อ (ลำดับเดิมอยู่ในไฟล์ metadata)
+func WriteMatrixParquet(m TrainingMatrix, filename, compression string) error {
+	codec, err := parquetCodec(compression)
+	if err != nil {
+		return err
+	}
+
+	group := parquet.Group{
+		"Symbol":                   parquet.String(),
+		"FinancialStatementType":   parquet.String(),
+		"SnapshotDate":             parquet.Date(),
+		"FundamentalPeriod":        parquet.String(),
+		"FundamentalAvailableDate": parquet.Date(),
+		"Split":                    parquet.String(),
+	}
+	for _, col := range m.Columns {
+		group[col.Name] = parquet.Optional(parquet.Leaf(parquet.DoubleType))
+	}
+
+	// schema เรียงคอลัมน์ตามชื่อ ค่าในแถวต้องอยู่ตามตำแหน่งคอลัมน์ของ schema
+	schema := parquet.NewSchema("training_matrix", group)
+	leaf := make(map[string]int, len(group))
+	for i, path := range schema.Columns() {
+		leaf[path[0]] = i
+	}
+
+	rows := make([]parquet.Row, len(m.Rows))
+	for i, row := range m.Rows {
+		values := make(parquet.Row, len(leaf))
+		set := func(name string, v any) {
+			values[leaf[name]] = parquet.ValueOf(v).Level(0, 0, leaf[name])
+		}
+		set("Symbol", row.Symbol)
+		set("FinancialStatementType", row.StatementType)
+		set("SnapshotDate", parquetDate(row.SnapshotDate.Format("2006-01-02")))
+		set("FundamentalPeriod", row.FundamentalPeriod.String())
+		set("FundamentalAvailableDate", parquetDate(row.AvailableDate.Format("2006-01-02")))
+		set("Split", row.Split)
+		// definition level 0 = null, 1 = มีค่า
+		for j, col := range m.Columns {
+			idx := leaf[col.Name]
+			values[idx] = parquet.NullValue().Level(0, 0, idx)
+			if v := row.Values[j]; !math.IsNaN(v) {
+				values[idx] = parquet.ValueOf(exportPrecision.Apply(col.Name, v)).Level(0, 1, idx)
+			}
+		}
+		rows[i] = values
+	}
+
+	file, err := createOutput(filename)
+	if err != nil {
+		return errMsg("export.create_file", "Parquet", err)
+	}
+	defer file.Close()
+
+	writer := parquet.NewWriter(file, schema,
+		parquet.Compression(codec),
+		parquet.KeyValueMetadata("stock_predict.schema_version", parquetSchemaVersion),
+		parquet.CreatedBy("stock-predict", parquetSchemaVersion, ""),
+	)
+	if _, err := writer.WriteRows(rows); err != nil {
+		return errMsg("export.parquet_write", err)
+	}
+	if err := writer.Close(); err != nil {
+		return errMsg("export.parquet_close", err)
+	}
+	if err := file.Close(); err != nil {
+		return errMsg("export.save_file", "Parquet", err)
+	}
+
+	logMsg("export.done", displayPath(filename), len(m.Rows))
+	return nil
+}
+
+// WriteMatrixMetadata - เขียนคำอธิบาย matrix เป็น JSON
+func WriteMatrixMetadata(meta MatrixMetadata, filename string) error {
+	body, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return errMsg("matrix.metadata", err)
+	}
+	out, err := createOutput(filename)
+	if err != nil {
+		return errMsg("export.create_file", "JSON", err)
+	}
+	defer out.Close()
+	if _, err := out.Write(append(body, '\n')); err != nil {
+		return errMsg("matrix.metadata", err)
+	}
+	if err := out.Close(); err != nil {
+		return errMsg("export.save_file", "JSON", err)
+	}
+	return nil
+}
+
+// parseSplitDates - อ่านวันที่แบ่งชุดข้อมูล เช่น "2022-01-01,2023-07-01" (ไม่เกินสองวันที่ เรียงจากเก่าไปใหม่)
+func parseSplitDates(spec string) ([]time.Time, error) {
+	var dates []time.Time
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", part)
+		if err != nil {
+			return nil, errMsg("matrix.invalid_split", spec)
+		}
+		dates = append(dates, date)
+	}
+	if len(dates) > 2 || (len(dates) == 2 && !dates[0].Before(dates[1])) {
+		return nil, errMsg("matrix.invalid_split", spec)
+	}
+	return dates, nil
+}
+
+// defaultEmbargoDays - ระยะ embargo เริ่มต้น = ระยะเวลาถือครองที่ยาวที่สุดเป็นวันปฏิทิน
+// เพื่อไม่ให้ผลตอบแทนล่วงหน้าของแถวใน train คาบเกี่ยวกับช่วงของ valid หรือ test
+func defaultEmbargoDays(horizons []int) int {
+	longest := 0
+	for _, h := range horizons {
+		longest = max(longest, h)
+	}
+	return int(math.Ceil(float64(longest) * 365 / tradingDaysPerYear))
+}
+
+// runMatrix - คำสั่ง matrix: stock-predict matrix [flags] <ข้อมูล>...
+// ข้อมูลหลายชุด (เช่น dataset หลายการรัน) ทำให้เลือกงบฉบับที่รู้ ณ วันที่ของแต่ละแถวได้เมื่อมีการแก้ไขงบย้อนหลัง
+func runMatrix(args []string) error {
+	fs := flag.NewFlagSet("matrix", flag.ContinueOnError)
+	output := fs.String("out", "training_matrix", "ชื่อไฟล์ผลลัพธ์ (ไม่ต้องใส่นามสกุล)")
+	formats := fs.String("format", "csv", "รูปแบบไฟล์ คั่นด้วยจุลภาค (csv,parquet) เติม .gz หรือ .zst เพื่อบีบอัด CSV")
+	compression := fs.String("compression", "snappy", "วิธีบีบอัดไฟล์ Parquet (snappy, zstd, gzip, none)")
+	pricesFile := fs.String("prices", "", "ไฟล์ Parquet ราคารายวัน (จาก -price-history) ถ้าไม่ระบุจะดึงจาก API")
+	quarterLag := fs.Int("quarter-lag", 45, "จำนวนวันหลังสิ้นงวดที่ถือว่างบรายไตรมาสเผยแพร่แล้ว")
+	annualLag := fs.Int("annual-lag", 60, "จำนวนวันหลังสิ้นงวดที่ถือว่างบปีเผยแพร่แล้ว")
+	labelHorizonSpec := fs.String("label-horizons", "20,60,120,250", "ระยะเวลาถือครองของ label เป็นวันทำการ คั่นด้วยจุลภาค")
+	benchmark := fs.String("benchmark", "", "หุ้นหรือกองทุนที่ใช้เป็น benchmark ของ label (ค่าเริ่มต้นคือค่าเฉลี่ยของหุ้นทุกตัวที่วันที่ snapshot เดียวกัน)")
+	splitSpec := fs.String("split", "", "วันที่แบ่ง train/valid/test คั่นด้วยจุลภาค เช่น 2022-01-01,2023-07-01 (วันเดียว = train/test)")
+	embargo := fs.Int("embargo", -1, "จำนวนวันก่อนวันแบ่งที่ตัดแถวทิ้ง (ค่าเริ่มต้นคือระยะเวลาถือครองที่ยาวที่สุด)")
+	winsor := fs.String("winsorize", "", "ตัดค่าสุดโต่งของ feature ในแต่ละไตรมาสที่เปอร์เซ็นไทล์ล่าง,บน เช่น 0.01,0.99")
+	normalize := fs.String("normalize", normalizeNone, "ปรับ feature ในแต่ละไตรมาสเป็น rank หรือ zscore (none = ไม่ปรับ)")
+	neutralize := fs.String("neutralize", "", "ปรับ feature เทียบกับกลุ่มในไตรมาสเดียวกัน: sector หรือ size")
+	sizeBuckets := fs.Int("size-buckets", 5, "จำนวนกลุ่มมูลค่าตลาดสำหรับ -neutralize size")
+	transformSpec := fs.String("transform-spec", "", "ไฟล์ _transform.json จากการรันก่อน ปรับ feature ด้วยการตั้งค่าเดียวกัน (แทน -winsorize -normalize -neutralize)")
+	sectorsFile := fs.String("sectors", "", "ไฟล์ CSV หุ้น,กลุ่มอุตสาหกรรม สำหรับ -neutralize sector และ -impute-median-by sector")
+	impute := fs.String("impute", "", "วิธีเติมค่าที่ขาดตามลำดับ คั่นด้วยจุลภาค (ffill, median, drop) เช่น ffill,median")
+	maxStaleness := fs.Int("impute-max-age", 2, "ffill ใช้ค่าที่เก่าไม่เกินกี่ไตรมาส")
+	medianBy := fs.String("impute-median-by", "period", "median คำนวณจากหุ้นทุกตัวในไตรมาส (period) หรือเฉพาะกลุ่มอุตสาหกรรมเดียวกัน (sector)")
+	indicators := fs.Bool("impute-indicators", false, "เพิ่มคอลัมน์ imputed_<ชื่อ> บอกว่าค่าถูกเติม")
+	zeroMissing := fs.Bool("zero-missing", false, "ถือว่าตัวเลขงบการเงินที่เป็นศูนย์คือไม่มีข้อมูล")
+	lang := fs.String("lang", "th", "ภาษาของข้อความ / language (th, en)")
+	buddhistEra := fs.Bool("buddhist-era", false, "แสดงปีเป็นพุทธศักราช / show years in the Buddhist Era")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := SetLocale(*lang, *buddhistEra); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errMsg("matrix.usage")
+	}
+
+	horizons, err := parseLabelHorizons(*labelHorizonSpec)
+	if err != nil {
+		return err
+	}
+	setLabelHorizons(horizons)
+	splits, err := parseSplitDates(*splitSpec)
+	if err != nil {
+		return err
+	}
+	var sectors map[string]string
+	if *sectorsFile != "" {
+		if sectors, err = loadSectors(*sectorsFile); err != nil {
+			return err
+		}
+	}
+	imputation, err := parseImputeConfig(*impute, *maxStaleness, *medianBy, *indicators, *zeroMissing, sectors)
+	if err != nil {
+		return err
+	}
+	transform, err := parseTransformConfig(*winsor, *normalize, *neutralize, *sizeBuckets, sectors)
+	if err != nil {
+		return err
+	}
+	if *transformSpec != "" {
+		spec, err := ReadTransformSpec(*transformSpec)
+		if err != nil {
+			return err
+		}
+		transform = spec.Config
+	}
+	formatList, err := parseOutputFormats(*formats, *output)
+	if err != nil {
+		return err
+	}
+	for _, format := range formatList {
+		if format.Name != "csv" && format.Name != "parquet" {
+			return errMsg("export.unknown_format", format.Name)
+		}
+	}
+
+	cfg := MatrixConfig{
+		QuarterLagDays: *quarterLag,
+		AnnualLagDays:  *annualLag,
+		Horizons:       horizons,
+		Benchmark:      *benchmark,
+		SplitDates:     splits,
+		EmbargoDays:    *embargo,
+	}
+	if cfg.EmbargoDays < 0 {
+		cfg.EmbargoDays = defaultEmbargoDays(horizons)
+	}
+
+	var versions []FinancialData
+	for _, spec := range fs.Args() {
+		data, err := readSnapshot(spec)
+		if err != nil {
+			return err
+		}
+		versions = append(versions, data...)
+	}
+	snapshots := latestVersions(versions)
+
+	history, err := matrixPriceHistory(snapshots, *pricesFile, *benchmark)
+	if err != nil {
+		return err
+	}
+	if *benchmark != "" && !slices.ContainsFunc(history, func(p EODPriceBySymbol) bool { return p.Symbol == *benchmark }) {
+		logMsg("label.benchmark_missing", *benchmark)
+	}
+	attachTechnicals(snapshots, history)
+	attachLabels(snapshots, history, LabelConfig{Horizons: horizons, Benchmark: *benchmark})
+
+	matrix := BuildTrainingMatrix(snapshots, versions, cfg)
+	if len(matrix.Rows) == 0 {
+		return errMsg("export.no_data")
+	}
+	// เติมค่าก่อนปรับ feature เพื่อให้ค่าที่เติมถูกปรับด้วยวิธีเดียวกับค่าจริง
+	if imputation.enabled() {
+		rows := len(matrix.Rows)
+		stats := ImputeMatrix(&matrix, imputation)
+		matrix.Impute = &imputation
+		logImputeSummary(stats, rows)
+		if err := ExportImputeSummary(stats, rows, *output+"_imputation.csv"); err != nil {
+			return err
+		}
+		if len(matrix.Rows) == 0 {
+			return errMsg("export.no_data")
+		}
+	}
+	if transform.enabled() {
+		spec := TransformMatrix(&matrix, transform)
+		matrix.Transform = &transform
+		if err := WriteTransformSpec(spec, *output+"_transform.json"); err != nil {
+			return err
+		}
+	}
+	meta := matrix.Metadata(fs.Args(), time.Now())
+	logMsg("matrix.summary", len(matrix.Rows), len(matrix.Columns), meta.RowCounts["train"], meta.RowCounts["valid"], meta.RowCounts["test"],
+		matrix.Dropped["embargo"], matrix.Dropped["no_fundamentals"], matrix.Dropped["missing_features"])
+
+	for _, format := range formatList {
+		switch format.Name {
+		case "csv":
+			err = WriteMatrixCSV(matrix, format.Path(*output, ".csv"))
+		case "parquet":
+			err = WriteMatrixParquet(matrix, format.Path(*output, ".parquet"), *compression)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return WriteMatrixMetadata(meta, *output+"_features.json")
+}
+
+// matrixPriceHistory - ราคารายวันจากไฟล์ หรือดึงจาก API ตั้งแต่หนึ่งปีก่อนงวดแรก (สำหรับตัวชี้วัดช่วงยาว) ถึงวันนี้
+func matrixPriceHistory(data []FinancialData, pricesFile, benchmark string) ([]EODPriceBySymbol, error) {
+	if pricesFile != "" {
+		return ReadPricesParquet(pricesFile)
+	}
+
+	godotenv.Load()
+	APIKEY = os.Getenv("API_KEY")
+
+	symbols := uniqueSymbols(data)
+	if benchmark != "" && !slices.Contains(symbols, benchmark) {
+		symbols = append(symbols, benchmark)
+	}
+	start := time.Now()
+	for _, item := range data {
+		if date := snapshotDate(item); date.Before(start) {
+			start = date
+		}
+	}
+	start = start.AddDate(-1, 0, 0)
+	return getAllPriceHistory(symbols, start.Format("2006-01-02"), time.Now().Format("2006-01-02"))
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// lagConfig - กำหนดส่งงบรายไตรมาส 45 วัน งบปี 60 วัน
+var lagConfig = MatrixConfig{QuarterLagDays: 45, AnnualLagDays: 60}
+
+func isoDate(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func fetchedVersion(year, quarter int, fetchedAt string, totalAssets float64) FinancialData {
+	item := quarterItem(year, quarter)
+	item.TotalAssets = totalAssets
+	item.Lineage.FetchedAt = fetchedAt
+	return item
+}
+
+func TestAvailableDate(t *testing.T) {
+	// ปีบัญชีสิ้นสุดมีนาคม: ไตรมาส 4 ของปีบัญชี 2024 สิ้นสุด 31 มี.ค. 2025
+	marchYearEnd := quarterItem(2024, 4)
+	marchYearEnd.Calendar = Period{Year: 2025, Quarter: 1}
+	marchYearEnd.DateAsof = "2025-03-31T00:00:00"
+	marchQ1 := quarterItem(2024, 1)
+	marchQ1.Calendar = Period{Year: 2024, Quarter: 2}
+	marchQ1.DateAsof = "2024-06-30T00:00:00"
+
+	tests := []struct {
+		name          string
+		item          FinancialData
+		wantEnd       string
+		wantAvailable string
+	}{
+		{"quarterly lag", quarterItem(2024, 1), "2024-03-31", "2024-05-15"},
+		{"annual lag on fiscal Q4", quarterItem(2023, 4), "2023-12-31", "2024-02-29"},
+		{"DateAsof sets the period end", marchQ1, "2024-06-30", "2024-08-14"},
+		{"annual lag follows the fiscal quarter", marchYearEnd, "2025-03-31", "2025-05-30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, available := lagConfig.availableDate(tt.item)
+			if !end.Equal(isoDate(tt.wantEnd)) || !available.Equal(isoDate(tt.wantAvailable)) {
+				t.Errorf("availableDate = %s, %s; want %s, %s",
+					end.Format("2006-01-02"), available.Format("2006-01-02"), tt.wantEnd, tt.wantAvailable)
+			}
+		})
+	}
+}
+
+func TestVersionAsOf(t *testing.T) {
+	versions := []FinancialData{
+		fetchedVersion(2024, 1, "2024-05-01T00:00:00Z", 1),
+		fetchedVersion(2024, 1, "2024-08-01T00:00:00Z", 2),
+		fetchedVersion(2024, 1, "2024-11-01T00:00:00Z", 3),
+	}
+	tests := []struct {
+		name string
+		date string
+		want float64
+	}{
+		{"before every fetch uses the first version", "2024-04-01", 1},
+		{"latest version fetched before the date", "2024-09-01", 2},
+		{"fetched on the date counts as known", "2024-11-01", 3},
+		{"restatement fetched later is ignored", "2024-10-31", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versionAsOf(versions, isoDate(tt.date)).TotalAssets; got != tt.want {
+				t.Errorf("versionAsOf(%s) = version %v; want %v", tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatrixSeriesAsOf(t *testing.T) {
+	// งบ 2023Q3 เผยแพร่ 14 พ.ย. 2023, งบปี 2023Q4 เผยแพร่ 29 ก.พ. 2024, งบ 2024Q1 เผยแพร่ 15 พ.ค. 2024
+	s := matrixSeries{
+		periods: []Period{{2024, 1}, {2023, 4}, {2023, 3}},
+		versions: map[Period][]FinancialData{
+			{2024, 1}: {quarterItem(2024, 1)},
+			{2023, 4}: {quarterItem(2023, 4)},
+			{2023, 3}: {quarterItem(2023, 3)},
+		},
+	}
+	tests := []struct {
+		name string
+		date string
+		want []Period
+	}{
+		{"own quarter not yet published", "2024-03-29", []Period{{2023, 4}, {2023, 3}}},
+		{"annual statement one day before its deadline", "2024-02-28", []Period{{2023, 3}}},
+		{"annual statement on its deadline", "2024-02-29", []Period{{2023, 4}, {2023, 3}}},
+		{"quarterly statement on its deadline", "2024-05-15", []Period{{2024, 1}, {2023, 4}, {2023, 3}}},
+		{"nothing published yet", "2023-11-13", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Period
+			for _, item := range s.asOf(isoDate(tt.date), lagConfig) {
+				got = append(got, item.Calendar)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("asOf(%s) = %v; want %v", tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatrixSplit(t *testing.T) {
+	cfg := MatrixConfig{SplitDates: []time.Time{isoDate("2023-01-01"), isoDate("2024-01-01")}, EmbargoDays: 30}
+	tests := []struct {
+		name   string
+		cfg    MatrixConfig
+		date   string
+		want   string
+		wantOK bool
+	}{
+		{"no split dates", MatrixConfig{}, "2030-01-01", "train", true},
+		{"train before the embargo", cfg, "2022-12-01", "train", true},
+		// 1 ม.ค. 2023 - 30 วัน = 2 ธ.ค. 2022
+		{"first day of the embargo", cfg, "2022-12-02", "", false},
+		{"last day before the split", cfg, "2022-12-31", "", false},
+		{"valid from the first split date", cfg, "2023-01-01", "valid", true},
+		{"embargo before the second split", cfg, "2023-12-15", "", false},
+		{"test from the second split date", cfg, "2024-01-01", "test", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.cfg.split(isoDate(tt.date))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("split(%s) = %q, %v; want %q, %v", tt.date, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBuildTrainingMatrix(t *testing.T) {
+	snapshot := func(year, quarter int, priceDate string) FinancialData {
+		item := quarterItem(year, quarter)
+		if priceDate != "" {
+			item.PriceData = map[string]interface{}{"price_date": priceDate, "price_close": 10.0}
+		}
+		return item
+	}
+	versions := []FinancialData{
+		// งบปี 2023 ถูกแก้ไขในเดือนสิงหาคม หลังวันที่ของแถวแรก
+		fetchedVersion(2023, 4, "2024-08-01T00:00:00Z", 120),
+		fetchedVersion(2023, 4, "2024-03-01T00:00:00Z", 100),
+		fetchedVersion(2024, 1, "2024-05-20T00:00:00Z", 200),
+	}
+	snapshots := []FinancialData{
+		snapshot(2023, 3, "2023-09-29"), // ยังไม่มีงบที่เผยแพร่
+		snapshot(2024, 1, "2024-03-29"), // งบ 2024Q1 ยังไม่เผยแพร่ ใช้งบปี 2023 ฉบับก่อนแก้ไข
+		snapshot(2024, 2, "2024-06-28"), // อยู่ในช่วง embargo ก่อน 1 ก.ค.
+		snapshot(2024, 3, "2024-09-30"), // ชุด test ใช้งบ 2024Q1
+		snapshot(2024, 4, ""),           // ไม่มีราคา
+	}
+	cfg := lagConfig
+	cfg.SplitDates = []time.Time{isoDate("2024-07-01")}
+	cfg.EmbargoDays = 5
+
+	m := BuildTrainingMatrix(snapshots, versions, cfg)
+
+	wantDropped := map[string]int{"no_fundamentals": 1, "embargo": 1, "missing_features": 1}
+	for reason, want := range wantDropped {
+		if got := m.Dropped[reason]; got != want {
+			t.Errorf("Dropped[%s] = %d; want %d", reason, got, want)
+		}
+	}
+
+	assets := slices.IndexFunc(m.Columns, func(c MatrixColumn) bool { return c.Name == "TotalAssets" })
+	tests := []struct {
+		snapshot    string
+		period      Period
+		available   string
+		split       string
+		totalAssets float64
+	}{
+		{"2024-03-29", Period{2023, 4}, "2024-02-29", "train", 100},
+		{"2024-09-30", Period{2024, 1}, "2024-05-15", "test", 200},
+	}
+	if len(m.Rows) != len(tests) {
+		t.Fatalf("got %d rows; want %d", len(m.Rows), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.snapshot, func(t *testing.T) {
+			row := m.Rows[i]
+			if !row.SnapshotDate.Equal(isoDate(tt.snapshot)) || row.FundamentalPeriod != tt.period ||
+				!row.AvailableDate.Equal(isoDate(tt.available)) || row.Split != tt.split {
+				t.Errorf("row = %s %v %s %s; want %s %v %s %s",
+					row.SnapshotDate.Format("2006-01-02"), row.FundamentalPeriod, row.AvailableDate.Format("2006-01-02"), row.Split,
+					tt.snapshot, tt.period, tt.available, tt.split)
+			}
+			if got := row.Values[assets]; got != tt.totalAssets {
+				t.Errorf("TotalAssets = %v; want %v", got, tt.totalAssets)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// วิธีปรับ feature ภายในแต่ละไตรมาส
+const (
+	normalizeNone   = "none"   // ตัดค่าสุดโต่งอย่างเดียว
+	normalizeRank   = "rank"   // อันดับเป็นสัดส่วน 0..1 (ค่าเท่ากันได้อันดับเฉลี่ย)
+	normalizeZScore = "zscore" // (ค่า - ค่าเฉลี่ย) / ส่วนเบี่ยงเบนมาตรฐาน
+)
+
+// วิธี neutralize: ปรับ feature เทียบกับกลุ่มย่อยภายในไตรมาสแทนทั้งตลาด
+const (
+	neutralizeSector = "sector" // ตามกลุ่มอุตสาหกรรมจากไฟล์ -sectors
+	neutralizeSize   = "size"   // ตามกลุ่มมูลค่าตลาด (price_marketCap) แบ่งเท่า ๆ กัน SizeBuckets กลุ่ม
+)
+
+// TransformConfig - การตั้งค่าการปรับ feature แบบ cross-section
+// ทุกขั้นตอนคำนวณแยกตามไตรมาสปฏิทินของ SnapshotDate จึงไม่ใช้ข้อมูลจากไตรมาสอื่น (ไม่มี lookahead)
+// ไม่ปรับคอลัมน์ label และคอลัมน์ imputed_*
+type TransformConfig struct {
+	WinsorLower float64           `json:"winsor_lower"` // เปอร์เซ็นไทล์ล่างที่ตัด (0 = ไม่ตัด)
+	WinsorUpper float64           `json:"winsor_upper"` // เปอร์เซ็นไทล์บนที่ตัด (1 = ไม่ตัด)
+	Method      string            `json:"method"`
+	Neutralize  string            `json:"neutralize,omitempty"`
+	SizeBuckets int               `json:"size_buckets,omitempty"`
+	Sectors     map[string]string `json:"sectors,omitempty"` // หุ้น -> กลุ่มอุตสาหกรรม
+}
+
+// enabled - มีขั้นตอนใดต้องทำหรือไม่
+func (c TransformConfig) enabled() bool {
+	return c.WinsorLower > 0 || c.WinsorUpper < 1 || c.Method != normalizeNone || c.Neutralize != ""
+}
+
+// TransformStat - ค่าที่ใช้ปรับ feature หนึ่งตัวในกลุ่มหนึ่งของไตรมาสหนึ่ง
+// Lower/Upper คือเกณฑ์ตัดค่าสุดโต่งของทั้งไตรมาส Mean/Std คำนวณหลังตัดค่าภายในกลุ่ม
+type TransformStat struct {
+	Period string  `json:"period"`
+	Bucket string  `json:"bucket,omitempty"`
+	Column string  `json:"column"`
+	Count  int     `json:"count"`
+	Lower  float64 `json:"lower"`
+	Upper  float64 `json:"upper"`
+	Mean   float64 `json:"mean"`
+	Std    float64 `json:"std"`
+}
+
+// TransformSpec - การตั้งค่าและค่าที่ใช้จริง เขียนเป็น _transform.json
+// matrix -transform-spec ใช้ Config นี้กับข้อมูลใหม่ (เช่นไตรมาสล่าสุดที่จะพยากรณ์) เพื่อให้ feature มีความหมายเดียวกับตอนฝึก
+type TransformSpec struct {
+	Config TransformConfig `json:"config"`
+	Stats  []TransformStat `json:"stats"`
+}
+
+// TransformMatrix - ตัดค่าสุดโต่ง ปรับเป็นอันดับหรือ z-score และ neutralize feature ทุกคอลัมน์ (ยกเว้น label) ใน matrix
+//
+//  1. ตัดค่าที่ต่ำกว่า/สูงกว่าเปอร์เซ็นไทล์ WinsorLower/WinsorUpper ของทั้งไตรมาส
+//  2. แบ่งหุ้นในไตรมาสเป็นกลุ่มตาม Neutralize (ไม่ระบุ = กลุ่มเดียว)
+//  3. ปรับค่าภายในกลุ่มตาม Method ถ้า Method เป็น none แต่มี Neutralize จะลบค่าเฉลี่ยของกลุ่มออก
+//
+// ค่าที่ไม่มี (NaN) ยังคงไม่มีค่า
+func TransformMatrix(m *TrainingMatrix, cfg TransformConfig) TransformSpec {
+	spec := TransformSpec{Config: cfg, Stats: []TransformStat{}}
+
+	byPeriod := make(map[Period][]int)
+	for i, row := range m.Rows {
+		p := periodOfDate(row.SnapshotDate)
+		byPeriod[p] = append(byPeriod[p], i)
+	}
+	periods := make([]Period, 0, len(byPeriod))
+	for p := range byPeriod {
+		periods = append(periods, p)
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Before(periods[j]) })
+
+	for _, p := range periods {
+		rows := byPeriod[p]
+		buckets := transformBuckets(m, rows, cfg)
+		for col, column := range m.Columns {
+			if column.Group == groupLabel || column.Group == groupIndicator {
+				continue
+			}
+
+			var values []float64
+			for _, idx := range rows {
+				if v := m.Rows[idx].Values[col]; !math.IsNaN(v) {
+					values = append(values, v)
+				}
+			}
+			if len(values) == 0 {
+				continue
+			}
+			sort.Float64s(values)
+			lower, upper := quantile(values, cfg.WinsorLower), quantile(values, cfg.WinsorUpper)
+			for _, idx := range rows {
+				if v := m.Rows[idx].Values[col]; !math.IsNaN(v) {
+					m.Rows[idx].Values[col] = math.Min(math.Max(v, lower), upper)
+				}
+			}
+
+			for _, bucket := range sortedKeys(buckets) {
+				stat := normalizeGroup(m, buckets[bucket], col, cfg)
+				stat.Period, stat.Bucket, stat.Column = p.String(), bucket, column.Name
+				stat.Lower, stat.Upper = lower, upper
+				if stat.Count > 0 {
+					spec.Stats = append(spec.Stats, stat)
+				}
+			}
+		}
+	}
+	return spec
+}
+
+// transformBuckets - กลุ่มย่อยของแถวในไตรมาสตามวิธี neutralize (ชื่อกลุ่ม -> แถว)
+// หุ้นที่ไม่รู้กลุ่มอุตสาหกรรมหรือไม่มีมูลค่าตลาดอยู่ในกลุ่ม "unknown"
+func transformBuckets(m *TrainingMatrix, rows []int, cfg TransformConfig) map[string][]int {
+	buckets := make(map[string][]int)
+	switch cfg.Neutralize {
+	case neutralizeSector:
+		for _, idx := range rows {
+			sector, ok := cfg.Sectors[m.Rows[idx].Symbol]
+			if !ok || sector == "" {
+				sector = "unknown"
+			}
+			buckets[sector] = append(buckets[sector], idx)
+		}
+	case neutralizeSize:
+		col := m.columnIndex("price_marketCap")
+		var sized []int
+		for _, idx := range rows {
+			if col >= 0 && !math.IsNaN(m.Rows[idx].Values[col]) {
+				sized = append(sized, idx)
+			} else {
+				buckets["unknown"] = append(buckets["unknown"], idx)
+			}
+		}
+		sort.SliceStable(sized, func(a, b int) bool { return m.Rows[sized[a]].Values[col] < m.Rows[sized[b]].Values[col] })
+		n := max(cfg.SizeBuckets, 1)
+		for i, idx := range sized {
+			// กลุ่มที่ 1 คือมูลค่าตลาดเล็กที่สุด
+			name := "size" + strconv.Itoa(i*n/len(sized)+1)
+			buckets[name] = append(buckets[name], idx)
+		}
+	default:
+		buckets[""] = rows
+	}
+	return buckets
+}
+
+// normalizeGroup - ปรับค่าของคอลัมน์ col ในแถวของกลุ่มหนึ่งตามวิธีที่เลือก
+func normalizeGroup(m *TrainingMatrix, rows []int, col int, cfg TransformConfig) TransformStat {
+	var present []int
+	var sum float64
+	for _, idx := range rows {
+		if v := m.Rows[idx].Values[col]; !math.IsNaN(v) {
+			present = append(present, idx)
+			sum += v
+		}
+	}
+	stat := TransformStat{Count: len(present)}
+	if len(present) == 0 {
+		return stat
+	}
+	stat.Mean = sum / float64(len(present))
+	var sq float64
+	for _, idx := range present {
+		d := m.Rows[idx].Values[col] - stat.Mean
+		sq += d * d
+	}
+	stat.Std = math.Sqrt(sq / float64(len(present)))
+
+	switch cfg.Method {
+	case normalizeZScore:
+		for _, idx := range present {
+			z := 0.0
+			if stat.Std > 0 {
+				z = (m.Rows[idx].Values[col] - stat.Mean) / stat.Std
+			}
+			m.Rows[idx].Values[col] = z
+		}
+	case normalizeRank:
+		sort.SliceStable(present, func(a, b int) bool { return m.Rows[present[a]].Values[col] < m.Rows[present[b]].Values[col] })
+		ranks := make([]float64, len(present))
+		for i := 0; i < len(present); {
+			// ค่าที่เท่ากันได้อันดับเฉลี่ยของกลุ่มค่าเท่ากัน
+			j := i
+			for j+1 < len(present) && m.Rows[present[j+1]].Values[col] == m.Rows[present[i]].Values[col] {
+				j++
+			}
+			for k := i; k <= j; k++ {
+				ranks[k] = 0.5
+				if len(present) > 1 {
+					ranks[k] = float64(i+j) / 2 / float64(len(present)-1)
+				}
+			}
+			i = j + 1
+		}
+		for i, idx := range present {
+			m.Rows[idx].Values[col] = ranks[i]
+		}
+	default:
+		if cfg.Neutralize != "" {
+			for _, idx := range present {
+				m.Rows[idx].Values[col] -= stat.Mean
+			}
+		}
+	}
+	return stat
+}
+
+// quantile - เปอร์เซ็นไทล์ q (0..1) ของค่าที่เรียงแล้ว ประมาณค่าเชิงเส้นระหว่างสองค่าที่ใกล้ที่สุด
+func quantile(sorted []float64, q float64) float64 {
+	if q <= 0 {
+		return sorted[0]
+	}
+	if q >= 1 {
+		return sorted[len(sorted)-1]
+	}
+	pos := q * float64(len(sorted)-1)
+	lo := int(pos)
+	if lo+1 >= len(sorted) {
+		return sorted[lo]
+	}
+	return sorted[lo] + (pos-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+// columnIndex - ตำแหน่งของคอลัมน์ใน Values คืน -1 ถ้าไม่มี
+func (m TrainingMatrix) columnIndex(name string) int {
+	for i, col := range m.Columns {
+		if col.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseTransformConfig - อ่านการตั้งค่าจาก command line
+// winsor เช่น "0.01,0.99" (ว่าง = ไม่ตัด), sectors จาก loadSectors ใช้เมื่อ neutralize เป็น sector
+func parseTransformConfig(winsor, method, neutralize string, sizeBuckets int, sectors map[string]string) (TransformConfig, error) {
+	cfg := TransformConfig{WinsorLower: 0, WinsorUpper: 1, Method: strings.ToLower(method), Neutralize: strings.ToLower(neutralize)}
+
+	if winsor != "" {
+		lower, upper, ok := strings.Cut(winsor, ",")
+		lo, err1 := strconv.ParseFloat(strings.TrimSpace(lower), 64)
+		hi, err2 := strconv.ParseFloat(strings.TrimSpace(upper), 64)
+		if !ok || err1 != nil || err2 != nil || lo < 0 || hi > 1 || lo >= hi {
+			return cfg, errMsg("transform.invalid_winsor", winsor)
+		}
+		cfg.WinsorLower, cfg.WinsorUpper = lo, hi
+	}
+
+	switch cfg.Method {
+	case normalizeNone, normalizeRank, normalizeZScore:
+	default:
+		return cfg, errMsg("transform.invalid_method", method)
+	}
+
+	switch cfg.Neutralize {
+	case "":
+	case neutralizeSize:
+		if sizeBuckets < 1 {
+			return cfg, errMsg("transform.invalid_buckets", sizeBuckets)
+		}
+		cfg.SizeBuckets = sizeBuckets
+	case neutralizeSector:
+		if sectors == nil {
+			return cfg, errMsg("transform.sectors_required")
+		}
+		cfg.Sectors = sectors
+	default:
+		return cfg, errMsg("transform.invalid_neutralize", neutralize)
+	}
+	return cfg, nil
+}
+
+// loadSectors - อ่านไฟล์ CSV สองคอลัมน์ หุ้น,กลุ่มอุตสาหกรรม (แถวแรกเป็นหัวคอลัมน์ได้)
+func loadSectors(filename string) (map[string]string, error) {
+	file, err := openInput(filename)
+	if err != nil {
+		return nil, errMsg("import.open_file", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(skipBOM(file))
+	reader.FieldsPerRecord = -1
+	sectors := make(map[string]string)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil || len(record) < 2 {
+			return nil, errMsg("transform.read_sectors", line)
+		}
+		symbol := strings.TrimSpace(record[0])
+		if line == 1 && (strings.EqualFold(symbol, "Symbol") || symbol == columnNames["Symbol"].th) {
+			continue
+		}
+		sectors[symbol] = strings.TrimSpace(record[1])
+	}
+	return sectors, nil
+}
+
+// WriteTransformSpec - เขียนการตั้งค่าและค่าที่ใช้ปรับ feature เป็น JSON
+func WriteTransformSpec(spec TransformSpec, filename string) error {
+	body, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return errMsg("transform.write", err)
+	}
+	if err := os.WriteFile(filename, append(body, '\n'), 0o644); err != nil {
+		return errMsg("transform.write", err)
+	}
+	return nil
+}
+
+// ReadTransformSpec - อ่านไฟล์จาก WriteTransformSpec สำหรับ matrix -transform-spec
+func ReadTransformSpec(filename string) (TransformSpec, error) {
+	var spec TransformSpec
+	body, err := os.ReadFile(filename)
+	if err != nil {
+		return spec, errMsg("transform.read", err)
+	}
+	if err := json.Unmarshal(body, &spec); err != nil {
+		return spec, errMsg("transform.read", err)
+	}
+	return spec, nil
+}
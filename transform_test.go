@@ -0,0 +1,112 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// testMatrix - matrix ทดสอบที่มี feature ตาม names และคอลัมน์ label_y เป็นคอลัมน์สุดท้าย
+func testMatrix(names []string, rows ...MatrixRow) TrainingMatrix {
+	m := TrainingMatrix{Rows: rows, Dropped: map[string]int{}}
+	for _, name := range names {
+		m.Columns = append(m.Columns, MatrixColumn{Name: name, Group: groupFundamental})
+	}
+	m.Columns = append(m.Columns, MatrixColumn{Name: "label_y", Group: groupLabel})
+	return m
+}
+
+// testRow - แถวของหุ้น symbol ณ วันที่ date (yyyy-mm-dd) ค่าสุดท้ายใน values คือ label
+func testRow(symbol, date string, values ...float64) MatrixRow {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		panic(err)
+	}
+	return MatrixRow{Symbol: symbol, StatementType: "C", SnapshotDate: d, Values: values}
+}
+
+// columnValues - ค่าของคอลัมน์ col ทุกแถว
+func columnValues(m TrainingMatrix, col int) []float64 {
+	out := make([]float64, len(m.Rows))
+	for i, row := range m.Rows {
+		out[i] = row.Values[col]
+	}
+	return out
+}
+
+func TestTransformMatrix(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  TransformConfig
+		rows []MatrixRow
+		want []float64
+	}{
+		{
+			// ตัดที่เปอร์เซ็นไทล์ 75 ของ 1,2,3,4,100 = 4 แล้วสองค่าสุดท้ายเท่ากันได้อันดับเฉลี่ย (3+4)/2/4
+			name: "winsorize then rank with ties",
+			cfg:  TransformConfig{WinsorLower: 0, WinsorUpper: 0.75, Method: normalizeRank},
+			rows: []MatrixRow{
+				testRow("A", "2024-03-29", 1, 9), testRow("B", "2024-03-29", 2, 9), testRow("C", "2024-03-29", 3, 9),
+				testRow("D", "2024-03-29", 4, 9), testRow("E", "2024-03-29", 100, 9),
+			},
+			want: []float64{0, 0.25, 0.5, 0.875, 0.875},
+		},
+		{
+			// ค่าเฉลี่ย 2 ส่วนเบี่ยงเบนมาตรฐาน sqrt(2/3) ค่าที่ขาดยังคงขาด
+			name: "zscore keeps missing values",
+			cfg:  TransformConfig{WinsorUpper: 1, Method: normalizeZScore},
+			rows: []MatrixRow{
+				testRow("A", "2024-03-29", 1, 9), testRow("B", "2024-03-29", 2, 9),
+				testRow("C", "2024-03-29", 3, 9), testRow("D", "2024-03-29", math.NaN(), 9),
+			},
+			want: []float64{-math.Sqrt(1.5), 0, math.Sqrt(1.5), math.NaN()},
+		},
+		{
+			name: "sector neutralization removes the sector mean",
+			cfg: TransformConfig{WinsorUpper: 1, Method: normalizeNone, Neutralize: neutralizeSector,
+				Sectors: map[string]string{"A": "bank", "B": "bank", "C": "energy", "D": "energy"}},
+			rows: []MatrixRow{
+				testRow("A", "2024-03-29", 1, 9), testRow("B", "2024-03-29", 3, 9),
+				testRow("C", "2024-03-29", 10, 9), testRow("D", "2024-03-29", 20, 9),
+			},
+			want: []float64{-1, 1, -5, 5},
+		},
+		{
+			name: "each quarter is ranked separately",
+			cfg:  TransformConfig{WinsorUpper: 1, Method: normalizeRank},
+			rows: []MatrixRow{
+				testRow("A", "2024-03-29", 1, 9), testRow("B", "2024-03-29", 2, 9),
+				testRow("A", "2024-06-28", 100, 9), testRow("B", "2024-06-28", 50, 9),
+			},
+			want: []float64{0, 1, 1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testMatrix([]string{"x"}, tt.rows...)
+			TransformMatrix(&m, tt.cfg)
+			if got := columnValues(m, 0); !seriesEqual(got, tt.want) {
+				t.Errorf("x = %v; want %v", got, tt.want)
+			}
+			for _, v := range columnValues(m, 1) {
+				if v != 9 {
+					t.Errorf("label changed to %v", v)
+				}
+			}
+		})
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 100}
+	tests := []struct {
+		q, want float64
+	}{
+		{0, 1}, {0.5, 3}, {0.75, 4}, {0.875, 52}, {1, 100},
+	}
+	for _, tt := range tests {
+		if got := quantile(sorted, tt.q); !approxEqual(got, tt.want) {
+			t.Errorf("quantile(%v) = %v; want %v", tt.q, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// ระดับความรุนแรงของปัญหา
+const (
+	severityError   = "error"   // ข้อมูลขัดกับหลักบัญชี น่าจะผิด
+	severityWarning = "warning" // ข้อมูลแปลกแต่อาจเกิดขึ้นได้จริง
+)
+
+// ValidationConfig - เกณฑ์ความคลาดเคลื่อนที่ยอมรับได้
+type ValidationConfig struct {
+	BalanceTolerance float64 // สัดส่วนของ TotalAssets ที่ยอมให้ สินทรัพย์ ≠ หนี้สิน + ส่วนของผู้ถือหุ้น
+	SumTolerance     float64 // สัดส่วนของค่าสะสมที่ยอมให้ผลรวมรายไตรมาสไม่ตรง
+	RatioTolerance   float64 // สัดส่วนที่ยอมให้อัตราส่วนที่คำนวณใหม่ต่างจากค่าของ API
+	RatioAbsolute    float64 // ผลต่างขั้นต่ำ (หน่วยเดียวกับอัตราส่วน) ที่จะถือว่าไม่ตรง
+}
+
+// defaultValidationConfig - เกณฑ์เริ่มต้น
+// อัตราส่วนของ API อาจใช้ค่าเฉลี่ยหรือข้อมูลย้อนหลัง 12 เดือน จึงยอมให้ต่างได้มากกว่าการตรวจยอดบัญชี
+var defaultValidationConfig = ValidationConfig{
+	BalanceTolerance: 0.01,
+	SumTolerance:     0.01,
+	RatioTolerance:   0.25,
+	RatioAbsolute:    1,
+}
+
+// Violation - ปัญหาหนึ่งรายการที่พบในงบการเงินหนึ่งงวด
+type Violation struct {
+	Symbol   string  `json:"symbol"`
+	Year     string  `json:"year"`
+	Quarter  string  `json:"quarter"`
+	Rule     string  `json:"rule"`
+	Severity string  `json:"severity"`
+	Expected float64 `json:"expected"`
+	Actual   float64 `json:"actual"`
+}
+
+// Detail - คำอธิบายของกฎที่ไม่ผ่าน
+func (v Violation) Detail() string {
+	return T("validate.rule." + v.Rule)
+}
+
+// QualityScore - คะแนนคุณภาพข้อมูลของหุ้นหนึ่งตัว
+// Score = 100 × (น้ำหนักของการตรวจที่ผ่าน / น้ำหนักของการตรวจทั้งหมด) โดยกฎระดับ error มีน้ำหนัก 1 และ warning มีน้ำหนัก 0.5
+// หุ้นที่ไม่ผ่านการตรวจทุกข้อจึงได้ 0 ไม่ว่ากฎเหล่านั้นจะเป็นระดับใด
+type QualityScore struct {
+	Symbol   string  `json:"symbol"`
+	Checks   int     `json:"checks"`
+	Errors   int     `json:"errors"`
+	Warnings int     `json:"warnings"`
+	Score    float64 `json:"score"`
+}
+
+// ValidationReport - ผลการตรวจสอบข้อมูลทั้งหมด
+type ValidationReport struct {
+	Violations []Violation
+	Scores     []QualityScore
+}
+
+// validationRule - กฎการตรวจหนึ่งข้อ คืน false ใน applicable ถ้าข้อมูลไม่พอสำหรับตรวจ
+type validationRule struct {
+	Name     string
+	Severity string
+	Check    func(item FinancialData, cfg ValidationConfig) (expected, actual float64, ok, applicable bool)
+}
+
+// validationRules - กฎที่ตรวจทีละงวด (กฎที่ต้องใช้งวดก่อนหน้าอยู่ใน checkQuarterSums)
+var validationRules = []validationRule{
+	{
+		// สินทรัพย์ = หนี้สิน + ส่วนของผู้ถือหุ้นรวม
+		Name: "balance", Severity: severityError,
+		Check: func(item FinancialData, cfg ValidationConfig) (float64, float64, bool, bool) {
+			if item.TotalAssets == 0 {
+				return 0, 0, false, false
+			}
+			expected := item.TotalLiabilities + item.TotalEquity
+			return expected, item.TotalAssets, withinRel(item.TotalAssets, expected, cfg.BalanceTolerance, 0), true
+		},
+	},
+	{
+		// ส่วนของผู้ถือหุ้นบริษัทใหญ่ต้องไม่เกินส่วนของผู้ถือหุ้นรวม (รวมส่วนได้เสียที่ไม่มีอำนาจควบคุม)
+		Name: "parent_equity", Severity: severityError,
+		Check: func(item FinancialData, cfg ValidationConfig) (float64, float64, bool, bool) {
+			if item.TotalEquity == 0 {
+				return 0, 0, false, false
+			}
+			limit := item.TotalEquity + math.Abs(item.TotalEquity)*cfg.BalanceTolerance
+			return item.TotalEquity, item.ShareholderEquity, item.ShareholderEquity <= limit, true
+		},
+	},
+	{
+		Name: "negative_assets", Severity: severityError,
+		Check: func(item FinancialData, cfg ValidationConfig) (float64, float64, bool, bool) {
+			return 0, item.TotalAssets, item.TotalAssets >= 0, true
+		},
+	},
+	{
+		Name: "negative_liabilities", Severity: severityError,
+		Check: func(item FinancialData, cfg ValidationConfig) (float64, float64, bool, bool) {
+			return 0, item.TotalLiabilities, item.TotalLiabilities >= 0, true
+		},
+	},
+	{
+		Name: "negative_revenue", Severity: severityWarning,
+		Check: func(item FinancialData, cfg ValidationConfig) (float64, float64, bool, bool) {
+			return 0, item.TotalRevenueAccum, item.TotalRevenueAccum >= 0, true
+		},
+	},
+	{
+		// ROE (%) = กำไรสุทธิสะสมปรับเป็นรายปี / ส่วนของผู้ถือหุ้นบริษัทใหญ่
+		Name: "roe", Severity: severityWarning,
+		Check: func(item FinancialData, cfg ValidationConfig) (float64, float64, bool, bool) {
+			annual, ok := annualized(item, item.NetProfitAccum)
+			if !ok || item.Roe == 0 {
+				return 0, 0, false, false
+			}
+			expected, ok := safeDiv(annual*100, item.ShareholderEquity)
+			if !ok {
+				return 0, 0, false, false
+			}
+			return expected, item.Roe, withinRel(item.Roe, expected, cfg.RatioTolerance, cfg.RatioAbsolute), true
+		},
+	},
+	{
+		// ROA (%) = EBIT สะสมปรับเป็นรายปี / สินทรัพย์รวม
+		Name: "roa", Severity: severityWarning,
+		Check: func(item FinancialData, cfg ValidationConfig) (float64, float64, bool, bool) {
+			annual, ok := annualized(item, item.EbitAccum)
+			if !ok || item.Roa == 0 {
+				return 0, 0, false, false
+			}
+			expected, ok := safeDiv(annual*100, item.TotalAssets)
+			if !ok {
+				return 0, 0, false, false
+			}
+			return expected, item.Roa, withinRel(item.Roa, expected, cfg.RatioTolerance, cfg.RatioAbsolute), true
+		},
+	},
+	{
+		// D/E (เท่า) = หนี้สินรวม / ส่วนของผู้ถือหุ้นบริษัทใหญ่
+		Name: "de", Severity: severityWarning,
+		Check: func(item FinancialData, cfg ValidationConfig) (float64, float64, bool, bool) {
+			expected, ok := safeDiv(item.TotalLiabilities, item.ShareholderEquity)
+			if !ok || item.De == 0 {
+				return 0, 0, false, false
+			}
+			return expected, item.De, withinRel(item.De, expected, cfg.RatioTolerance, 0.01), true
+		},
+	},
+	{
+		// อัตรากำไรสุทธิรายไตรมาส (%) = กำไรสุทธิ / รายได้รวม
+		Name: "net_margin", Severity: severityWarning,
+		Check: func(item FinancialData, cfg ValidationConfig) (float64, float64, bool, bool) {
+			expected, ok := safeDiv(item.NetProfitQuarter*100, item.TotalRevenueQuarter)
+			if !ok || item.NetProfitMarginQuarter == 0 {
+				return 0, 0, false, false
+			}
+			return expected, item.NetProfitMarginQuarter, withinRel(item.NetProfitMarginQuarter, expected, cfg.RatioTolerance, cfg.RatioAbsolute), true
+		},
+	},
+	{
+		// บริษัทที่มีรายได้ปกติควรมีกระแสเงินสดจากการดำเนินงานไม่ติดลบมากกว่ารายได้ทั้งหมด
+		Name: "operating_cash_flow", Severity: severityWarning,
+		Check: func(item FinancialData, cfg ValidationConfig) (float64, float64, bool, bool) {
+			if item.TotalRevenueAccum <= 0 {
+				return 0, 0, false, false
+			}
+			return -item.TotalRevenueAccum, item.OperatingCashFlow, item.OperatingCashFlow >= -item.TotalRevenueAccum, true
+		},
+	},
+	{
+		// กระแสเงินสดจากการลงทุนมักติดลบ ถ้าเป็นบวกมากกว่าสินทรัพย์รวมแสดงว่าหน่วยหรือเครื่องหมายผิด
+		Name: "investing_cash_flow", Severity: severityWarning,
+		Check: func(item FinancialData, cfg ValidationConfig) (float64, float64, bool, bool) {
+			if item.TotalAssets <= 0 {
+				return 0, 0, false, false
+			}
+			return item.TotalAssets, item.InvestingCashFlow, item.InvestingCashFlow <= item.TotalAssets, true
+		},
+	},
+}
+
+// quarterSumFields - คู่ของ field รายไตรมาสกับ field สะสมตั้งแต่ต้นปีบัญชี
+var quarterSumFields = []struct {
+	Rule    string
+	Quarter func(FinancialData) float64
+	Accum   func(FinancialData) float64
+}{
+	{"sum_revenue", func(f FinancialData) float64 { return f.TotalRevenueQuarter }, func(f FinancialData) float64 { return f.TotalRevenueAccum }},
+	{"sum_expenses", func(f FinancialData) float64 { return f.TotalExpensesQuarter }, func(f FinancialData) float64 { return f.TotalExpensesAccum }},
+	{"sum_ebit", func(f FinancialData) float64 { return f.EbitQuarter }, func(f FinancialData) float64 { return f.EbitAccum }},
+	{"sum_net_profit", func(f FinancialData) float64 { return f.NetProfitQuarter }, func(f FinancialData) float64 { return f.NetProfitAccum }},
+}
+
+// ValidateFinancialData - ตรวจสอบความถูกต้องของงบการเงินทั้งหมด
+func ValidateFinancialData(data []FinancialData, cfg ValidationConfig) ValidationReport {
+	type tally struct {
+		checks, errors, warnings int
+		weight, failed           float64
+	}
+	tallies := make(map[string]*tally)
+	var violations []Violation
+
+	record := func(item FinancialData, rule, severity string, expected, actual float64, ok bool) {
+		t := tallies[item.Symbol]
+		if t == nil {
+			t = &tally{}
+			tallies[item.Symbol] = t
+		}
+		t.checks++
+		t.weight += severityWeight(severity)
+		if ok {
+			return
+		}
+		t.failed += severityWeight(severity)
+		if severity == severityError {
+			t.errors++
+		} else {
+			t.warnings++
+		}
+		violations = append(violations, Violation{
+			Symbol:   item.Symbol,
+			Year:     item.Year,
+			Quarter:  item.Quarter,
+			Rule:     rule,
+			Severity: severity,
+			Expected: expected,
+			Actual:   actual,
+		})
+	}
+
+	for _, item := range data {
+		for _, rule := range validationRules {
+			expected, actual, ok, applicable := rule.Check(item, cfg)
+			if applicable {
+				record(item, rule.Name, rule.Severity, expected, actual, ok)
+			}
+		}
+	}
+	checkQuarterSums(data, cfg, record)
+
+	var scores []QualityScore
+	for symbol, t := range tallies {
+		score := QualityScore{Symbol: symbol, Checks: t.checks, Errors: t.errors, Warnings: t.warnings, Score: 100}
+		if t.weight > 0 {
+			score.Score = 100 * (1 - t.failed/t.weight)
+		}
+		scores = append(scores, score)
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].Symbol < scores[j].Symbol })
+
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Symbol < violations[j].Symbol })
+	return ValidationReport{Violations: violations, Scores: scores}
+}
+
+// severityWeight - น้ำหนักของกฎในคะแนนคุณภาพ
+func severityWeight(severity string) float64 {
+	if severity == severityWarning {
+		return 0.5
+	}
+	return 1
+}
+
+// checkQuarterSums - ค่าสะสมของไตรมาสนี้ต้องเท่ากับค่าสะสมไตรมาสก่อน + ค่ารายไตรมาส (ไตรมาส 1 ต้องเท่ากับค่ารายไตรมาส)
+// เทียบตามไตรมาสของปีบัญชี เพราะค่าสะสมเริ่มนับใหม่ทุกต้นปีบัญชี
+func checkQuarterSums(data []FinancialData, cfg ValidationConfig, record func(FinancialData, string, string, float64, float64, bool)) {
+	type key struct {
+		Symbol, StatementType string
+		Period                Period
+	}
+	index := make(map[key]FinancialData, len(data))
+	for _, item := range data {
+		if fiscal, ok := fiscalPeriodOf(item); ok {
+			index[key{item.Symbol, item.FinancialStatementType, fiscal}] = item
+		}
+	}
+
+	for _, item := range data {
+		fiscal, ok := fiscalPeriodOf(item)
+		if !ok {
+			continue
+		}
+		prev, hasPrev := index[key{item.Symbol, item.FinancialStatementType, fiscal.Add(-1)}]
+		if fiscal.Quarter != 1 && !hasPrev {
+			continue
+		}
+
+		for _, field := range quarterSumFields {
+			expected := field.Quarter(item)
+			if fiscal.Quarter != 1 {
+				expected += field.Accum(prev)
+			}
+			actual := field.Accum(item)
+			if actual == 0 && expected == 0 {
+				continue
+			}
+			record(item, field.Rule, severityError, expected, actual, withinRel(actual, expected, cfg.SumTolerance, 1))
+		}
+	}
+}
+
+// withinRel - a และ b ต่างกันไม่เกิน rel ของค่าที่ใหญ่กว่า หรือไม่เกิน abs
+func withinRel(a, b, rel, abs float64) bool {
+	diff := math.Abs(a - b)
+	return diff <= abs || diff <= rel*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// annualized - ปรับค่าสะสมตั้งแต่ต้นปีบัญชีเป็นรายปี (ค่าสะสม Q2 × 2 เป็นต้น)
+func annualized(item FinancialData, accum float64) (float64, bool) {
+	q, err := strconv.Atoi(item.Quarter)
+	if err != nil || q < 1 || q > 4 {
+		return 0, false
+	}
+	return accum * 4 / float64(q), true
+}
+
+// violationColumns - คอลัมน์ของรายงานปัญหา
+var violationColumns = []string{"Symbol", "Year", "Quarter", "Rule", "Severity", "Expected", "Actual", "Detail"}
+
+// qualityColumns - คอลัมน์ของรายงานคะแนนคุณภาพ
+var qualityColumns = []string{"Symbol", "Checks", "Errors", "Warnings", "QualityScore"}
+
+// ExportValidationReport - เขียนรายงานปัญหาและคะแนนคุณภาพเป็น CSV สองไฟล์
+func ExportValidationReport(report ValidationReport, violationsFile, scoresFile string) error {
+	rows := make([][]string, len(report.Violations))
+	for i, v := range report.Violations {
+		rows[i] = []string{
+			v.Symbol, displayYearString(v.Year), v.Quarter, v.Rule, T("validate.severity." + v.Severity),
+			exportPrecision.Format("Expected", v.Expected), exportPrecision.Format("Actual", v.Actual), v.Detail(),
+		}
+	}
+	if err := writeReportCSV(violationsFile, violationColumns, rows); err != nil {
+		return err
+	}
+
+	rows = make([][]string, len(report.Scores))
+	for i, s := range report.Scores {
+		rows[i] = []string{
+			s.Symbol, strconv.Itoa(s.Checks), strconv.Itoa(s.Errors), strconv.Itoa(s.Warnings),
+			strconv.FormatFloat(s.Score, 'f', 1, 64),
+		}
+	}
+	return writeReportCSV(scoresFile, qualityColumns, rows)
+}
+
+// logValidationSummary - แสดงสรุปผลการตรวจ และหุ้นที่คะแนนต่ำสุด
+func logValidationSummary(report ValidationReport, worst int) {
+	errors, warnings := 0, 0
+	for _, v := range report.Violations {
+		if v.Severity == severityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	logMsg("validate.summary", len(report.Scores), errors, warnings)
+
+	scores := append([]QualityScore(nil), report.Scores...)
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score < scores[j].Score })
+	for i := 0; i < len(scores) && i < worst; i++ {
+		if scores[i].Score >= 100 {
+			break
+		}
+		logMsg("validate.worst", scores[i].Symbol, fmt.Sprintf("%.1f", scores[i].Score), scores[i].Errors, scores[i].Warnings)
+	}
+}
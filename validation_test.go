@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestQualityScoreWeights(t *testing.T) {
+	// งวดที่เป็นศูนย์ทั้งหมดตรวจได้ 3 กฎ: negative_assets และ negative_liabilities (error น้ำหนัก 1)
+	// กับ negative_revenue (warning น้ำหนัก 0.5) รวมน้ำหนัก 2.5
+	// รายได้ติดลบเพิ่มการตรวจ sum_revenue (error ที่ผ่าน) รวมน้ำหนัก 3.5
+	withRevenue := func(item FinancialData, revenue float64) FinancialData {
+		item.TotalRevenueQuarter = revenue
+		item.TotalRevenueAccum = revenue
+		return item
+	}
+	withLiabilities := func(item FinancialData, liabilities float64) FinancialData {
+		item.TotalLiabilities = liabilities
+		return item
+	}
+	tests := []struct {
+		name         string
+		item         FinancialData
+		wantChecks   int
+		wantErrors   int
+		wantWarnings int
+		wantScore    float64
+	}{
+		{"clean", quarterItem(2024, 1), 3, 0, 0, 100},
+		// ไม่ผ่าน warning ครึ่งหน่วยจาก 3.5
+		{"failed warning", withRevenue(quarterItem(2024, 1), -100), 4, 0, 1, 100 * 3 / 3.5},
+		// ไม่ผ่าน error หนึ่งหน่วยจาก 2.5
+		{"failed error", withLiabilities(quarterItem(2024, 1), -10), 3, 1, 0, 60},
+		// ไม่ผ่าน 1.5 หน่วยจาก 3.5
+		{"failed error and warning", withLiabilities(withRevenue(quarterItem(2024, 1), -100), -10), 4, 1, 1, 100 * 2 / 3.5},
+		// สินทรัพย์ -10 หนี้สิน -20 ไม่ผ่าน balance ด้วย และรายได้สะสมไม่ตรงกับรายไตรมาส ไม่ผ่านทุกข้อจึงได้ 0
+		{"every check failed", FinancialData{
+			Symbol: "AAA", Year: "2024", Quarter: "1", FinancialStatementType: "C", Calendar: Period{Year: 2024, Quarter: 1},
+			TotalAssets: -10, TotalLiabilities: -20, TotalRevenueAccum: -100,
+		}, 5, 4, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := ValidateFinancialData([]FinancialData{tt.item}, defaultValidationConfig)
+			if len(report.Scores) != 1 {
+				t.Fatalf("got %d scores; want 1", len(report.Scores))
+			}
+			got := report.Scores[0]
+			if got.Checks != tt.wantChecks || got.Errors != tt.wantErrors || got.Warnings != tt.wantWarnings {
+				t.Errorf("checks/errors/warnings = %d/%d/%d; want %d/%d/%d",
+					got.Checks, got.Errors, got.Warnings, tt.wantChecks, tt.wantErrors, tt.wantWarnings)
+			}
+			if !approxEqual(got.Score, tt.wantScore) {
+				t.Errorf("score = %v; want %v", got.Score, tt.wantScore)
+			}
+		})
+	}
+}